@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 
 	"channels-instagram-dm/domain"
 	"channels-instagram-dm/presenter/jsonapi"
@@ -31,9 +32,10 @@ type runtimeContext struct {
 	service    domain.Service
 	eventBus   domain.EventBus
 	mq         domain.MQ
+	status     domain.StatusRegistry
 }
 
-func RuntimeContext(withContext context.Context, rep domain.Repository, service domain.Service, logger domain.Logger, mq domain.MQ, eventBus domain.EventBus, syncer domain.Syncer) domain.RuntimeContext {
+func RuntimeContext(withContext context.Context, rep domain.Repository, service domain.Service, logger domain.Logger, mq domain.MQ, eventBus domain.EventBus, syncer domain.Syncer, status domain.StatusRegistry) domain.RuntimeContext {
 	return &runtimeContext{
 		ctx:        withContext,
 		syncer:     syncer,
@@ -42,6 +44,7 @@ func RuntimeContext(withContext context.Context, rep domain.Repository, service
 		service:    service,
 		eventBus:   eventBus,
 		mq:         mq,
+		status:     status,
 	}
 }
 
@@ -53,11 +56,11 @@ func NewSession(req *http.Request) *Session {
 }
 
 func (c *runtimeContext) WithContext(ctx context.Context) domain.RuntimeContext {
-	return RuntimeContext(ctx, c.Repository(), c.Service(), c.Logger(), c.MQ(), c.EventBus(), c.Syncer())
+	return RuntimeContext(ctx, c.Repository(), c.Service(), c.Logger(), c.MQ(), c.EventBus(), c.Syncer(), c.Status())
 }
 
 func (c *runtimeContext) WithLogger(logger domain.Logger) domain.RuntimeContext {
-	return RuntimeContext(c.ctx, c.Repository(), c.Service(), logger, c.MQ(), c.EventBus(), c.Syncer())
+	return RuntimeContext(c.ctx, c.Repository(), c.Service(), logger, c.MQ(), c.EventBus(), c.Syncer(), c.Status())
 }
 
 func (c *runtimeContext) Context() context.Context {
@@ -88,6 +91,10 @@ func (c *runtimeContext) Syncer() domain.Syncer {
 	return c.syncer
 }
 
+func (c *runtimeContext) Status() domain.StatusRegistry {
+	return c.status
+}
+
 type routeHandler func(domain.RuntimeContext, *http.Request) ([]byte, error)
 
 func RouteHandler(rc domain.RuntimeContext, r *mux.Router, path string, handler routeHandler) *mux.Route {
@@ -119,6 +126,7 @@ func RouteHandler(rc domain.RuntimeContext, r *mux.Router, path string, handler
 
 func RespondWithError(w http.ResponseWriter, err error) {
 	httpCode := extractHttpErrorCode(err)
+	setRetryAfter(w, err)
 	presenter := jsonapi.NewErrorPresenter()
 
 	var bs []byte
@@ -139,6 +147,7 @@ func RespondWithJSON(w http.ResponseWriter, code int, payload []byte) {
 
 func RespondWithEmptyError(w http.ResponseWriter, err error) {
 	httpCode := extractHttpErrorCode(err)
+	setRetryAfter(w, err)
 	w.WriteHeader(httpCode)
 	w.Write(nil)
 }
@@ -148,9 +157,42 @@ func extractHttpErrorCode(err error) int {
 		return http.StatusBadRequest
 	}
 
-	if errors.Is(err, domain.ErrorPermissionDenied) {
+	if errors.Is(err, domain.ErrorTargetNotFound) {
+		return http.StatusNotFound
+	}
+
+	if errors.Is(err, domain.ErrorPermissionDenied) || errors.Is(err, domain.ErrorSpamBlocked) {
 		return http.StatusForbidden
 	}
 
+	if errors.Is(err, domain.ErrorNoLoggedIn) || errors.Is(err, domain.ErrorInvalidCredentials) ||
+		errors.Is(err, domain.ErrorCheckpoint) || errors.Is(err, domain.ErrorLoginFailed) ||
+		errors.Is(err, domain.ErrorChallengeFailed) {
+		return http.StatusUnauthorized
+	}
+
+	if errors.Is(err, domain.ErrorRateLimited) {
+		return http.StatusTooManyRequests
+	}
+
+	if errors.Is(err, domain.ErrorProxyFailed) {
+		return http.StatusBadGateway
+	}
+
+	if errors.Is(err, domain.ErrorTimeout) {
+		return http.StatusGatewayTimeout
+	}
+
+	if errors.Is(err, domain.ErrorConnectionLost) {
+		return http.StatusServiceUnavailable
+	}
+
 	return http.StatusInternalServerError
 }
+
+// setRetryAfter Подсказка повтора ошибки библиотеки передается клиенту API
+func setRetryAfter(w http.ResponseWriter, err error) {
+	if retryAfter := domain.RetryAfter(err); retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+	}
+}
@@ -4,17 +4,32 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
+	"time"
 
 	"channels-instagram-dm/domain"
 	"channels-instagram-dm/domain/case/add_account"
+	"channels-instagram-dm/domain/case/assign_slot"
+	"channels-instagram-dm/domain/case/cancel_backfill"
 	"channels-instagram-dm/domain/case/delete_account"
+	"channels-instagram-dm/domain/case/drain_slot"
 	"channels-instagram-dm/domain/case/get_account"
 	"channels-instagram-dm/domain/case/get_activity_log"
 	"channels-instagram-dm/domain/case/get_all_accounts"
+	"channels-instagram-dm/domain/case/get_backfills"
+	"channels-instagram-dm/domain/case/get_pending_conversations"
+	"channels-instagram-dm/domain/case/get_slot_assignments"
+	"channels-instagram-dm/domain/case/get_sync_status"
 	"channels-instagram-dm/domain/case/login"
 	"channels-instagram-dm/domain/case/logout"
+	"channels-instagram-dm/domain/case/migrate_account"
+	"channels-instagram-dm/domain/case/resolve_pending"
 	"channels-instagram-dm/domain/case/resume_account"
+	"channels-instagram-dm/domain/case/set_pending_policy"
+	"channels-instagram-dm/domain/case/set_polling_bounds"
+	"channels-instagram-dm/domain/case/set_slot_mode"
+	"channels-instagram-dm/domain/case/start_backfill"
 	"channels-instagram-dm/domain/case/suspend_account"
+	"channels-instagram-dm/domain/model"
 	"channels-instagram-dm/presenter/jsonapi"
 
 	"github.com/gorilla/mux"
@@ -49,6 +64,91 @@ func RefreshSlots(ctx domain.RuntimeContext, req *http.Request) ([]byte, error)
 	return result, nil
 }
 
+func CordonSlot(runtimeContext domain.RuntimeContext, req *http.Request) ([]byte, error) {
+	vars := mux.Vars(req)
+
+	_, err := set_slot_mode.Run(runtimeContext, set_slot_mode.Request{
+		Host: vars["host"],
+		Mode: model.SlotModeCordoned,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return jsonapi.NewSlotPresenter().
+		MarshalList(runtimeContext.Service().Slots())
+}
+
+func UncordonSlot(runtimeContext domain.RuntimeContext, req *http.Request) ([]byte, error) {
+	vars := mux.Vars(req)
+
+	_, err := set_slot_mode.Run(runtimeContext, set_slot_mode.Request{
+		Host: vars["host"],
+		Mode: model.SlotModeActive,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return jsonapi.NewSlotPresenter().
+		MarshalList(runtimeContext.Service().Slots())
+}
+
+func DrainSlot(runtimeContext domain.RuntimeContext, req *http.Request) ([]byte, error) {
+	vars := mux.Vars(req)
+
+	_, err := drain_slot.Run(runtimeContext, drain_slot.Request{
+		Host: vars["host"],
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return jsonapi.NewSlotPresenter().
+		MarshalList(runtimeContext.Service().Slots())
+}
+
+func GetSlotAssignments(runtimeContext domain.RuntimeContext, req *http.Request) ([]byte, error) {
+	resp, err := get_slot_assignments.Run(runtimeContext, get_slot_assignments.Request{})
+	if err != nil {
+		return nil, err
+	}
+
+	return jsonapi.NewSlotAssignmentPresenter().
+		MarshalList(resp.Assignments)
+}
+
+func AssignSlot(runtimeContext domain.RuntimeContext, req *http.Request) ([]byte, error) {
+	vars := mux.Vars(req)
+
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	defer req.Body.Close()
+
+	data := struct {
+		Host string `json:"host"`
+	}{}
+
+	err = json.Unmarshal(body, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := assign_slot.Run(runtimeContext, assign_slot.Request{
+		ExternalID: vars["external_id"],
+		Host:       data.Host,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return jsonapi.NewSlotAssignmentPresenter().
+		Marshal(resp.Assignment)
+}
+
 func GetAllAccounts(runtimeContext domain.RuntimeContext, req *http.Request) ([]byte, error) {
 	resp, err := get_all_accounts.Run(runtimeContext, get_all_accounts.Request{})
 	if err != nil {
@@ -162,6 +262,39 @@ func ResumeAccount(runtimeContext domain.RuntimeContext, req *http.Request) ([]b
 	return nil, nil
 }
 
+func MigrateAccount(runtimeContext domain.RuntimeContext, req *http.Request) ([]byte, error) {
+	vars := mux.Vars(req)
+
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	defer req.Body.Close()
+
+	// Пустое тело - слот выбирается автоматически
+	data := struct {
+		Host string `json:"host"`
+	}{}
+
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &data); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := migrate_account.Run(runtimeContext, migrate_account.Request{
+		ExternalID: vars["external_id"],
+		Host:       data.Host,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return jsonapi.NewAccountPresenter().
+		Marshal(resp.Account)
+}
+
 func SuspendAccount(runtimeContext domain.RuntimeContext, req *http.Request) ([]byte, error) {
 	vars := mux.Vars(req)
 
@@ -221,3 +354,203 @@ func GetActivityLog(runtimeContext domain.RuntimeContext, req *http.Request) ([]
 
 	return result, nil
 }
+
+func GetPendingConversations(runtimeContext domain.RuntimeContext, req *http.Request) ([]byte, error) {
+	vars := mux.Vars(req)
+
+	resp, err := get_pending_conversations.Run(runtimeContext, get_pending_conversations.Request{
+		ExternalID: vars["external_id"],
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	presenter := jsonapi.NewConversationPresenter()
+	result, err := presenter.MarshalList(resp.Conversations)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func ResolvePending(runtimeContext domain.RuntimeContext, req *http.Request) ([]byte, error) {
+	vars := mux.Vars(req)
+
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	data := struct {
+		Accept  []string `json:"accept"`
+		Decline []string `json:"decline"`
+	}{}
+
+	err = json.Unmarshal(body, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	err = resolve_pending.Run(runtimeContext, resolve_pending.Request{
+		ExternalID: vars["external_id"],
+		Accept:     data.Accept,
+		Decline:    data.Decline,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func SetPendingPolicy(runtimeContext domain.RuntimeContext, req *http.Request) ([]byte, error) {
+	vars := mux.Vars(req)
+
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	data := struct {
+		Mode      string   `json:"mode"`
+		AllowList []string `json:"allow_list"`
+	}{}
+
+	err = json.Unmarshal(body, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := set_pending_policy.Run(runtimeContext, set_pending_policy.Request{
+		ExternalID: vars["external_id"],
+		Policy: model.PendingPolicy{
+			Mode:      model.PendingPolicyMode(data.Mode),
+			AllowList: data.AllowList,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return jsonapi.NewAccountPresenter().
+		Marshal(resp.Account)
+}
+
+func GetBackfills(runtimeContext domain.RuntimeContext, req *http.Request) ([]byte, error) {
+	vars := mux.Vars(req)
+
+	resp, err := get_backfills.Run(runtimeContext, get_backfills.Request{
+		ExternalID: vars["external_id"],
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return jsonapi.NewBackfillPresenter().
+		MarshalList(resp.Backfills)
+}
+
+func StartBackfill(runtimeContext domain.RuntimeContext, req *http.Request) ([]byte, error) {
+	vars := mux.Vars(req)
+
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	data := struct {
+		ConversationID string    `json:"conversation_id"`
+		Depth          int       `json:"depth"`
+		Until          time.Time `json:"until"`
+		Deliver        bool      `json:"deliver"`
+	}{}
+
+	err = json.Unmarshal(body, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Timestamp сообщений Instagram в микросекундах
+	var until int64
+	if !data.Until.IsZero() {
+		until = data.Until.UnixNano() / int64(time.Microsecond)
+	}
+
+	resp, err := start_backfill.Run(runtimeContext, start_backfill.Request{
+		ExternalID:     vars["external_id"],
+		ConversationID: data.ConversationID,
+		Depth:          data.Depth,
+		Until:          until,
+		Deliver:        data.Deliver,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return jsonapi.NewBackfillPresenter().
+		Marshal(resp.Backfill)
+}
+
+func CancelBackfill(runtimeContext domain.RuntimeContext, req *http.Request) ([]byte, error) {
+	vars := mux.Vars(req)
+
+	resp, err := cancel_backfill.Run(runtimeContext, cancel_backfill.Request{
+		ExternalID: vars["external_id"],
+		BackfillID: vars["backfill_id"],
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return jsonapi.NewBackfillPresenter().
+		Marshal(resp.Backfill)
+}
+
+func GetSyncStatus(runtimeContext domain.RuntimeContext, req *http.Request) ([]byte, error) {
+	vars := mux.Vars(req)
+
+	resp, err := get_sync_status.Run(runtimeContext, get_sync_status.Request{
+		ExternalID: vars["external_id"],
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return jsonapi.NewSyncStatusPresenter().
+		Marshal(resp.Account, resp.Running, resp.Status, resp.Undelivered)
+}
+
+func SetPollingBounds(runtimeContext domain.RuntimeContext, req *http.Request) ([]byte, error) {
+	vars := mux.Vars(req)
+
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Границы в секундах
+	data := struct {
+		Min int64 `json:"min"`
+		Max int64 `json:"max"`
+	}{}
+
+	err = json.Unmarshal(body, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := set_polling_bounds.Run(runtimeContext, set_polling_bounds.Request{
+		ExternalID: vars["external_id"],
+		Polling: model.PollingBounds{
+			Min: time.Duration(data.Min) * time.Second,
+			Max: time.Duration(data.Max) * time.Second,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return jsonapi.NewAccountPresenter().
+		Marshal(resp.Account)
+}
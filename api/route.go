@@ -11,6 +11,11 @@ func InitRoutes(ctx domain.RuntimeContext, r *mux.Router) {
 	RouteHandler(ctx, r, "/health", HealthCheck).Methods(http.MethodGet)
 	RouteHandler(ctx, r, "/slots", Slots).Methods(http.MethodGet)
 	RouteHandler(ctx, r, "/slots/refresh", RefreshSlots).Methods(http.MethodPost)
+	RouteHandler(ctx, r, "/slots/cordon/{host}", CordonSlot).Methods(http.MethodPost)
+	RouteHandler(ctx, r, "/slots/uncordon/{host}", UncordonSlot).Methods(http.MethodPost)
+	RouteHandler(ctx, r, "/slots/drain/{host}", DrainSlot).Methods(http.MethodPost)
+	RouteHandler(ctx, r, "/slots/assignments", GetSlotAssignments).Methods(http.MethodGet)
+	RouteHandler(ctx, r, "/slots/assignments/{external_id}", AssignSlot).Methods(http.MethodPost)
 
 	RouteHandler(ctx, r, "/account/all", GetAllAccounts).Methods(http.MethodGet)
 	RouteHandler(ctx, r, "/account/{external_id}", GetAccount).Methods(http.MethodGet)
@@ -22,6 +27,17 @@ func InitRoutes(ctx domain.RuntimeContext, r *mux.Router) {
 
 	RouteHandler(ctx, r, "/account/resume/{external_id}", ResumeAccount).Methods(http.MethodPost)
 	RouteHandler(ctx, r, "/account/suspend/{external_id}", SuspendAccount).Methods(http.MethodPost)
+	RouteHandler(ctx, r, "/account/migrate/{external_id}", MigrateAccount).Methods(http.MethodPost)
 
 	RouteHandler(ctx, r, "/account/activity/{external_id}", GetActivityLog).Methods(http.MethodGet)
+	RouteHandler(ctx, r, "/account/sync/{external_id}", GetSyncStatus).Methods(http.MethodGet)
+	RouteHandler(ctx, r, "/account/polling/{external_id}", SetPollingBounds).Methods(http.MethodPost)
+
+	RouteHandler(ctx, r, "/account/pending/{external_id}", GetPendingConversations).Methods(http.MethodGet)
+	RouteHandler(ctx, r, "/account/pending/{external_id}", ResolvePending).Methods(http.MethodPost)
+	RouteHandler(ctx, r, "/account/pending/policy/{external_id}", SetPendingPolicy).Methods(http.MethodPost)
+
+	RouteHandler(ctx, r, "/account/backfill/{external_id}", GetBackfills).Methods(http.MethodGet)
+	RouteHandler(ctx, r, "/account/backfill/{external_id}", StartBackfill).Methods(http.MethodPost)
+	RouteHandler(ctx, r, "/account/backfill/{external_id}/{backfill_id}", CancelBackfill).Methods(http.MethodDelete)
 }
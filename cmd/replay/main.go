@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"channels-instagram-dm/service/instagram_api"
+)
+
+// Воспроизводит разбор ответов слота из записи RECORD_DIR без подключения к слоту
+// go run ./cmd/replay records/username.rec
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatal("Usage: replay <record file>...")
+	}
+
+	failed := 0
+
+	for _, path := range os.Args[1:] {
+		n, err := replay(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		failed += n
+	}
+
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func replay(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	results, err := instagram_api.Replay(file)
+	if err != nil {
+		return 0, fmt.Errorf("Replay [%s]: Error. %w", path, err)
+	}
+
+	failed := 0
+
+	for _, result := range results {
+		if result.Err == nil {
+			continue
+		}
+
+		failed++
+
+		at := time.Unix(0, result.Frame.At*int64(time.Millisecond)).UTC().Format(time.RFC3339)
+
+		fmt.Printf("%s [%s] [%s] on [%s]: %s\n", at, result.Frame.ID, result.Method, result.Frame.Host, result.Err)
+	}
+
+	fmt.Printf("%s: responses [%d], failed [%d]\n", path, len(results), failed)
+
+	return failed, nil
+}
@@ -0,0 +1,80 @@
+package assign_slot
+
+import (
+	"errors"
+	"fmt"
+
+	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/case/add_activity_log"
+	"channels-instagram-dm/domain/model"
+)
+
+type Request struct {
+	ExternalID string
+	Host       string
+}
+
+type Response struct {
+	Assignment model.SlotAssignment
+}
+
+func validate(req Request) error {
+	if req.ExternalID == "" {
+		return fmt.Errorf("ExternalID should not be empty")
+	}
+
+	if req.Host == "" {
+		return fmt.Errorf("Host should not be empty")
+	}
+
+	return nil
+}
+
+func Run(runtimeContext domain.RuntimeContext, req Request) (Response, error) {
+	runtimeContext.Logger().Info("[assign_slot] Case run", nil)
+
+	resp, err := run(runtimeContext, req)
+	if err != nil {
+		runtimeContext.Logger().Error(fmt.Sprintf("[assign_slot] Case err [%s]", err), nil)
+		return resp, err
+	}
+
+	return resp, nil
+}
+
+func run(runtimeContext domain.RuntimeContext, req Request) (Response, error) {
+	resp := Response{}
+
+	if err := validate(req); err != nil {
+		return resp, domain.NewErrorInvalidArgument(err.Error())
+	}
+
+	credentials, err := runtimeContext.Repository().CredentialsRepository().WhereExternalID(req.ExternalID)
+	if err != nil {
+		return resp, err
+	}
+
+	if err := runtimeContext.Service().AssignSlot(credentials.Username, req.Host); err != nil {
+		return resp, err
+	}
+
+	assignment, err := runtimeContext.Repository().SlotAssignmentRepository().WhereUsername(credentials.Username)
+	if err != nil {
+		return resp, err
+	}
+
+	// Аккаунт может и не существовать
+	account, err := runtimeContext.Repository().AccountRepository().WhereExternalID(req.ExternalID)
+	if err == nil {
+		_, _ = add_activity_log.Run(runtimeContext, add_activity_log.Request{
+			AccountID: account.ID,
+			Log:       fmt.Sprintf("Slot %s was assigned", req.Host),
+		})
+	} else if !errors.Is(err, domain.ErrorNotFound) {
+		return resp, err
+	}
+
+	resp.Assignment = assignment
+
+	return resp, nil
+}
@@ -0,0 +1,87 @@
+package cancel_backfill
+
+import (
+	"fmt"
+
+	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/case/add_activity_log"
+	"channels-instagram-dm/domain/model"
+)
+
+type Request struct {
+	ExternalID string
+	BackfillID string
+}
+
+type Response struct {
+	Backfill model.Backfill
+}
+
+func validate(req Request) error {
+	if req.ExternalID == "" {
+		return fmt.Errorf("ExternalID should not be empty")
+	}
+
+	if req.BackfillID == "" {
+		return fmt.Errorf("BackfillID should not be empty")
+	}
+
+	return nil
+}
+
+func Run(runtimeContext domain.RuntimeContext, req Request) (Response, error) {
+	runtimeContext.Logger().Info("[cancel_backfill] Case run", nil)
+
+	resp, err := run(runtimeContext, req)
+	if err != nil {
+		runtimeContext.Logger().Error(fmt.Sprintf("[cancel_backfill] Case err [%s]", err), nil)
+		return resp, err
+	}
+
+	return resp, nil
+}
+
+// run Помечает загрузку отмененной, выполняющаяся загрузка остановится перед следующим запросом истории
+func run(runtimeContext domain.RuntimeContext, req Request) (Response, error) {
+	resp := Response{}
+
+	if err := validate(req); err != nil {
+		return resp, domain.NewErrorInvalidArgument(err.Error())
+	}
+
+	account, err := runtimeContext.Repository().AccountRepository().WhereExternalID(req.ExternalID)
+	if err != nil {
+		return resp, err
+	}
+
+	backfillRepository := runtimeContext.Repository().BackfillRepository()
+
+	backfill, err := backfillRepository.WhereID(req.BackfillID)
+	if err != nil {
+		return resp, err
+	}
+
+	if backfill.AccountID != account.ID {
+		return resp, domain.NewErrorNotFound(fmt.Sprintf("Backfill [%s] belongs to another account", backfill.ID))
+	}
+
+	if backfill.IsFinished() {
+		return resp, domain.NewErrorInvalidArgument(fmt.Sprintf("Backfill [%s] is already %s", backfill.ID, backfill.Status))
+	}
+
+	backfill.SetStatus(model.BackfillStatusCancelled)
+
+	backfill, err = backfillRepository.Store(backfill)
+	if err != nil {
+		return resp, err
+	}
+
+	_, _ = add_activity_log.Run(runtimeContext, add_activity_log.Request{
+		AccountID: account.ID,
+		Log:       fmt.Sprintf("Backfill %s was cancelled", backfill.ID),
+	})
+
+	resp.Backfill = backfill
+
+	return resp, nil
+}
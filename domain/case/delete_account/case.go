@@ -5,6 +5,7 @@ import (
 
 	"channels-instagram-dm/domain"
 	"channels-instagram-dm/domain/case/add_activity_log"
+	"channels-instagram-dm/domain/case/release_slot"
 	"channels-instagram-dm/domain/model"
 )
 
@@ -65,6 +66,10 @@ func run(runtimeContext domain.RuntimeContext, req Request) error {
 		return err
 	}
 
+	if err := release_slot.Run(runtimeContext, release_slot.Request{Username: credentials.Username}); err != nil {
+		return err
+	}
+
 	_, _ = add_activity_log.Run(runtimeContext, add_activity_log.Request{
 		AccountID: account.ID,
 		Log:       "Account was deleted",
@@ -0,0 +1,84 @@
+package drain_slot
+
+import (
+	"errors"
+	"fmt"
+
+	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/model"
+)
+
+type Request struct {
+	Host string
+}
+
+type Response struct {
+	Slot domain.SlotContainer
+}
+
+func validate(req Request) error {
+	if req.Host == "" {
+		return fmt.Errorf("Host should not be empty")
+	}
+
+	return nil
+}
+
+func Run(runtimeContext domain.RuntimeContext, req Request) (Response, error) {
+	runtimeContext.Logger().Info("[drain_slot] Case run", nil)
+
+	resp, err := run(runtimeContext, req)
+	if err != nil {
+		runtimeContext.Logger().Error(fmt.Sprintf("[drain_slot] Case err [%s]", err), nil)
+		return resp, err
+	}
+
+	return resp, nil
+}
+
+// run Переводит слот в режим вывода из работы и останавливает его аккаунт
+// Запущенный аккаунт останавливается через suspend, слот освобождается при следующем discovery
+func run(runtimeContext domain.RuntimeContext, req Request) (Response, error) {
+	resp := Response{}
+
+	if err := validate(req); err != nil {
+		return resp, domain.NewErrorInvalidArgument(err.Error())
+	}
+
+	slot, err := runtimeContext.Service().SetSlotMode(req.Host, model.SlotModeDraining)
+	if err != nil {
+		return resp, err
+	}
+
+	resp.Slot = slot
+
+	if slot.Username == "" {
+		return resp, nil
+	}
+
+	account, err := runtimeContext.Repository().AccountRepository().WhereUsername(slot.Username)
+	if err != nil && !errors.Is(err, domain.ErrorNotFound) {
+		return resp, err
+	}
+
+	if err == nil {
+		if _, running := runtimeContext.Status().Get(account.ID); running {
+			runtimeContext.EventBus().PublishSuspendAccount(domain.EventSuspendAccount{
+				Reason:  model.AccountStateReasonSlotDrained,
+				Account: account,
+			})
+
+			return resp, nil
+		}
+	}
+
+	if err := runtimeContext.Service().ReleaseSlot(req.Host); err != nil {
+		return resp, err
+	}
+
+	if err := runtimeContext.Service().RefreshSlots(); err != nil {
+		return resp, err
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,60 @@
+package get_backfills
+
+import (
+	"fmt"
+
+	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/model"
+)
+
+const BackfillsLimit = 50
+
+type Request struct {
+	ExternalID string
+}
+
+type Response struct {
+	Backfills []model.Backfill
+}
+
+func validate(req Request) error {
+	if req.ExternalID == "" {
+		return fmt.Errorf("ExternalID should not be empty")
+	}
+
+	return nil
+}
+
+func Run(runtimeContext domain.RuntimeContext, req Request) (Response, error) {
+	runtimeContext.Logger().Info("[get_backfills] Case run", nil)
+
+	resp, err := run(runtimeContext, req)
+	if err != nil {
+		runtimeContext.Logger().Error(fmt.Sprintf("[get_backfills] Case err [%s]", err), nil)
+		return resp, err
+	}
+
+	return resp, nil
+}
+
+func run(runtimeContext domain.RuntimeContext, req Request) (Response, error) {
+	resp := Response{}
+
+	if err := validate(req); err != nil {
+		return resp, domain.NewErrorInvalidArgument(err.Error())
+	}
+
+	account, err := runtimeContext.Repository().AccountRepository().WhereExternalID(req.ExternalID)
+	if err != nil {
+		return resp, err
+	}
+
+	backfills, err := runtimeContext.Repository().BackfillRepository().WhereAccountID(account.ID, BackfillsLimit)
+	if err != nil {
+		return resp, err
+	}
+
+	resp.Backfills = backfills
+
+	return resp, nil
+}
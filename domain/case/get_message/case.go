@@ -0,0 +1,73 @@
+package get_message
+
+import (
+	"errors"
+	"fmt"
+
+	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/model"
+)
+
+type Request struct {
+	ConversationID string
+	MessageID      string // Channels знает сообщения из Channels по своему идентификатору, а сообщения из Instagram - по идентификатору сервиса
+}
+
+type Response struct {
+	Message model.Message
+}
+
+func validate(req Request) error {
+	if req.ConversationID == "" {
+		return fmt.Errorf("ConversationID should not be empty")
+	}
+
+	if req.MessageID == "" {
+		return fmt.Errorf("MessageID should not be empty")
+	}
+
+	return nil
+}
+
+func Run(runtimeContext domain.RuntimeContext, req Request) (Response, error) {
+	runtimeContext.Logger().Info("[get_message] Case run", nil)
+
+	resp, err := run(runtimeContext, req)
+	if err != nil {
+		runtimeContext.Logger().Error(fmt.Sprintf("[get_message] Case err [%s]", err), nil)
+		return resp, err
+	}
+
+	return resp, nil
+}
+
+// run Находит сообщение беседы по идентификатору, под которым его знает Channels
+func run(runtimeContext domain.RuntimeContext, req Request) (Response, error) {
+	resp := Response{}
+
+	if err := validate(req); err != nil {
+		return resp, domain.NewErrorInvalidArgument(err.Error())
+	}
+
+	messageRepository := runtimeContext.Repository().MessageRepository()
+
+	message, err := messageRepository.WhereChannelsAttributeID(req.MessageID)
+	if errors.Is(err, domain.ErrorNotFound) {
+		message, err = messageRepository.WhereID(req.MessageID)
+		if errors.Is(err, domain.ErrorInvalidArgument) {
+			err = domain.NewErrorNotFound(fmt.Sprintf("Message [%s] was not found", req.MessageID))
+		}
+	}
+
+	if err != nil {
+		return resp, err
+	}
+
+	if message.ConversationID != req.ConversationID {
+		return resp, domain.NewErrorNotFound(fmt.Sprintf("Message [%s] belongs to another conversation", req.MessageID))
+	}
+
+	resp.Message = message
+
+	return resp, nil
+}
@@ -0,0 +1,58 @@
+package get_pending_conversations
+
+import (
+	"fmt"
+
+	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/model"
+)
+
+type Request struct {
+	ExternalID string
+}
+
+type Response struct {
+	Conversations []model.Conversation
+}
+
+func validate(req Request) error {
+	if req.ExternalID == "" {
+		return fmt.Errorf("ExternalID should not be empty")
+	}
+
+	return nil
+}
+
+func Run(runtimeContext domain.RuntimeContext, req Request) (Response, error) {
+	runtimeContext.Logger().Info("[get_pending_conversations] Case run", nil)
+
+	resp, err := run(runtimeContext, req)
+	if err != nil {
+		runtimeContext.Logger().Error(fmt.Sprintf("[get_pending_conversations] Case err [%s]", err), nil)
+		return resp, err
+	}
+
+	return resp, nil
+}
+
+func run(runtimeContext domain.RuntimeContext, req Request) (Response, error) {
+	resp := Response{}
+
+	if err := validate(req); err != nil {
+		return resp, domain.NewErrorInvalidArgument(err.Error())
+	}
+
+	account, err := runtimeContext.Repository().AccountRepository().WhereExternalID(req.ExternalID)
+	if err != nil {
+		return resp, err
+	}
+
+	conversations, err := runtimeContext.Repository().ConversationRepository().WhereAccountIDPending(account.ID)
+	if err != nil {
+		return resp, err
+	}
+
+	resp.Conversations = conversations
+
+	return resp, nil
+}
@@ -0,0 +1,39 @@
+package get_slot_assignments
+
+import (
+	"fmt"
+
+	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/model"
+)
+
+type Request struct{}
+
+type Response struct {
+	Assignments []model.SlotAssignment
+}
+
+func Run(runtimeContext domain.RuntimeContext, req Request) (Response, error) {
+	runtimeContext.Logger().Info("[get_slot_assignments] Case run", nil)
+
+	resp, err := run(runtimeContext, req)
+	if err != nil {
+		runtimeContext.Logger().Error(fmt.Sprintf("[get_slot_assignments] Case err [%s]", err), nil)
+		return resp, err
+	}
+
+	return resp, nil
+}
+
+func run(runtimeContext domain.RuntimeContext, req Request) (Response, error) {
+	resp := Response{}
+
+	assignments, err := runtimeContext.Repository().SlotAssignmentRepository().All()
+	if err != nil {
+		return resp, err
+	}
+
+	resp.Assignments = assignments
+
+	return resp, nil
+}
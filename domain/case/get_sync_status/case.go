@@ -0,0 +1,107 @@
+package get_sync_status
+
+import (
+	"fmt"
+
+	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/model"
+)
+
+type Request struct {
+	ExternalID string
+}
+
+type Response struct {
+	Account     model.Account
+	Running     bool
+	Status      domain.SyncStatus
+	Undelivered domain.UndeliveredCount
+}
+
+func validate(req Request) error {
+	if req.ExternalID == "" {
+		return fmt.Errorf("ExternalID should not be empty")
+	}
+
+	return nil
+}
+
+func Run(runtimeContext domain.RuntimeContext, req Request) (Response, error) {
+	runtimeContext.Logger().Info("[get_sync_status] Case run", nil)
+
+	resp, err := run(runtimeContext, req)
+	if err != nil {
+		runtimeContext.Logger().Error(fmt.Sprintf("[get_sync_status] Case err [%s]", err), nil)
+		return resp, err
+	}
+
+	return resp, nil
+}
+
+func run(runtimeContext domain.RuntimeContext, req Request) (Response, error) {
+	resp := Response{}
+
+	if err := validate(req); err != nil {
+		return resp, domain.NewErrorInvalidArgument(err.Error())
+	}
+
+	account, err := runtimeContext.Repository().AccountRepository().WhereExternalID(req.ExternalID)
+	if err != nil {
+		return resp, err
+	}
+
+	resp.Account = account
+	resp.Status, resp.Running = runtimeContext.Status().Get(account.ID)
+
+	undelivered, err := countUndelivered(runtimeContext, account)
+	if err != nil {
+		return resp, err
+	}
+
+	resp.Undelivered = undelivered
+
+	return resp, nil
+}
+
+func countUndelivered(runtimeContext domain.RuntimeContext, account model.Account) (domain.UndeliveredCount, error) {
+	messageRepository := runtimeContext.Repository().MessageRepository()
+
+	count := func(source model.MessageSource, status model.MessageDeliveryStatus) (int64, error) {
+		filter := messageRepository.Filter().
+			WithAccountID(account.ID).
+			WithSource(source)
+
+		return messageRepository.CountDelivered(filter, status)
+	}
+
+	var (
+		result domain.UndeliveredCount
+		err    error
+	)
+
+	if result.InstagramNone, err = count(model.MessageSourceInstagram, model.MessageDeliveryStatusNone); err != nil {
+		return result, err
+	}
+
+	if result.InstagramFailed, err = count(model.MessageSourceInstagram, model.MessageDeliveryStatusFailed); err != nil {
+		return result, err
+	}
+
+	if result.InstagramRejected, err = count(model.MessageSourceInstagram, model.MessageDeliveryStatusRejected); err != nil {
+		return result, err
+	}
+
+	if result.ChannelsNone, err = count(model.MessageSourceChannels, model.MessageDeliveryStatusNone); err != nil {
+		return result, err
+	}
+
+	if result.ChannelsFailed, err = count(model.MessageSourceChannels, model.MessageDeliveryStatusFailed); err != nil {
+		return result, err
+	}
+
+	if result.ChannelsRejected, err = count(model.MessageSourceChannels, model.MessageDeliveryStatusRejected); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
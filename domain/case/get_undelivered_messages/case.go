@@ -8,6 +8,12 @@ import (
 	"channels-instagram-dm/domain/model"
 )
 
+const (
+	// WaitingTimeout Сообщение в ожидании дольше этого времени считается недоставленным (например, процесс был остановлен)
+	// Превышает таймаут отправки медиа
+	WaitingTimeout = 15 * time.Minute
+)
+
 type Request struct {
 	Account model.Account
 }
@@ -51,6 +57,18 @@ func run(runtimeContext domain.RuntimeContext, req Request) (Response, error) {
 		return resp, nil
 	}
 
+	messagesRecent, err = runtimeContext.Repository().MessageRepository().WhereChannelsDeliveredWaitingStaleAt(filter, WaitingTimeout, limit-len(messages))
+	if err != nil {
+		return resp, err
+	}
+
+	messages = append(messages, messagesRecent...)
+
+	if len(messages) == limit {
+		resp.Messages = messages
+		return resp, nil
+	}
+
 	messagesRecent, err = runtimeContext.Repository().MessageRepository().WhereChannelsDeliveredNone(filter, limit-len(messages))
 	if err != nil {
 		return resp, err
@@ -78,6 +96,18 @@ func run(runtimeContext domain.RuntimeContext, req Request) (Response, error) {
 		return resp, nil
 	}
 
+	messagesRecent, err = runtimeContext.Repository().MessageRepository().WhereInstagramDeliveredWaitingStaleAt(filter, WaitingTimeout, limit-len(messages))
+	if err != nil {
+		return resp, err
+	}
+
+	messages = append(messages, messagesRecent...)
+
+	if len(messages) == limit {
+		resp.Messages = messages
+		return resp, nil
+	}
+
 	messagesRecent, err = runtimeContext.Repository().MessageRepository().WhereInstagramDeliveredNone(filter, limit-len(messages))
 	if err != nil {
 		return resp, err
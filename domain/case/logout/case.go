@@ -6,6 +6,7 @@ import (
 
 	"channels-instagram-dm/domain"
 	"channels-instagram-dm/domain/case/add_activity_log"
+	"channels-instagram-dm/domain/case/release_slot"
 )
 
 type Request struct {
@@ -47,14 +48,23 @@ func run(runtimeContext domain.RuntimeContext, req Request) error {
 		return err
 	}
 
+	loggedIn := true
 	if err := api.Logout(); err != nil {
-		if errors.Is(err, domain.ErrorNoLoggedIn) {
-			return nil
+		if !errors.Is(err, domain.ErrorNoLoggedIn) {
+			return err
 		}
 
+		loggedIn = false
+	}
+
+	if err := release_slot.Run(runtimeContext, release_slot.Request{Username: credentials.Username}); err != nil {
 		return err
 	}
 
+	if !loggedIn {
+		return nil
+	}
+
 	// Аккаунт может и не существовать
 	account, err := runtimeContext.Repository().AccountRepository().WhereExternalID(req.ExternalID)
 	if err != nil {
@@ -0,0 +1,85 @@
+package mark_seen
+
+import (
+	"fmt"
+
+	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/case/get_message"
+	"channels-instagram-dm/domain/model"
+)
+
+type Request struct {
+	Account        model.Account
+	ConversationID string
+	MessageID      string // Прочитанное оператором сообщение под идентификатором Channels, пустое - последнее сообщение беседы
+}
+
+func validate(req Request) error {
+	if req.Account.ID == "" {
+		return fmt.Errorf("Account should not be empty")
+	}
+
+	if req.ConversationID == "" {
+		return fmt.Errorf("ConversationID should not be empty")
+	}
+
+	return nil
+}
+
+func Run(runtimeContext domain.RuntimeContext, req Request) error {
+	runtimeContext.Logger().Info("[mark_seen] Case run", nil)
+
+	err := run(runtimeContext, req)
+	if err != nil {
+		runtimeContext.Logger().Error(fmt.Sprintf("[mark_seen] Case err [%s]", err), nil)
+		return err
+	}
+
+	return nil
+}
+
+// run Отмечает тред прочитанным в Instagram до сообщения, прочитанного оператором в Channels
+func run(runtimeContext domain.RuntimeContext, req Request) error {
+	if err := validate(req); err != nil {
+		return domain.NewErrorInvalidArgument(err.Error())
+	}
+
+	conversation, err := runtimeContext.Repository().ConversationRepository().WhereID(req.ConversationID)
+	if err != nil {
+		return err
+	}
+
+	if conversation.AccountID != req.Account.ID {
+		return domain.NewErrorNotFound(fmt.Sprintf("Conversation [%s] belongs to another account", conversation.ID))
+	}
+
+	itemID := conversation.Attributes.ThreadAttributes.LastThreadItemID
+	if req.MessageID != "" {
+		resp, err := get_message.Run(runtimeContext, get_message.Request{
+			ConversationID: conversation.ID,
+			MessageID:      req.MessageID,
+		})
+		if err != nil {
+			return err
+		}
+
+		itemID = resp.Message.Attributes.InstagramAttributes.ID
+	}
+
+	if itemID == "" {
+		return domain.NewErrorInvalidArgument(fmt.Sprintf("Conversation [%s] has no Instagram item to mark seen", conversation.ID))
+	}
+
+	api, err := runtimeContext.Service().InstagramAPI(req.Account.Username)
+	if err != nil {
+		return err
+	}
+
+	if err := api.DirectMarkSeen(conversation.Attributes.ThreadAttributes.ID, itemID); err != nil {
+		domain.ApplyErrorPolicy(runtimeContext, req.Account, err)
+
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,229 @@
+package migrate_account
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/case/add_activity_log"
+	"channels-instagram-dm/domain/case/login"
+	"channels-instagram-dm/domain/case/resume_account"
+	"channels-instagram-dm/domain/case/suspend_account"
+	"channels-instagram-dm/domain/model"
+	"channels-instagram-dm/domain/model/instagram"
+)
+
+const (
+	StopTimeout      = 30 * time.Second       // Ожидание остановки горутин аккаунта
+	StopPollInterval = 500 * time.Millisecond // Интервал проверки остановки
+)
+
+type Request struct {
+	ExternalID string
+	Host       string // Пустое значение - слот выбирается автоматически
+}
+
+type Response struct {
+	Account model.Account
+	Slot    domain.SlotContainer
+}
+
+func validate(req Request) error {
+	if req.ExternalID == "" {
+		return fmt.Errorf("ExternalID should not be empty")
+	}
+
+	return nil
+}
+
+func Run(runtimeContext domain.RuntimeContext, req Request) (Response, error) {
+	runtimeContext.Logger().Info("[migrate_account] Case run", nil)
+
+	resp, err := run(runtimeContext, req)
+	if err != nil {
+		runtimeContext.Logger().Error(fmt.Sprintf("[migrate_account] Case err [%s]", err), nil)
+		return resp, err
+	}
+
+	return resp, nil
+}
+
+// run Останавливает аккаунт, освобождает прежний слот, авторизует аккаунт на новом слоте и запускает его снова
+// При неудачной авторизации аккаунт возвращается на прежний слот
+func run(runtimeContext domain.RuntimeContext, req Request) (Response, error) {
+	resp := Response{}
+
+	if err := validate(req); err != nil {
+		return resp, domain.NewErrorInvalidArgument(err.Error())
+	}
+
+	account, err := runtimeContext.Repository().AccountRepository().WhereExternalID(req.ExternalID)
+	if err != nil {
+		return resp, err
+	}
+
+	if account.StateReason == model.AccountStateReasonMarkedAsDeleted {
+		return resp, fmt.Errorf("Account marked as deleted")
+	}
+
+	credentials, err := runtimeContext.Repository().CredentialsRepository().WhereExternalID(req.ExternalID)
+	if err != nil {
+		return resp, err
+	}
+
+	wasActive := account.State == model.AccountStateActive
+
+	if wasActive {
+		if err := suspend_account.Run(runtimeContext, suspend_account.Request{
+			ExternalID: account.ExternalID,
+			StopReason: model.AccountStateReasonMigration,
+		}); err != nil {
+			return resp, err
+		}
+
+		if err := waitStopped(runtimeContext, account); err != nil {
+			return resp, err
+		}
+
+		activityLog(runtimeContext, account, "Migration: account was stopped")
+	}
+
+	from := currentHost(runtimeContext, credentials.Username)
+
+	if from != "" {
+		logoutSlot(runtimeContext, account, credentials.Username, from)
+	}
+
+	slot, err := runtimeContext.Service().MigrateSlot(credentials.Username, req.Host)
+	if err != nil {
+		activityLog(runtimeContext, account, fmt.Sprintf("Migration: slot was not taken. %s", err))
+
+		return resp, rollback(runtimeContext, account, wasActive, err)
+	}
+
+	activityLog(runtimeContext, account, fmt.Sprintf("Migration: slot %s was taken", slot.Slot.Host))
+
+	loginResp, err := login.Run(runtimeContext, login.Request{
+		AutoLogin: true,
+		Login: instagram.Login{
+			ExternalID: account.ExternalID,
+		},
+	})
+	if err == nil && loginResp.Login.Required.Case != instagram.RequiredStepNone {
+		err = domain.NewErrorNoLoggedIn(fmt.Sprintf("Required is %s", loginResp.Login.Required.Case))
+	}
+
+	if err != nil {
+		activityLog(runtimeContext, account, fmt.Sprintf("Migration: login on slot %s failed. %s", slot.Slot.Host, err))
+
+		if errRelease := runtimeContext.Service().ReleaseSlot(slot.Slot.Host); errRelease != nil {
+			runtimeContext.Logger().Error(fmt.Sprintf("[migrate_account] Failed to release slot %s. %s", slot.Slot.Host, errRelease), nil)
+		}
+
+		if from != "" {
+			if errAssign := runtimeContext.Service().AssignSlot(credentials.Username, from); errAssign != nil {
+				runtimeContext.Logger().Error(fmt.Sprintf("[migrate_account] Failed to assign slot %s. %s", from, errAssign), nil)
+			} else {
+				activityLog(runtimeContext, account, fmt.Sprintf("Migration: rolled back to slot %s", from))
+			}
+		}
+
+		// Прежний слот возвращен аккаунту при закреплении, новый освобождается после discovery
+		if errRefresh := runtimeContext.Service().RefreshSlots(); errRefresh != nil {
+			runtimeContext.Logger().Error(fmt.Sprintf("[migrate_account] Failed to refresh slots. %s", errRefresh), nil)
+		}
+
+		return resp, rollback(runtimeContext, account, wasActive, err)
+	}
+
+	activityLog(runtimeContext, account, fmt.Sprintf("Migration: logged in on slot %s", slot.Slot.Host))
+
+	if wasActive {
+		if err := resume_account.Run(runtimeContext, resume_account.Request{
+			ExternalID: account.ExternalID,
+		}); err != nil {
+			return resp, err
+		}
+	}
+
+	account, err = runtimeContext.Repository().AccountRepository().WhereID(account.ID)
+	if err != nil {
+		return resp, err
+	}
+
+	resp.Account = account
+	resp.Slot = slot
+
+	return resp, nil
+}
+
+// waitStopped Ожидает завершения горутин аккаунта, чтобы они не обращались к слоту во время переноса
+func waitStopped(runtimeContext domain.RuntimeContext, account model.Account) error {
+	timeout := time.NewTimer(StopTimeout)
+	defer timeout.Stop()
+
+	ticker := time.NewTicker(StopPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, running := runtimeContext.Status().Get(account.ID); !running {
+			return nil
+		}
+
+		select {
+		case <-runtimeContext.Context().Done():
+			return runtimeContext.Context().Err()
+		case <-timeout.C:
+			return fmt.Errorf("Account [%s] was not stopped in %s", account.ExternalID, StopTimeout)
+		case <-ticker.C:
+		}
+	}
+}
+
+func currentHost(runtimeContext domain.RuntimeContext, username string) string {
+	for _, sc := range runtimeContext.Service().Slots() {
+		if sc.Username == username {
+			return sc.Slot.Host
+		}
+	}
+
+	return ""
+}
+
+// logoutSlot Завершает сессию на прежнем слоте, неисправный слот будет просто закрыт при переносе
+func logoutSlot(runtimeContext domain.RuntimeContext, account model.Account, username, host string) {
+	api, err := runtimeContext.Service().InstagramAPI(username)
+	if err == nil {
+		err = api.Logout()
+	}
+
+	if err != nil && !errors.Is(err, domain.ErrorNoLoggedIn) {
+		activityLog(runtimeContext, account, fmt.Sprintf("Migration: slot %s will be closed without logout. %s", host, err))
+		return
+	}
+
+	activityLog(runtimeContext, account, fmt.Sprintf("Migration: logged out from slot %s", host))
+}
+
+// rollback Запускает остановленный аккаунт снова, возвращает исходную ошибку переноса
+func rollback(runtimeContext domain.RuntimeContext, account model.Account, wasActive bool, err error) error {
+	if !wasActive {
+		return err
+	}
+
+	if errResume := resume_account.Run(runtimeContext, resume_account.Request{
+		ExternalID: account.ExternalID,
+	}); errResume != nil {
+		runtimeContext.Logger().Error(fmt.Sprintf("[migrate_account] Failed to resume account. %s", errResume), nil)
+	}
+
+	return err
+}
+
+func activityLog(runtimeContext domain.RuntimeContext, account model.Account, log string) {
+	_, _ = add_activity_log.Run(runtimeContext, add_activity_log.Request{
+		AccountID: account.ID,
+		Log:       log,
+	})
+}
@@ -0,0 +1,71 @@
+package release_slot
+
+import (
+	"errors"
+	"fmt"
+
+	"channels-instagram-dm/domain"
+)
+
+type Request struct {
+	Username string
+}
+
+func validate(req Request) error {
+	if req.Username == "" {
+		return fmt.Errorf("Username should not be empty")
+	}
+
+	return nil
+}
+
+func Run(runtimeContext domain.RuntimeContext, req Request) error {
+	runtimeContext.Logger().Info("[release_slot] Case run", nil)
+
+	err := run(runtimeContext, req)
+	if err != nil {
+		runtimeContext.Logger().Error(fmt.Sprintf("[release_slot] Case err [%s]", err), nil)
+		return err
+	}
+
+	return nil
+}
+
+// run Освобождает слот аккаунта и снимает его закрепление
+// Без этого после перезапуска слот остается занят аккаунтом, которого уже нет
+func run(runtimeContext domain.RuntimeContext, req Request) error {
+	if err := validate(req); err != nil {
+		return domain.NewErrorInvalidArgument(err.Error())
+	}
+
+	for _, sc := range runtimeContext.Service().Slots() {
+		if sc.Username != req.Username {
+			continue
+		}
+
+		if err := runtimeContext.Service().ReleaseSlot(sc.Slot.Host); err != nil {
+			return err
+		}
+
+		// Слот возвращается в свободные сразу, не дожидаясь следующего discovery
+		if err := runtimeContext.Service().RefreshSlots(); err != nil {
+			return err
+		}
+
+		break
+	}
+
+	// Закрепление могло остаться и без занятого слота, например после перезапуска
+	assignments := runtimeContext.Repository().SlotAssignmentRepository()
+
+	assignment, err := assignments.WhereUsername(req.Username)
+	if err != nil {
+		if errors.Is(err, domain.ErrorNotFound) {
+			return nil
+		}
+
+		return err
+	}
+
+	return assignments.Release(req.Username, assignment.Host)
+}
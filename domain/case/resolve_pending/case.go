@@ -0,0 +1,148 @@
+package resolve_pending
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/case/add_activity_log"
+	"channels-instagram-dm/domain/model"
+)
+
+type Request struct {
+	ExternalID string
+	Accept     []string // ID тредов, запросы которых принимаются
+	Decline    []string // ID тредов, запросы которых отклоняются
+}
+
+func validate(req Request) error {
+	if req.ExternalID == "" {
+		return fmt.Errorf("ExternalID should not be empty")
+	}
+
+	if len(req.Accept) == 0 && len(req.Decline) == 0 {
+		return fmt.Errorf("Accept or Decline should not be empty")
+	}
+
+	for _, accepted := range req.Accept {
+		for _, declined := range req.Decline {
+			if accepted == declined {
+				return fmt.Errorf("Thread [%s] should not be accepted and declined at once", accepted)
+			}
+		}
+	}
+
+	return nil
+}
+
+func Run(runtimeContext domain.RuntimeContext, req Request) error {
+	runtimeContext.Logger().Info("[resolve_pending] Case run", nil)
+
+	err := run(runtimeContext, req)
+	if err != nil {
+		runtimeContext.Logger().Error(fmt.Sprintf("[resolve_pending] Case err [%s]", err), nil)
+		return err
+	}
+
+	return nil
+}
+
+func run(runtimeContext domain.RuntimeContext, req Request) error {
+	if err := validate(req); err != nil {
+		return domain.NewErrorInvalidArgument(err.Error())
+	}
+
+	account, err := runtimeContext.Repository().AccountRepository().WhereExternalID(req.ExternalID)
+	if err != nil {
+		return err
+	}
+
+	accepted, err := pendingConversations(runtimeContext, account, req.Accept)
+	if err != nil {
+		return err
+	}
+
+	declined, err := pendingConversations(runtimeContext, account, req.Decline)
+	if err != nil {
+		return err
+	}
+
+	api, err := runtimeContext.Service().InstagramAPI(account.Username)
+	if err != nil {
+		return err
+	}
+
+	if len(accepted) != 0 {
+		if err := api.DirectAcceptInboxPending(req.Accept); err != nil {
+			return err
+		}
+
+		if err := storeResolved(runtimeContext, accepted, false); err != nil {
+			return err
+		}
+
+		_, _ = add_activity_log.Run(runtimeContext, add_activity_log.Request{
+			AccountID: account.ID,
+			Log:       "Pending threads was accepted: " + strings.Join(req.Accept, ", "),
+		})
+	}
+
+	if len(declined) != 0 {
+		if err := api.DirectDeclineInboxPending(req.Decline); err != nil {
+			return err
+		}
+
+		if err := storeResolved(runtimeContext, declined, true); err != nil {
+			return err
+		}
+
+		_, _ = add_activity_log.Run(runtimeContext, add_activity_log.Request{
+			AccountID: account.ID,
+			Log:       "Pending threads was declined: " + strings.Join(req.Decline, ", "),
+		})
+	}
+
+	return nil
+}
+
+func pendingConversations(runtimeContext domain.RuntimeContext, account model.Account, threadIDs []string) ([]model.Conversation, error) {
+	conversations := make([]model.Conversation, 0, len(threadIDs))
+
+	for _, threadID := range threadIDs {
+		conversation, err := runtimeContext.Repository().ConversationRepository().WhereAttributeThreadID(threadID)
+		if err != nil {
+			if errors.Is(err, domain.ErrorNotFound) {
+				return nil, domain.NewErrorNotFound(fmt.Sprintf("Pending thread [%s] not found", threadID))
+			}
+
+			return nil, err
+		}
+
+		if conversation.AccountID != account.ID {
+			return nil, domain.NewErrorNotFound(fmt.Sprintf("Pending thread [%s] not found", threadID))
+		}
+
+		if !conversation.Attributes.ThreadAttributes.Pending {
+			return nil, domain.NewErrorInvalidArgument(fmt.Sprintf("Thread [%s] is not pending", threadID))
+		}
+
+		conversations = append(conversations, conversation)
+	}
+
+	return conversations, nil
+}
+
+// storeResolved Отклоненный запрос скрывается из входящих, поэтому беседа помечается архивной
+func storeResolved(runtimeContext domain.RuntimeContext, conversations []model.Conversation, declined bool) error {
+	for _, conversation := range conversations {
+		conversation.Attributes.ThreadAttributes.Pending = false
+		conversation.Attributes.ThreadAttributes.Archived = declined
+
+		if _, err := runtimeContext.Repository().ConversationRepository().Store(conversation); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package send_message
+
+import (
+	"fmt"
+
+	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/model"
+)
+
+type Request struct {
+	Account model.Account
+	Message model.Message
+}
+
+type Response struct {
+	Message model.Message
+}
+
+func validate(req Request) error {
+	if req.Account.ID == "" {
+		return fmt.Errorf("Account should not be empty")
+	}
+
+	if req.Message.ID == "" {
+		return fmt.Errorf("Message should be stored")
+	}
+
+	if req.Message.Source != model.MessageSourceChannels {
+		return fmt.Errorf("Message source should be %s", model.MessageSourceChannels)
+	}
+
+	return nil
+}
+
+func Run(runtimeContext domain.RuntimeContext, req Request) (Response, error) {
+	runtimeContext.Logger().Info("[send_message] Case run", nil)
+
+	resp, err := run(runtimeContext, req)
+	if err != nil {
+		runtimeContext.Logger().Error(fmt.Sprintf("[send_message] Case err [%s]", err), nil)
+		return resp, err
+	}
+
+	return resp, nil
+}
+
+// run Отправляет сообщение из Channels в Instagram и сохраняет статус доставки
+// Ошибка возвращается только если статус доставки не удалось сохранить
+func run(runtimeContext domain.RuntimeContext, req Request) (Response, error) {
+	resp := Response{
+		Message: req.Message,
+	}
+
+	if err := validate(req); err != nil {
+		return resp, domain.NewErrorInvalidArgument(err.Error())
+	}
+
+	messageRepository := runtimeContext.Repository().MessageRepository()
+
+	message := req.Message
+
+	// Статус ожидания исключает сообщение из повторной доставки на время отправки
+	message.DeliveredWaiting()
+
+	message, err := messageRepository.Store(message)
+	if err != nil {
+		return resp, err
+	}
+
+	payload, err := send(runtimeContext, req.Account, message)
+	if err != nil {
+		runtimeContext.Logger().Error(fmt.Sprintf("[send_message] Failed to send message [%s]. %s", message.ID, err), nil)
+
+		domain.ApplyErrorPolicy(runtimeContext, req.Account, err)
+
+		// Повторная отправка не исправит само сообщение, поэтому оно не должно оставаться в очереди
+		if domain.IsPermanentError(err) {
+			message.DeliveredReject()
+		} else {
+			message.DeliveredFail()
+		}
+	} else {
+		// Для медиа payload дополняется размерами, определенными при отправке
+		message.SetPayload(payload)
+		message.DeliveredSuccess()
+	}
+
+	message, err = messageRepository.Store(message)
+	if err != nil {
+		return resp, err
+	}
+
+	resp.Message = message
+
+	return resp, nil
+}
+
+// send Отправляет сообщение и возвращает payload отправленного сообщения
+func send(runtimeContext domain.RuntimeContext, account model.Account, message model.Message) (interface{}, error) {
+	conversation, err := runtimeContext.Repository().ConversationRepository().WhereID(message.ConversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	api, err := runtimeContext.Service().InstagramAPI(account.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	username := conversation.Attributes.UserAttributes.Username
+
+	switch payload := message.Payload.(type) {
+	case model.MessageText:
+		errRealtime := api.RealtimeSendText(conversation.Attributes.ThreadAttributes.ID, message)
+		if errRealtime == nil {
+			return message.Payload, nil
+		}
+
+		runtimeContext.Logger().Info(fmt.Sprintf("[send_message] Realtime send failed, fallback to direct. %s", errRealtime), nil)
+
+		if username == "" {
+			return nil, fmt.Errorf("Username of conversation [%s] is empty. %w", conversation.ID, errRealtime)
+		}
+
+		return message.Payload, api.DirectSendText(username, message)
+	case model.MessageMediaImage, model.MessageMediaVideo, model.MessageMediaVoice:
+		if username == "" {
+			return nil, fmt.Errorf("Username of conversation [%s] is empty", conversation.ID)
+		}
+
+		return sendMedia(api, username, message)
+	case model.MessageLike:
+		if username == "" {
+			return nil, fmt.Errorf("Username of conversation [%s] is empty", conversation.ID)
+		}
+
+		return message.Payload, api.DirectSendLike(username, message)
+	case model.MessageLink:
+		if username == "" {
+			return nil, fmt.Errorf("Username of conversation [%s] is empty", conversation.ID)
+		}
+
+		return message.Payload, api.DirectSendLink(username, message)
+	case model.MessageReaction:
+		if payload.ItemID == "" {
+			return nil, domain.NewErrorInvalidArgument(fmt.Sprintf("Reaction target [%s] has no Instagram item", payload.MessageID))
+		}
+
+		return message.Payload, api.DirectSendReaction(conversation.Attributes.ThreadAttributes.ID, message)
+	default:
+		return nil, domain.NewErrorInvalidArgument(fmt.Sprintf("Message type [%s] is not supported", message.Type))
+	}
+}
+
+func sendMedia(api domain.InstagramAPI, username string, message model.Message) (interface{}, error) {
+	switch message.Payload.(type) {
+	case model.MessageMediaImage:
+		return api.DirectSendPhoto(username, message)
+	case model.MessageMediaVideo:
+		return api.DirectSendVideo(username, message)
+	default:
+		return api.DirectSendVoice(username, message)
+	}
+}
@@ -0,0 +1,73 @@
+package set_polling_bounds
+
+import (
+	"fmt"
+
+	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/case/add_activity_log"
+	"channels-instagram-dm/domain/model"
+)
+
+type Request struct {
+	ExternalID string
+	Polling    model.PollingBounds
+}
+
+type Response struct {
+	Account model.Account
+}
+
+func validate(req Request) error {
+	if req.ExternalID == "" {
+		return fmt.Errorf("ExternalID should not be empty")
+	}
+
+	if err := req.Polling.Validate(); err != nil {
+		return fmt.Errorf("Polling is invalid. %s", err)
+	}
+
+	return nil
+}
+
+func Run(runtimeContext domain.RuntimeContext, req Request) (Response, error) {
+	runtimeContext.Logger().Info("[set_polling_bounds] Case run", nil)
+
+	resp, err := run(runtimeContext, req)
+	if err != nil {
+		runtimeContext.Logger().Error(fmt.Sprintf("[set_polling_bounds] Case err [%s]", err), nil)
+		return resp, err
+	}
+
+	return resp, nil
+}
+
+func run(runtimeContext domain.RuntimeContext, req Request) (Response, error) {
+	resp := Response{}
+
+	if err := validate(req); err != nil {
+		return resp, domain.NewErrorInvalidArgument(err.Error())
+	}
+
+	accountRepository := runtimeContext.Repository().AccountRepository()
+
+	account, err := accountRepository.WhereExternalID(req.ExternalID)
+	if err != nil {
+		return resp, err
+	}
+
+	account.SetPolling(req.Polling)
+
+	account, err = accountRepository.Store(account)
+	if err != nil {
+		return resp, err
+	}
+
+	_, _ = add_activity_log.Run(runtimeContext, add_activity_log.Request{
+		AccountID: account.ID,
+		Log:       fmt.Sprintf("Polling bounds were changed to [%s, %s]", req.Polling.Min, req.Polling.Max),
+	})
+
+	resp.Account = account
+
+	return resp, nil
+}
@@ -0,0 +1,59 @@
+package set_slot_mode
+
+import (
+	"fmt"
+
+	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/model"
+)
+
+type Request struct {
+	Host string
+	Mode model.SlotMode
+}
+
+type Response struct {
+	Slot domain.SlotContainer
+}
+
+func validate(req Request) error {
+	if req.Host == "" {
+		return fmt.Errorf("Host should not be empty")
+	}
+
+	// Вывод слота из работы выполняется через drain_slot
+	if req.Mode != model.SlotModeActive && req.Mode != model.SlotModeCordoned {
+		return fmt.Errorf("Mode [%s] is unsupported", req.Mode)
+	}
+
+	return nil
+}
+
+func Run(runtimeContext domain.RuntimeContext, req Request) (Response, error) {
+	runtimeContext.Logger().Info("[set_slot_mode] Case run", nil)
+
+	resp, err := run(runtimeContext, req)
+	if err != nil {
+		runtimeContext.Logger().Error(fmt.Sprintf("[set_slot_mode] Case err [%s]", err), nil)
+		return resp, err
+	}
+
+	return resp, nil
+}
+
+func run(runtimeContext domain.RuntimeContext, req Request) (Response, error) {
+	resp := Response{}
+
+	if err := validate(req); err != nil {
+		return resp, domain.NewErrorInvalidArgument(err.Error())
+	}
+
+	slot, err := runtimeContext.Service().SetSlotMode(req.Host, req.Mode)
+	if err != nil {
+		return resp, err
+	}
+
+	resp.Slot = slot
+
+	return resp, nil
+}
@@ -0,0 +1,117 @@
+package start_backfill
+
+import (
+	"fmt"
+
+	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/case/add_activity_log"
+	"channels-instagram-dm/domain/model"
+)
+
+type Request struct {
+	ExternalID     string
+	ConversationID string // Пустое значение - загрузка всех бесед аккаунта
+	Depth          int
+	Until          int64
+	Deliver        bool
+}
+
+type Response struct {
+	Backfill model.Backfill
+}
+
+func validate(req Request) error {
+	if req.ExternalID == "" {
+		return fmt.Errorf("ExternalID should not be empty")
+	}
+
+	if req.Depth < 0 {
+		return fmt.Errorf("Depth should not be negative")
+	}
+
+	if req.Until < 0 {
+		return fmt.Errorf("Until should not be negative")
+	}
+
+	return nil
+}
+
+func Run(runtimeContext domain.RuntimeContext, req Request) (Response, error) {
+	runtimeContext.Logger().Info("[start_backfill] Case run", nil)
+
+	resp, err := run(runtimeContext, req)
+	if err != nil {
+		runtimeContext.Logger().Error(fmt.Sprintf("[start_backfill] Case err [%s]", err), nil)
+		return resp, err
+	}
+
+	return resp, nil
+}
+
+// run Ставит загрузку истории в очередь аккаунта
+// Загрузка выполняется, пока аккаунт активен, и продолжается после его возобновления
+func run(runtimeContext domain.RuntimeContext, req Request) (Response, error) {
+	resp := Response{}
+
+	if err := validate(req); err != nil {
+		return resp, domain.NewErrorInvalidArgument(err.Error())
+	}
+
+	account, err := runtimeContext.Repository().AccountRepository().WhereExternalID(req.ExternalID)
+	if err != nil {
+		return resp, err
+	}
+
+	if req.ConversationID != "" {
+		conversation, err := runtimeContext.Repository().ConversationRepository().WhereID(req.ConversationID)
+		if err != nil {
+			return resp, err
+		}
+
+		if conversation.AccountID != account.ID {
+			return resp, domain.NewErrorNotFound(fmt.Sprintf("Conversation [%s] belongs to another account", conversation.ID))
+		}
+	}
+
+	backfillRepository := runtimeContext.Repository().BackfillRepository()
+
+	active, err := backfillRepository.WhereAccountIDStatus(account.ID, model.BackfillStatusQueued, model.BackfillStatusRunning)
+	if err != nil {
+		return resp, err
+	}
+
+	for _, backfill := range active {
+		if backfill.ConversationID == req.ConversationID {
+			return resp, domain.NewErrorInvalidArgument(fmt.Sprintf("Backfill [%s] is already %s", backfill.ID, backfill.Status))
+		}
+	}
+
+	backfill := model.NewBackfill(account.ID)
+	backfill.ConversationID = req.ConversationID
+	backfill.Depth = req.Depth
+	backfill.Until = req.Until
+	backfill.Deliver = req.Deliver
+
+	if err := backfill.Validate(); err != nil {
+		return resp, domain.NewErrorInvalidArgument(err.Error())
+	}
+
+	backfill, err = backfillRepository.Store(backfill)
+	if err != nil {
+		return resp, err
+	}
+
+	_, _ = add_activity_log.Run(runtimeContext, add_activity_log.Request{
+		AccountID: account.ID,
+		Log:       fmt.Sprintf("Backfill %s was queued", backfill.ID),
+	})
+
+	runtimeContext.EventBus().PublishStartBackfill(domain.EventStartBackfill{
+		Account:  account,
+		Backfill: backfill,
+	})
+
+	resp.Backfill = backfill
+
+	return resp, nil
+}
@@ -76,6 +76,16 @@ func run(runtimeContext domain.RuntimeContext, req Request) error {
 		log = "challenge required"
 	case model.AccountStateReasonMarkedAsDeleted:
 		log = "marked as deleted"
+	case model.AccountStateReasonSlotDrained:
+		log = "slot drained"
+	case model.AccountStateReasonMigration:
+		log = "migration"
+	case model.AccountStateReasonRateLimited:
+		log = "rate limited"
+	case model.AccountStateReasonSpamBlocked:
+		log = "spam blocked"
+	case model.AccountStateReasonProxyFailed:
+		log = "proxy failed"
 	default:
 		log = account.StateReason
 	}
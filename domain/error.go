@@ -3,6 +3,7 @@ package domain
 import (
 	"errors"
 	"fmt"
+	"time"
 )
 
 var (
@@ -13,6 +14,13 @@ var (
 	ErrorInvalidCredentials = errors.New("Invalid credentials")
 	ErrorInvalidArgument    = errors.New("Invalid argument")
 	ErrorPermissionDenied   = errors.New("Permission denied")
+	ErrorConnectionLost     = errors.New("Connection lost")
+	ErrorRateLimited        = errors.New("Rate limited")
+	ErrorSpamBlocked        = errors.New("Spam blocked")
+	ErrorCheckpoint         = errors.New("Checkpoint required")
+	ErrorTargetNotFound     = errors.New("Instagram user or thread not found")
+	ErrorProxyFailed        = errors.New("Proxy failed")
+	ErrorTimeout            = errors.New("Instagram library timeout")
 )
 
 type BaseError interface {
@@ -21,8 +29,9 @@ type BaseError interface {
 }
 
 type Error struct {
-	err  error
-	code string
+	err        error
+	code       string
+	retryAfter time.Duration // Подсказка: через сколько имеет смысл повторить запрос, 0 - без подсказки
 }
 
 func (e *Error) Error() string {
@@ -37,6 +46,10 @@ func (e *Error) Code() string {
 	return e.code
 }
 
+func (e *Error) RetryAfter() time.Duration {
+	return e.retryAfter
+}
+
 func NewError(code string, err error) error {
 	return &Error{
 		code: code,
@@ -44,6 +57,24 @@ func NewError(code string, err error) error {
 	}
 }
 
+func NewErrorRetry(code string, err error, retryAfter time.Duration) error {
+	return &Error{
+		code:       code,
+		err:        err,
+		retryAfter: retryAfter,
+	}
+}
+
+// RetryAfter Подсказка повтора из цепочки ошибок, 0 - подсказки нет
+func RetryAfter(err error) time.Duration {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.RetryAfter()
+	}
+
+	return 0
+}
+
 func NewErrorNotFound(msg string) error {
 	return NewError(ErrorNotFound.Error(), fmt.Errorf("%w. %s", ErrorNotFound, msg))
 }
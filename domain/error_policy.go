@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"channels-instagram-dm/domain/model"
+)
+
+const (
+	ErrorActionRetry   ErrorAction = iota + 1 // Повтор по обычному расписанию
+	ErrorActionBackoff                        // Повтор после паузы из подсказки
+	ErrorActionRelogin                        // Сессия потеряна, нужен повторный вход
+	ErrorActionSuspend                        // Аккаунт останавливается с причиной
+	ErrorActionNone                           // Ошибка относится к запросу, аккаунт продолжает работу
+)
+
+const (
+	BackoffDefault = 5 * time.Minute // Пауза, если ошибка не содержит подсказки повтора
+)
+
+type ErrorAction int
+
+// ErrorPolicy Реакция аккаунта на ошибку библиотеки Instagram
+type ErrorPolicy struct {
+	Action     ErrorAction
+	Reason     string        // Причина остановки аккаунта, если ошибка не устранится повторами
+	RetryAfter time.Duration // Пауза перед повтором для ErrorActionBackoff
+}
+
+func NewErrorPolicy(err error) ErrorPolicy {
+	switch {
+	case err == nil:
+		return ErrorPolicy{Action: ErrorActionNone}
+
+	case errors.Is(err, ErrorNoLoggedIn):
+		return ErrorPolicy{Action: ErrorActionRelogin, Reason: model.AccountStateReasonNoLoggedIn}
+
+	case errors.Is(err, ErrorInvalidCredentials):
+		return ErrorPolicy{Action: ErrorActionSuspend, Reason: model.AccountStateReasonNoLoggedIn}
+
+	case errors.Is(err, ErrorCheckpoint):
+		return ErrorPolicy{Action: ErrorActionSuspend, Reason: model.AccountStateReasonChallenge}
+
+	case errors.Is(err, ErrorSpamBlocked):
+		return ErrorPolicy{Action: ErrorActionSuspend, Reason: model.AccountStateReasonSpamBlocked}
+
+	case errors.Is(err, ErrorRateLimited):
+		return newBackoffPolicy(err, model.AccountStateReasonRateLimited)
+
+	case errors.Is(err, ErrorProxyFailed):
+		return newBackoffPolicy(err, model.AccountStateReasonProxyFailed)
+
+	case errors.Is(err, ErrorTimeout), errors.Is(err, ErrorConnectionLost), errors.Is(err, context.DeadlineExceeded):
+		return newBackoffPolicy(err, model.AccountStateReasonPermanentError)
+
+	case errors.Is(err, ErrorTargetNotFound):
+		return ErrorPolicy{Action: ErrorActionNone}
+
+	default:
+		return ErrorPolicy{Action: ErrorActionRetry, Reason: model.AccountStateReasonPermanentError}
+	}
+}
+
+// ApplyErrorPolicy Применяет к аккаунту политику ошибки библиотеки: повторный вход или остановка с точной причиной
+// Паузу перед повтором выдерживает вызывающий
+func ApplyErrorPolicy(runtimeContext RuntimeContext, account model.Account, err error) ErrorPolicy {
+	policy := NewErrorPolicy(err)
+
+	switch policy.Action {
+	case ErrorActionRelogin:
+		runtimeContext.EventBus().PublishLoginAccount(EventLoginAccount{
+			Account: account,
+		})
+
+	case ErrorActionSuspend:
+		runtimeContext.Logger().Error(fmt.Sprintf("Account will be suspended with reason [%s]. %s", policy.Reason, err), nil)
+
+		runtimeContext.EventBus().PublishSuspendAccount(EventSuspendAccount{
+			Reason:  policy.Reason,
+			Account: account,
+		})
+	}
+
+	return policy
+}
+
+// IsPermanentError Ошибка относится к самому сообщению и не устранится повторной отправкой
+func IsPermanentError(err error) bool {
+	return errors.Is(err, ErrorInvalidArgument) || errors.Is(err, ErrorTargetNotFound)
+}
+
+func newBackoffPolicy(err error, reason string) ErrorPolicy {
+	retryAfter := RetryAfter(err)
+	if retryAfter == 0 {
+		retryAfter = BackoffDefault
+	}
+
+	return ErrorPolicy{
+		Action:     ErrorActionBackoff,
+		Reason:     reason,
+		RetryAfter: retryAfter,
+	}
+}
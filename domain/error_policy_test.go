@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"channels-instagram-dm/domain/model"
+)
+
+func TestNewErrorPolicy(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		action     ErrorAction
+		reason     string
+		retryAfter time.Duration
+	}{
+		{"nil", nil, ErrorActionNone, "", 0},
+		{"no logged in", NewErrorNoLoggedIn("session"), ErrorActionRelogin, model.AccountStateReasonNoLoggedIn, 0},
+		{"invalid credentials", NewError("", fmt.Errorf("%w", ErrorInvalidCredentials)), ErrorActionSuspend, model.AccountStateReasonNoLoggedIn, 0},
+		{"checkpoint", NewError("", fmt.Errorf("%w", ErrorCheckpoint)), ErrorActionSuspend, model.AccountStateReasonChallenge, 0},
+		{"spam blocked", NewErrorRetry("", fmt.Errorf("%w", ErrorSpamBlocked), time.Hour), ErrorActionSuspend, model.AccountStateReasonSpamBlocked, 0},
+		{"rate limited", NewErrorRetry("", fmt.Errorf("%w", ErrorRateLimited), time.Hour), ErrorActionBackoff, model.AccountStateReasonRateLimited, time.Hour},
+		{"proxy failed without hint", NewError("", fmt.Errorf("%w", ErrorProxyFailed)), ErrorActionBackoff, model.AccountStateReasonProxyFailed, BackoffDefault},
+		{"timeout", NewErrorRetry("", fmt.Errorf("%w", ErrorTimeout), time.Minute), ErrorActionBackoff, model.AccountStateReasonPermanentError, time.Minute},
+		{"connection lost", NewError("", fmt.Errorf("%w", ErrorConnectionLost)), ErrorActionBackoff, model.AccountStateReasonPermanentError, BackoffDefault},
+		{"deadline", fmt.Errorf("request: %w", context.DeadlineExceeded), ErrorActionBackoff, model.AccountStateReasonPermanentError, BackoffDefault},
+		{"target not found", NewError("", fmt.Errorf("%w", ErrorTargetNotFound)), ErrorActionNone, "", 0},
+		{"unknown", errors.New("unknown"), ErrorActionRetry, model.AccountStateReasonPermanentError, 0},
+	}
+
+	for _, test := range tests {
+		policy := NewErrorPolicy(test.err)
+
+		if policy.Action != test.action || policy.Reason != test.reason || policy.RetryAfter != test.retryAfter {
+			t.Errorf("%s: want {%d %s %s}, got {%d %s %s}", test.name, test.action, test.reason, test.retryAfter, policy.Action, policy.Reason, policy.RetryAfter)
+		}
+	}
+}
+
+func TestIsPermanentError(t *testing.T) {
+	if !IsPermanentError(NewErrorInvalidArgument("text is empty")) {
+		t.Error("invalid argument: want permanent")
+	}
+
+	if !IsPermanentError(NewError("", fmt.Errorf("%w", ErrorTargetNotFound))) {
+		t.Error("target not found: want permanent")
+	}
+
+	if IsPermanentError(NewError("", fmt.Errorf("%w", ErrorTimeout))) {
+		t.Error("timeout: want temporary")
+	}
+
+	if IsPermanentError(NewErrorNotFound("message")) {
+		t.Error("not found: want temporary")
+	}
+}
@@ -17,6 +17,7 @@ type EventBus interface {
 	PublishSuspendAccount(EventSuspendAccount)
 	PublishInboxHasChanges(EventInboxHasChanges)
 	PublishLoginAccount(EventLoginAccount)
+	PublishStartBackfill(EventStartBackfill)
 	SubscribeOnAccountCreated(EventFilter) chan EventAccountCreated
 	SubscribeOnAccountResumed(EventFilter) chan EventAccountResumed
 	SubscribeOnAccountSuspended(EventFilter) chan EventAccountSuspended
@@ -25,6 +26,8 @@ type EventBus interface {
 	SubscribeOnSuspendAccount(EventFilter) chan EventSuspendAccount
 	SubscribeOnInboxHasChanges(EventFilter) chan EventInboxHasChanges
 	SubscribeOnLoginAccount(EventFilter) chan EventLoginAccount
+	SubscribeOnStartBackfill(EventFilter) chan EventStartBackfill
+	Unsubscribe(channel interface{}) // Подписки аккаунта снимаются при его остановке, каналы подписок не закрываются
 }
 
 type EventFilter func(event interface{}) bool
@@ -61,3 +64,8 @@ type EventInboxHasChanges struct {
 type EventLoginAccount struct {
 	Account model.Account
 }
+
+type EventStartBackfill struct {
+	Account  model.Account
+	Backfill model.Backfill
+}
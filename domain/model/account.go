@@ -16,6 +16,11 @@ const (
 	AccountStateReasonPermanentError  = "_PERMANENT_ERROR_"    // Постоянная ошибка
 	AccountStateReasonChallenge       = "_CHALLENGE_REQUIRED_" // Всплыл challenge
 	AccountStateReasonMarkedAsDeleted = "_MARKED_AS_DELETED_"  // Пометили на удаление
+	AccountStateReasonSlotDrained     = "_SLOT_DRAINED_"       // Слот аккаунта выведен из работы
+	AccountStateReasonMigration       = "_MIGRATION_"          // Перенос аккаунта на другой слот
+	AccountStateReasonRateLimited     = "_RATE_LIMITED_"       // Instagram ограничил частоту запросов (feedback_required)
+	AccountStateReasonSpamBlocked     = "_SPAM_BLOCKED_"       // Действия аккаунта заблокированы как спам
+	AccountStateReasonProxyFailed     = "_PROXY_FAILED_"       // Прокси аккаунта не работает
 )
 
 type AccountState int
@@ -23,19 +28,24 @@ type AccountState int
 type AccountStateReason int
 
 type Account struct {
-	ID          string
-	ExternalID  string
-	Username    string
-	State       AccountState
-	StateReason string
-	CreatedAt   time.Time
-	InboxSync   InboxSync
+	ID            string
+	ExternalID    string
+	Username      string
+	State         AccountState
+	StateReason   string
+	CreatedAt     time.Time
+	InboxSync     InboxSync
+	PendingPolicy PendingPolicy
+	Polling       PollingBounds
 }
 
 // LastInboxSyncSnapshot
 type InboxSync struct {
-	SeqID      int64 // По параметру будут отслеживаться наличие изменений в inbox
-	SnapshotAt int64 // По этому параметру будут определяться последние изменения в thread! Если у сообщение timestamp больше
+	SeqID                int64     // По параметру будут отслеживаться наличие изменений в inbox
+	SnapshotAt           int64     // По этому параметру будут определяться последние изменения в thread! Если у сообщение timestamp больше
+	PendingRequestsTotal int       // Количество запросов на переписку
+	UnseenCount          int       // Количество непрочитанных тредов
+	PendingSyncAt        time.Time // Последняя успешная загрузка запросов на переписку
 }
 
 func NewAccount(externalID, username string) Account {
@@ -45,6 +55,9 @@ func NewAccount(externalID, username string) Account {
 		State:      AccountStateSuspend,
 		CreatedAt:  time.Now(),
 		InboxSync:  InboxSync{},
+		PendingPolicy: PendingPolicy{
+			Mode: PendingPolicyNever,
+		},
 	}
 }
 
@@ -59,3 +72,11 @@ func (account Account) Validate() error {
 func (account *Account) SetInboxSync(inboxSync InboxSync) {
 	account.InboxSync = inboxSync
 }
+
+func (account *Account) SetPendingPolicy(policy PendingPolicy) {
+	account.PendingPolicy = policy
+}
+
+func (account *Account) SetPolling(polling PollingBounds) {
+	account.Polling = polling
+}
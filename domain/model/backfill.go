@@ -0,0 +1,105 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	BackfillStatusQueued    BackfillStatus = "queued"
+	BackfillStatusRunning   BackfillStatus = "running"
+	BackfillStatusDone      BackfillStatus = "done"
+	BackfillStatusCancelled BackfillStatus = "cancelled"
+	BackfillStatusFailed    BackfillStatus = "failed"
+)
+
+type BackfillStatus string
+
+// Backfill Загрузка истории переписки аккаунта или отдельной беседы
+type Backfill struct {
+	ID             string
+	AccountID      string
+	ConversationID string // Пустое значение - загрузка всех бесед аккаунта
+	Depth          int    // Максимальное количество сообщений на беседу, 0 - без ограничения
+	Until          int64  // Граница истории по timestamp сообщения (микросекунды), 0 - без ограничения
+	Deliver        bool   // Доставлять загруженные сообщения в Channels
+	Status         BackfillStatus
+	Progress       BackfillProgress
+	Error          string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+type BackfillProgress struct {
+	ConversationsTotal int
+	ConversationsDone  int
+	Messages           int      // Количество новых сохраненных сообщений
+	ThreadsDone        []string // Полностью загруженные треды, пропускаются при продолжении загрузки
+}
+
+func (p BackfillProgress) IsThreadDone(threadID string) bool {
+	for _, id := range p.ThreadsDone {
+		if id == threadID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SetThreadDone Отмечает тред загруженным, копируя срез, чтобы не изменять сохраненный ранее прогресс
+func (p *BackfillProgress) SetThreadDone(threadID string) {
+	if p.IsThreadDone(threadID) {
+		return
+	}
+
+	threadsDone := make([]string, 0, len(p.ThreadsDone)+1)
+	threadsDone = append(threadsDone, p.ThreadsDone...)
+
+	p.ThreadsDone = append(threadsDone, threadID)
+	p.ConversationsDone = len(p.ThreadsDone)
+}
+
+func NewBackfill(accountID string) Backfill {
+	return Backfill{
+		AccountID: accountID,
+		Status:    BackfillStatusQueued,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
+
+func (b Backfill) Validate() error {
+	if b.AccountID == "" {
+		return fmt.Errorf("AccountID should not be empty")
+	}
+
+	if b.Depth < 0 {
+		return fmt.Errorf("Depth should not be negative")
+	}
+
+	if b.Until < 0 {
+		return fmt.Errorf("Until should not be negative")
+	}
+
+	return nil
+}
+
+func (b Backfill) IsFinished() bool {
+	return b.Status == BackfillStatusDone || b.Status == BackfillStatusCancelled || b.Status == BackfillStatusFailed
+}
+
+func (b *Backfill) SetStatus(status BackfillStatus) {
+	b.Status = status
+	b.UpdatedAt = time.Now()
+}
+
+func (b *Backfill) SetProgress(progress BackfillProgress) {
+	b.Progress = progress
+	b.UpdatedAt = time.Now()
+}
+
+func (b *Backfill) Fail(err error) {
+	b.Error = err.Error()
+	b.SetStatus(BackfillStatusFailed)
+}
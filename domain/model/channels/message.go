@@ -2,14 +2,26 @@ package channels
 
 import (
 	"fmt"
+	"path"
+	"strings"
 )
 
 const (
 	MessageTypeText      MessageType = "text"
 	MessageTypeMedia     MessageType = "media"
+	MessageTypeLike      MessageType = "like"
+	MessageTypeLink      MessageType = "link"
+	MessageTypeReaction  MessageType = "reaction"
+	MessageTypeSeen      MessageType = "seen"
 	MessageTypeUndefined MessageType = "undefined"
 )
 
+const (
+	MediaTypeImage MediaType = "image"
+	MediaTypeVideo MediaType = "video"
+	MediaTypeVoice MediaType = "voice"
+)
+
 type MessageType string
 
 type MediaType string
@@ -21,15 +33,61 @@ type Message struct {
 	Type           MessageType
 	Text           string
 	Media          Media
+	Link           Link
+	Reaction       Reaction
+	Seen           Seen
 }
 
 type Media struct {
-	ID  string
-	Url string
+	ID   string
+	Type MediaType
+	Url  string
+}
+
+type Link struct {
+	Url             string
+	Title           string
+	Summary         string
+	ImagePreviewUrl string
+}
+
+// Reaction Реакция на сообщение, MessageID - идентификатор сообщения, под которым его знает Channels
+type Reaction struct {
+	MessageID string
+	Emoji     string
+	Remove    bool
+}
+
+// Seen Прочтение сообщения и всех предыдущих, MessageID - идентификатор сообщения, под которым его знает Channels
+// Пустой MessageID в отметке оператора означает последнее сообщение беседы
+type Seen struct {
+	MessageID string
+	Timestamp int64 // Микросекунды
+}
+
+// GetType Тип медиа, если Channels его не передал - определяется по расширению файла в URL
+func (m Media) GetType() MediaType {
+	if m.Type != "" {
+		return m.Type
+	}
+
+	ext := strings.ToLower(path.Ext(strings.SplitN(m.Url, "?", 2)[0]))
+
+	switch ext {
+	case ".jpg", ".jpeg", ".png":
+		return MediaTypeImage
+	case ".mp4", ".mov":
+		return MediaTypeVideo
+	case ".m4a", ".mp3", ".aac", ".ogg", ".oga", ".wav":
+		return MediaTypeVoice
+	default:
+		return ""
+	}
 }
 
 func (m Message) Validate() error {
-	if m.ID == "" {
+	// Отметка о прочтении не является сообщением и может не иметь идентификатора
+	if m.ID == "" && m.Type != MessageTypeSeen {
 		return fmt.Errorf("ID should not be empty")
 	}
 
@@ -45,5 +103,19 @@ func (m Message) Validate() error {
 		return fmt.Errorf("Type should not be empty")
 	}
 
+	if m.Type == MessageTypeLink && m.Link.Url == "" {
+		return fmt.Errorf("Link url should not be empty")
+	}
+
+	if m.Type == MessageTypeReaction {
+		if m.Reaction.MessageID == "" {
+			return fmt.Errorf("Reaction message ID should not be empty")
+		}
+
+		if m.Reaction.Emoji == "" && !m.Reaction.Remove {
+			return fmt.Errorf("Reaction emoji should not be empty")
+		}
+	}
+
 	return nil
 }
@@ -12,7 +12,15 @@ type Conversation struct {
 type ConversationAttributes struct {
 	UserAttributes
 	ThreadAttributes
-	LastSyncedThreadItemID string // Последнее сохраненное сообщение из треда
+	LastSyncedThreadItemID string              // Последнее сохраненное сообщение из треда
+	LastSeenAt             map[string]LastSeen // Последнее прочитанное сообщение по участникам треда
+	ReconcileAttempts      int                 // Неудачные сверки треда подряд
+}
+
+// LastSeen Последнее прочитанное участником сообщение треда, Timestamp в микросекундах
+type LastSeen struct {
+	ItemID    string
+	Timestamp int64
 }
 
 type ThreadAttributes struct {
@@ -23,6 +31,7 @@ type ThreadAttributes struct {
 	Archived         bool
 	ThreadType       string
 	InviterUserID    string
+	ViewerUserID     string // Пользователь Instagram, от имени которого ведется переписка
 	LastThreadItemID string
 }
 
@@ -53,9 +62,32 @@ func (c *Conversation) SetThreadAttributes(thread instagram.Thread) {
 	c.Attributes.ThreadAttributes.Archived = thread.Archived
 	c.Attributes.ThreadAttributes.ThreadType = thread.ThreadType
 	c.Attributes.ThreadAttributes.InviterUserID = thread.InviterUserID
+	c.Attributes.ThreadAttributes.ViewerUserID = thread.ViewerUserID
 	c.Attributes.ThreadAttributes.LastThreadItemID = thread.LastPermanentItem.ItemID
 }
 
+// IsLastSeenNewer Прочтение участника новее известного
+func (c Conversation) IsLastSeenNewer(userID string, seen LastSeen) bool {
+	current, ok := c.Attributes.LastSeenAt[userID]
+
+	return !ok || seen.Timestamp > current.Timestamp
+}
+
+// SetLastSeen Сохраняет более позднее прочтение участника, возвращает false, если оно не новее известного
+func (c *Conversation) SetLastSeen(userID string, seen LastSeen) bool {
+	if !c.IsLastSeenNewer(userID, seen) {
+		return false
+	}
+
+	if c.Attributes.LastSeenAt == nil {
+		c.Attributes.LastSeenAt = make(map[string]LastSeen)
+	}
+
+	c.Attributes.LastSeenAt[userID] = seen
+
+	return true
+}
+
 func (c *Conversation) SetUserAttributes(user instagram.User) {
 	c.Attributes.UserAttributes.ID = user.ID
 	c.Attributes.UserAttributes.Username = user.Username
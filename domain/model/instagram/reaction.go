@@ -0,0 +1,30 @@
+package instagram
+
+import (
+	"fmt"
+)
+
+// ReactionLike Реакция лайком, в том числе лайк старого формата без эмодзи
+const ReactionLike = "❤️"
+
+// Reaction Реакция пользователя на сообщение, Timestamp в микросекундах
+type Reaction struct {
+	UserID    string
+	Emoji     string
+	Timestamp int64
+}
+
+// ReactionUpdate Реакция, поставленная или снятая в реальном времени
+type ReactionUpdate struct {
+	ItemID string
+	Reaction
+	Removed bool
+}
+
+func (r Reaction) Validate() error {
+	if r.UserID == "" {
+		return fmt.Errorf("Reaction UserID should not be empty")
+	}
+
+	return nil
+}
@@ -4,4 +4,5 @@ type RealtimeUpdate struct {
 	ThreadID     string
 	ThreadItemID string
 	ThreadItem   ThreadItem
+	Reaction     *ReactionUpdate // Не nil для обновления реакции на сообщение ThreadItemID вместо нового сообщения
 }
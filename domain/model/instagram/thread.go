@@ -21,6 +21,13 @@ type Thread struct {
 		Timestamp int64
 		ItemType  string
 	}
+	LastSeenAt map[string]LastSeen // По идентификатору участника треда
+}
+
+// LastSeen Последнее прочитанное участником сообщение, Timestamp в микросекундах
+type LastSeen struct {
+	ItemID    string
+	Timestamp int64
 }
 
 type ThreadWithItems struct {
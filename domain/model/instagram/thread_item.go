@@ -34,6 +34,7 @@ type ThreadItem struct {
 	Text          Text
 	Media         Media
 	Link          Link
+	Reactions     []Reaction // nil - реакции неизвестны, например в сообщении из realtime
 }
 
 type Text string
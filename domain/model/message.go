@@ -15,9 +15,14 @@ const (
 	MessageTypeMediaVisualVideo MessageType = "media_visual_video"
 	MessageTypeMediaAnimated    MessageType = "media_animated"
 	MessageTypeMediaVoice       MessageType = "media_voice"
+	MessageTypeReaction         MessageType = "reaction"
+	MessageTypeSeen             MessageType = "seen"
 	MessageTypeUndefined        MessageType = "undefined"
 )
 
+// LikeDefault Лайк Instagram, отправляемый, если Channels не передал свой
+const LikeDefault = "❤️"
+
 const (
 	MessageSourceInstagram MessageSource = "instagram"
 	MessageSourceChannels  MessageSource = "channels"
@@ -28,6 +33,7 @@ const (
 	MessageDeliveryStatusWaiting
 	MessageDeliveryStatusSuccess
 	MessageDeliveryStatusFailed
+	MessageDeliveryStatusRejected // Сообщение не может быть доставлено, повторы не выполняются
 )
 
 type MessageType string
@@ -45,9 +51,17 @@ type Message struct {
 	Payload        interface{}
 	Attributes     MessageAttributes
 	Delivered      MessageDelivered
+	Reactions      []Reaction // Текущие реакции пользователей на сообщение
 	CreatedAt      time.Time
 }
 
+// Reaction Реакция пользователя Instagram на сообщение, Timestamp в микросекундах
+type Reaction struct {
+	UserID    string
+	Emoji     string
+	Timestamp int64
+}
+
 type MessageAttributes struct {
 	InstagramAttributes
 	ChannelsAttributes
@@ -130,6 +144,21 @@ type MessageMediaVoice struct {
 	MessageMedia
 }
 
+// MessageReaction Реакция, поставленная или снятая на сообщение треда, пустой Emoji при Remove снимает любую реакцию
+type MessageReaction struct {
+	MessageID string // Сообщение, на которое ставится реакция, под идентификатором Channels
+	ItemID    string // InstagramAttributes.ID этого сообщения
+	Emoji     string
+	Remove    bool
+}
+
+// MessageSeen Прочтение собеседником сообщения и всех предыдущих
+type MessageSeen struct {
+	MessageID string // Прочитанное сообщение под идентификатором Channels
+	ItemID    string // InstagramAttributes.ID этого сообщения
+	Timestamp int64  // Время прочтения в микросекундах
+}
+
 type MessagesBatch map[string][]Message
 
 func NewMessage(accountID string, conversationID string, source MessageSource) Message {
@@ -165,6 +194,52 @@ func (m *Message) DeliveredFail() {
 	m.Delivered.AttemptAt = time.Now()
 }
 
+func (m *Message) DeliveredReject() {
+	m.Delivered.Status = MessageDeliveryStatusRejected
+	m.Delivered.AttemptAt = time.Now()
+}
+
+// GetChannelsID Идентификатор, под которым сообщение знает Channels
+func (m Message) GetChannelsID() string {
+	if m.Attributes.ChannelsAttributes.ID != "" {
+		return m.Attributes.ChannelsAttributes.ID
+	}
+
+	return m.ID
+}
+
+// SetReaction Заменяет реакцию пользователя, возвращает false, если такая реакция уже есть
+func (m *Message) SetReaction(reaction Reaction) bool {
+	for i, item := range m.Reactions {
+		if item.UserID != reaction.UserID {
+			continue
+		}
+
+		if item.Emoji == reaction.Emoji {
+			return false
+		}
+
+		m.Reactions[i] = reaction
+		return true
+	}
+
+	m.Reactions = append(m.Reactions, reaction)
+
+	return true
+}
+
+// RemoveReaction Снимает реакцию пользователя, возвращает false, если реакции не было
+func (m *Message) RemoveReaction(userID string) (Reaction, bool) {
+	for i, item := range m.Reactions {
+		if item.UserID == userID {
+			m.Reactions = append(m.Reactions[:i], m.Reactions[i+1:]...)
+			return item, true
+		}
+	}
+
+	return Reaction{}, false
+}
+
 func (m *Message) SetPayload(payload interface{}) {
 	switch payload.(type) {
 	case MessageText:
@@ -187,6 +262,10 @@ func (m *Message) SetPayload(payload interface{}) {
 		m.Type = MessageTypeMediaAnimated
 	case MessageMediaVoice:
 		m.Type = MessageTypeMediaVoice
+	case MessageReaction:
+		m.Type = MessageTypeReaction
+	case MessageSeen:
+		m.Type = MessageTypeSeen
 	case MessageUndefined:
 		m.Type = MessageTypeUndefined
 	default:
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"fmt"
+
+	"channels-instagram-dm/domain/model/instagram"
+)
+
+const (
+	PendingPolicyNever     PendingPolicyMode = "never"      // Запросы принимаются только вручную
+	PendingPolicyAlways    PendingPolicyMode = "always"     // Принимаются все запросы
+	PendingPolicyVerified  PendingPolicyMode = "verified"   // Принимаются запросы от верифицированных пользователей
+	PendingPolicyAllowList PendingPolicyMode = "allow_list" // Принимаются запросы от пользователей из списка
+)
+
+type PendingPolicyMode string
+
+// PendingPolicy Политика автоматического принятия запросов на переписку
+type PendingPolicy struct {
+	Mode      PendingPolicyMode
+	AllowList []string // Username или ID пользователей Instagram
+}
+
+func (p PendingPolicy) Validate() error {
+	switch p.Mode {
+	case PendingPolicyNever, PendingPolicyAlways, PendingPolicyVerified:
+		return nil
+	case PendingPolicyAllowList:
+		if len(p.AllowList) == 0 {
+			return fmt.Errorf("AllowList should not be empty")
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("Mode [%s] is unsupported", p.Mode)
+	}
+}
+
+func (p PendingPolicy) Accepts(user instagram.User) bool {
+	switch p.Mode {
+	case PendingPolicyAlways:
+		return true
+	case PendingPolicyVerified:
+		return user.IsVerified
+	case PendingPolicyAllowList:
+		for _, allowed := range p.AllowList {
+			if allowed == user.Username || allowed == user.ID {
+				return true
+			}
+		}
+
+		return false
+	default:
+		return false
+	}
+}
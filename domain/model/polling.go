@@ -0,0 +1,33 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	PollingIntervalFloor   = 30 * time.Second // Нижняя граница, защищающая аккаунт от слишком частых запросов inbox
+	PollingIntervalCeiling = 24 * time.Hour
+)
+
+// PollingBounds Границы интервала опроса inbox аккаунта, нулевое значение - граница по умолчанию
+type PollingBounds struct {
+	Min time.Duration
+	Max time.Duration
+}
+
+func (b PollingBounds) Validate() error {
+	if b.Min != 0 && (b.Min < PollingIntervalFloor || b.Min > PollingIntervalCeiling) {
+		return fmt.Errorf("Min should be between %s and %s", PollingIntervalFloor, PollingIntervalCeiling)
+	}
+
+	if b.Max != 0 && (b.Max < PollingIntervalFloor || b.Max > PollingIntervalCeiling) {
+		return fmt.Errorf("Max should be between %s and %s", PollingIntervalFloor, PollingIntervalCeiling)
+	}
+
+	if b.Min != 0 && b.Max != 0 && b.Min > b.Max {
+		return fmt.Errorf("Min should not be greater than Max")
+	}
+
+	return nil
+}
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// SlotAssignment Закрепление аккаунта за хостом слота, чтобы сессия не переезжала между хостами после перезапуска
+type SlotAssignment struct {
+	ID        string
+	Username  string
+	Host      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func NewSlotAssignment(username, host string) SlotAssignment {
+	return SlotAssignment{
+		Username:  username,
+		Host:      host,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
+
+func (a SlotAssignment) Validate() error {
+	if a.Username == "" {
+		return fmt.Errorf("Username should not be empty")
+	}
+
+	if a.Host == "" {
+		return fmt.Errorf("Host should not be empty")
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	SlotModeActive   SlotMode = "active"   // Слот принимает аккаунты
+	SlotModeCordoned SlotMode = "cordoned" // Новые аккаунты за слотом не закрепляются
+	SlotModeDraining SlotMode = "draining" // Аккаунт слота останавливается, слот освобождается
+)
+
+type SlotMode string
+
+// SlotMaintenance Административный режим слота, сохраняется только для режимов, отличных от SlotModeActive
+type SlotMaintenance struct {
+	ID        string
+	Host      string
+	Mode      SlotMode
+	UpdatedAt time.Time
+}
+
+func NewSlotMaintenance(host string, mode SlotMode) SlotMaintenance {
+	return SlotMaintenance{
+		Host:      host,
+		Mode:      mode,
+		UpdatedAt: time.Now(),
+	}
+}
+
+func (m SlotMode) Validate() error {
+	switch m {
+	case SlotModeActive, SlotModeCordoned, SlotModeDraining:
+		return nil
+	default:
+		return fmt.Errorf("Mode [%s] is unsupported", m)
+	}
+}
+
+// IsAssignable Слот может быть закреплен за новым аккаунтом
+func (m SlotMode) IsAssignable() bool {
+	return m == "" || m == SlotModeActive
+}
@@ -11,6 +11,41 @@ func GetChannelsMessagePayload(m channels.Message) interface{} {
 	switch m.Type {
 	case channels.MessageTypeText:
 		return MessageText{Text: m.Text}
+	case channels.MessageTypeLike:
+		like := m.Text
+		if like == "" {
+			like = LikeDefault
+		}
+
+		return MessageLike{Like: like}
+	case channels.MessageTypeLink:
+		return MessageLink{
+			Url:             m.Link.Url,
+			Title:           m.Link.Title,
+			Summary:         m.Link.Summary,
+			ImagePreviewUrl: m.Link.ImagePreviewUrl,
+		}
+	case channels.MessageTypeReaction:
+		return MessageReaction{
+			MessageID: m.Reaction.MessageID,
+			Emoji:     m.Reaction.Emoji,
+			Remove:    m.Reaction.Remove,
+		}
+	case channels.MessageTypeMedia:
+		media := MessageMedia{ID: m.Media.ID, Url: m.Media.Url}
+
+		switch m.Media.GetType() {
+		case channels.MediaTypeImage:
+			return MessageMediaImage{MessageMedia: media}
+		case channels.MediaTypeVideo:
+			return MessageMediaVideo{MessageMedia: media}
+		case channels.MediaTypeVoice:
+			return MessageMediaVoice{MessageMedia: media}
+		default:
+			return MessageUndefined{
+				Text: fmt.Sprintf("unsupported message media type %s", m.Media.Type),
+			}
+		}
 	default:
 		return MessageUndefined{
 			Text: fmt.Sprintf("unsupported message type %s", m.Type),
@@ -18,6 +53,60 @@ func GetChannelsMessagePayload(m channels.Message) interface{} {
 	}
 }
 
+func GetChannelsMessage(m Message) channels.Message {
+	message := channels.Message{
+		ID:             m.ID,
+		AccountID:      m.AccountID,
+		ConversationID: m.ConversationID,
+		Type:           channels.MessageTypeText,
+	}
+
+	switch payload := m.Payload.(type) {
+	case MessageText:
+		message.Text = payload.Text
+	case MessageLike:
+		message.Text = payload.Like
+	case MessageLink:
+		message.Text = payload.Summary
+		if message.Text == "" {
+			message.Text = payload.Url
+		}
+	case MessageActionLog:
+		message.Text = payload.Text
+	case MessageMediaImage:
+		message.Type = channels.MessageTypeMedia
+		message.Media = channels.Media{ID: payload.ID, Type: channels.MediaTypeImage, Url: payload.Url}
+	case MessageMediaVideo:
+		message.Type = channels.MessageTypeMedia
+		message.Media = channels.Media{ID: payload.ID, Type: channels.MediaTypeVideo, Url: payload.Url}
+	case MessageMediaVisualImage:
+		message.Type = channels.MessageTypeMedia
+		message.Media = channels.Media{ID: payload.ID, Type: channels.MediaTypeImage, Url: payload.Url}
+	case MessageMediaVisualVideo:
+		message.Type = channels.MessageTypeMedia
+		message.Media = channels.Media{ID: payload.ID, Type: channels.MediaTypeVideo, Url: payload.Url}
+	case MessageMediaAnimated:
+		message.Type = channels.MessageTypeMedia
+		message.Media = channels.Media{ID: payload.ID, Type: channels.MediaTypeImage, Url: payload.Url}
+	case MessageMediaVoice:
+		message.Type = channels.MessageTypeMedia
+		message.Media = channels.Media{ID: payload.ID, Type: channels.MediaTypeVoice, Url: payload.Url}
+	case MessageReaction:
+		message.Type = channels.MessageTypeReaction
+		message.Reaction = channels.Reaction{MessageID: payload.MessageID, Emoji: payload.Emoji, Remove: payload.Remove}
+	case MessageSeen:
+		message.Type = channels.MessageTypeSeen
+		message.Seen = channels.Seen{MessageID: payload.MessageID, Timestamp: payload.Timestamp}
+	case MessageUndefined:
+		message.Type = channels.MessageTypeUndefined
+		message.Text = payload.Text
+	default:
+		message.Type = channels.MessageTypeUndefined
+	}
+
+	return message
+}
+
 func GetInstagramMessagePayload(i instagram.ThreadItem) interface{} {
 	switch i.Type {
 	case instagram.MessageTypeText:
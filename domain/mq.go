@@ -4,7 +4,7 @@ type ConsumerHandler func([]byte) error
 
 type MQ interface {
 	Producer() Producer
-	Consumer() Consumer
+	Consumer(name string) Consumer
 }
 
 type Producer interface {
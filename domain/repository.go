@@ -12,6 +12,9 @@ type Repository interface {
 	ConversationRepository() ConversationRepository
 	MessageRepository() MessageRepository
 	ActivityLogRepository() ActivityLogRepository
+	BackfillRepository() BackfillRepository
+	SlotAssignmentRepository() SlotAssignmentRepository
+	SlotMaintenanceRepository() SlotMaintenanceRepository
 }
 
 type AccountRepository interface {
@@ -28,12 +31,15 @@ type CredentialsRepository interface {
 	Store(credentials model.Credentials) (model.Credentials, error)
 	Delete(id string) error
 	WhereExternalID(externalID string) (model.Credentials, error)
+	WhereUsername(username string) (model.Credentials, error)
 }
 
 type ConversationRepository interface {
 	Store(conversation model.Conversation) (model.Conversation, error)
 	WhereID(id string) (model.Conversation, error)
 	WhereAttributeThreadID(id string) (model.Conversation, error)
+	WhereAccountIDPending(accountID string) ([]model.Conversation, error)
+	WhereAccountIDUnsynced(accountID string, limit int) ([]model.Conversation, error)
 }
 
 type ActivityLogRepository interface {
@@ -51,8 +57,12 @@ type MessageRepository interface {
 	WhereChannelsDeliveredNone(filter MessageRepositoryFilter, limit int) ([]model.Message, error)
 	WhereInstagramDeliveredFailedRecentAt(filter MessageRepositoryFilter, recentAt time.Duration, limit int) ([]model.Message, error)
 	WhereInstagramDeliveredNone(filter MessageRepositoryFilter, limit int) ([]model.Message, error)
+	WhereChannelsDeliveredWaitingStaleAt(filter MessageRepositoryFilter, staleAt time.Duration, limit int) ([]model.Message, error)
+	WhereInstagramDeliveredWaitingStaleAt(filter MessageRepositoryFilter, staleAt time.Duration, limit int) ([]model.Message, error)
 	WhereInstagramAttributeID(id string) (model.Message, error)
+	WhereChannelsAttributeID(id string) (model.Message, error)
 	WhereInstagramAttribute(filter MessageRepositoryInstagramAttributeFilter, limit int) ([]model.Message, error)
+	CountDelivered(filter MessageRepositoryFilter, status model.MessageDeliveryStatus) (int64, error)
 }
 
 type MessageRepositoryFilter interface {
@@ -63,3 +73,23 @@ type MessageRepositoryFilter interface {
 type MessageRepositoryInstagramAttributeFilter interface {
 	WithID(string) MessageRepositoryInstagramAttributeFilter
 }
+
+type BackfillRepository interface {
+	Store(backfill model.Backfill) (model.Backfill, error)
+	WhereID(id string) (model.Backfill, error)
+	WhereAccountID(accountID string, limit int) ([]model.Backfill, error)
+	WhereAccountIDStatus(accountID string, statuses ...model.BackfillStatus) ([]model.Backfill, error)
+}
+
+type SlotAssignmentRepository interface {
+	Assign(username, host string) (model.SlotAssignment, error)
+	Release(username, host string) error
+	All() ([]model.SlotAssignment, error)
+	WhereUsername(username string) (model.SlotAssignment, error)
+}
+
+type SlotMaintenanceRepository interface {
+	Store(maintenance model.SlotMaintenance) (model.SlotMaintenance, error)
+	DeleteHost(host string) error
+	All() ([]model.SlotMaintenance, error)
+}
@@ -12,6 +12,7 @@ type RuntimeContext interface {
 	Service() Service
 	Logger() Logger
 	MQ() MQ
+	Status() StatusRegistry
 }
 
 type Syncer interface {
@@ -1,6 +1,8 @@
 package domain
 
 import (
+	"time"
+
 	"channels-instagram-dm/domain/model"
 	"channels-instagram-dm/domain/model/instagram"
 )
@@ -13,16 +15,60 @@ const (
 
 type SlotStatus string
 
+const (
+	ConnectionStateConnected    ConnectionState = "connected"
+	ConnectionStateReconnecting ConnectionState = "reconnecting" // Соединение со слотом потеряно, идет переподключение
+	ConnectionStateClosed       ConnectionState = "closed"       // Сервис закрыт и больше не используется
+)
+
+type ConnectionState string
+
+const (
+	SlotHealthUnknown     SlotHealthStatus = "unknown"     // Проверок еще не было
+	SlotHealthHealthy     SlotHealthStatus = "healthy"     // Слот отвечает без ошибок и задержек
+	SlotHealthDegraded    SlotHealthStatus = "degraded"    // Слот отвечает медленно или с ошибками
+	SlotHealthUnavailable SlotHealthStatus = "unavailable" // Слот не отвечает
+)
+
+type SlotHealthStatus string
+
 type SlotContainer struct {
 	Slot     Slot
 	Username string
 	Metadata SlotMetadata
+	Health   SlotHealth
 	Service  InstagramAPI
 }
 
 type Slot struct {
-	Host   string
-	Status SlotStatus
+	Host       string
+	Status     SlotStatus
+	Mode       model.SlotMode
+	Attributes SlotAttributes
+}
+
+// SlotAttributes Атрибуты слота из источника слотов
+type SlotAttributes struct {
+	Weight      int      // Относительный приоритет при выборе слота
+	Tags        []string // Метки слота, например хост прокси, через который работает слот
+	MaxAccounts int      // Ограничение количества сессий аккаунтов на слоте, 0 - без ограничения
+	Disabled    bool     // Слот не закрепляется за новыми аккаунтами
+	Transport   string   // Транспорт библиотеки: ws (по умолчанию), wss, http или https
+}
+
+// IsAssignable Слот может быть закреплен за новым аккаунтом
+func (s Slot) IsAssignable() bool {
+	return s.Mode.IsAssignable() && !s.Attributes.Disabled
+}
+
+// SlotHealth Результаты периодических проверок слота за последнее окно
+type SlotHealth struct {
+	Status        SlotHealthStatus
+	Latency       time.Duration // Среднее время ответа успешных проверок
+	ErrorRate     float64       // Доля неудачных проверок
+	LastCheckAt   time.Time
+	LastSuccessAt time.Time
+	LastError     string
 }
 
 type SlotMetadata struct {
@@ -41,6 +87,10 @@ type Service interface {
 	InstagramAPI(username string) (InstagramAPI, error)
 	RefreshSlots() error
 	Slots() []SlotContainer
+	AssignSlot(username, host string) error
+	SetSlotMode(host string, mode model.SlotMode) (SlotContainer, error)
+	ReleaseSlot(host string) error
+	MigrateSlot(username, host string) (SlotContainer, error)
 }
 
 type InstagramAPI interface {
@@ -48,9 +98,17 @@ type InstagramAPI interface {
 	DirectInbox(cursor string, limit int) (instagram.InboxWithThreads, error) // TODO: DirectInbox
 	DirectInboxPending(cursor string) ([]instagram.ThreadWithItems, error)
 	DirectAcceptInboxPending(threadIDs []string) error
+	DirectDeclineInboxPending(threadIDs []string) error
 	DirectThread(string, string) (instagram.ThreadWithItems, error) // Add sleep duration
 	DirectSendText(username string, text model.Message) error
 	RealtimeSendText(threadID string, text model.Message) error
+	DirectSendPhoto(username string, message model.Message) (model.MessageMediaImage, error) // Возвращает payload с размерами отправленного фото
+	DirectSendVideo(username string, message model.Message) (model.MessageMediaVideo, error)
+	DirectSendVoice(username string, message model.Message) (model.MessageMediaVoice, error)
+	DirectSendLike(username string, message model.Message) error
+	DirectSendLink(username string, message model.Message) error
+	DirectSendReaction(threadID string, message model.Message) error // Реакция ставится на сообщение по InstagramAttributes.ID
+	DirectMarkSeen(threadID, itemID string) error
 	Login(credentials instagram.Credentials) (instagram.Required, error)
 	Login2F(credentials instagram.Credentials, required instagram.Required) error
 	Challenge(required instagram.Required) error
@@ -58,4 +116,6 @@ type InstagramAPI interface {
 	ListenThreadUpdates(chan instagram.RealtimeUpdate) (chan error, error)
 	Close()
 	IsClosed() bool
+	ConnectionState() ConnectionState
+	SubscribeOnConnectionState() (chan ConnectionState, func()) // Возвращает канал изменений состояния и функцию отписки
 }
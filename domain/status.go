@@ -0,0 +1,41 @@
+package domain
+
+import "time"
+
+// StatusRegistry Состояние синхронизации запущенных аккаунтов
+// Заполняется горутинами аккаунта, запись существует, пока аккаунт запущен
+type StatusRegistry interface {
+	Register(accountID string)
+	Unregister(accountID string)
+	Update(accountID string, f func(status *SyncStatus))
+	Get(accountID string) (SyncStatus, bool)
+}
+
+type SyncStatus struct {
+	StartedAt  time.Time
+	Scheduler  SchedulerStatus
+	Realtime   RealtimeStatus
+	LastSyncAt time.Time // Последний успешный раунд inbox
+}
+
+type SchedulerStatus struct {
+	RoundDuration time.Duration
+	IsAttemptMode bool
+	Attempts      int
+	NextRunAt     time.Time
+}
+
+type RealtimeStatus struct {
+	Connected    bool
+	Since        time.Time // Время последнего изменения Connected
+	LastUpdateAt time.Time
+}
+
+type UndeliveredCount struct {
+	InstagramNone     int64 // Ожидают доставки в Channels
+	InstagramFailed   int64
+	InstagramRejected int64 // Не будут доставлены
+	ChannelsNone      int64 // Ожидают отправки в Instagram
+	ChannelsFailed    int64
+	ChannelsRejected  int64
+}
@@ -1,16 +1,20 @@
 package main
 
 import (
+	"sync"
+
 	"channels-instagram-dm/domain"
 )
 
 type eventBus struct {
+	mux         sync.RWMutex
 	subscribers []subscriberOn
 }
 
 type subscriberOn struct {
 	channel interface{}
 	filter  domain.EventFilter
+	done    chan struct{} // Закрывается при отписке, чтобы отложенная доставка не ждала читателя вечно
 }
 
 func EventBus() domain.EventBus {
@@ -20,108 +24,163 @@ func EventBus() domain.EventBus {
 }
 
 func (e *eventBus) PublishAccountCreated(event domain.EventAccountCreated) {
-	for _, s := range e.subscribers {
-		if s.filter == nil || s.filter(event) {
-			if channel, ok := s.channel.(chan domain.EventAccountCreated); ok {
-				go func(ch chan domain.EventAccountCreated) {
-					ch <- event
-				}(channel)
-			}
+	for _, s := range e.matched(event) {
+		if channel, ok := s.channel.(chan domain.EventAccountCreated); ok {
+			go func(ch chan domain.EventAccountCreated, done chan struct{}) {
+				select {
+				case ch <- event:
+				case <-done:
+				}
+			}(channel, s.done)
 		}
 	}
 }
 
 func (e *eventBus) PublishAccountResumed(event domain.EventAccountResumed) {
-	for _, s := range e.subscribers {
-		if s.filter == nil || s.filter(event) {
-			if channel, ok := s.channel.(chan domain.EventAccountResumed); ok {
-				go func(ch chan domain.EventAccountResumed) {
-					ch <- event
-				}(channel)
-			}
+	for _, s := range e.matched(event) {
+		if channel, ok := s.channel.(chan domain.EventAccountResumed); ok {
+			go func(ch chan domain.EventAccountResumed, done chan struct{}) {
+				select {
+				case ch <- event:
+				case <-done:
+				}
+			}(channel, s.done)
 		}
 	}
 }
 
 func (e *eventBus) PublishAccountSuspended(event domain.EventAccountSuspended) {
-	for _, s := range e.subscribers {
-		if s.filter == nil || s.filter(event) {
-			if channel, ok := s.channel.(chan domain.EventAccountSuspended); ok {
-				go func(ch chan domain.EventAccountSuspended) {
-					ch <- event
-				}(channel)
-			}
+	for _, s := range e.matched(event) {
+		if channel, ok := s.channel.(chan domain.EventAccountSuspended); ok {
+			go func(ch chan domain.EventAccountSuspended, done chan struct{}) {
+				select {
+				case ch <- event:
+				case <-done:
+				}
+			}(channel, s.done)
 		}
 	}
 }
 
 func (e *eventBus) PublishAccountDeleted(event domain.EventAccountDeleted) {
-	for _, s := range e.subscribers {
-		if s.filter == nil || s.filter(event) {
-			if channel, ok := s.channel.(chan domain.EventAccountDeleted); ok {
-				go func(ch chan domain.EventAccountDeleted) {
-					ch <- event
-				}(channel)
-			}
+	for _, s := range e.matched(event) {
+		if channel, ok := s.channel.(chan domain.EventAccountDeleted); ok {
+			go func(ch chan domain.EventAccountDeleted, done chan struct{}) {
+				select {
+				case ch <- event:
+				case <-done:
+				}
+			}(channel, s.done)
 		}
 	}
 }
 
 func (e *eventBus) PublishAccountLogout(event domain.EventAccountLogout) {
-	for _, s := range e.subscribers {
-		if s.filter == nil || s.filter(event) {
-			if channel, ok := s.channel.(chan domain.EventAccountLogout); ok {
-				go func(ch chan domain.EventAccountLogout) {
-					ch <- event
-				}(channel)
-			}
+	for _, s := range e.matched(event) {
+		if channel, ok := s.channel.(chan domain.EventAccountLogout); ok {
+			go func(ch chan domain.EventAccountLogout, done chan struct{}) {
+				select {
+				case ch <- event:
+				case <-done:
+				}
+			}(channel, s.done)
 		}
 	}
 }
 
 func (e *eventBus) PublishSuspendAccount(event domain.EventSuspendAccount) {
-	for _, s := range e.subscribers {
-		if s.filter == nil || s.filter(event) {
-			if channel, ok := s.channel.(chan domain.EventSuspendAccount); ok {
-				go func(ch chan domain.EventSuspendAccount) {
-					ch <- event
-				}(channel)
-			}
+	for _, s := range e.matched(event) {
+		if channel, ok := s.channel.(chan domain.EventSuspendAccount); ok {
+			go func(ch chan domain.EventSuspendAccount, done chan struct{}) {
+				select {
+				case ch <- event:
+				case <-done:
+				}
+			}(channel, s.done)
 		}
 	}
 }
 
 func (e *eventBus) PublishInboxHasChanges(event domain.EventInboxHasChanges) {
-	for _, s := range e.subscribers {
-		if s.filter == nil || s.filter(event) {
-			if channel, ok := s.channel.(chan domain.EventInboxHasChanges); ok {
-				go func(ch chan domain.EventInboxHasChanges) {
-					ch <- event
-				}(channel)
-			}
+	for _, s := range e.matched(event) {
+		if channel, ok := s.channel.(chan domain.EventInboxHasChanges); ok {
+			go func(ch chan domain.EventInboxHasChanges, done chan struct{}) {
+				select {
+				case ch <- event:
+				case <-done:
+				}
+			}(channel, s.done)
 		}
 	}
 }
 
 func (e *eventBus) PublishLoginAccount(event domain.EventLoginAccount) {
+	for _, s := range e.matched(event) {
+		if channel, ok := s.channel.(chan domain.EventLoginAccount); ok {
+			go func(ch chan domain.EventLoginAccount, done chan struct{}) {
+				select {
+				case ch <- event:
+				case <-done:
+				}
+			}(channel, s.done)
+		}
+	}
+}
+
+func (e *eventBus) PublishStartBackfill(event domain.EventStartBackfill) {
+	for _, s := range e.matched(event) {
+		if channel, ok := s.channel.(chan domain.EventStartBackfill); ok {
+			go func(ch chan domain.EventStartBackfill, done chan struct{}) {
+				select {
+				case ch <- event:
+				case <-done:
+				}
+			}(channel, s.done)
+		}
+	}
+}
+
+// matched Подписчики, фильтр которых пропускает событие
+func (e *eventBus) matched(event interface{}) []subscriberOn {
+	e.mux.RLock()
+	defer e.mux.RUnlock()
+
+	subscribers := make([]subscriberOn, 0, len(e.subscribers))
 	for _, s := range e.subscribers {
 		if s.filter == nil || s.filter(event) {
-			if channel, ok := s.channel.(chan domain.EventLoginAccount); ok {
-				go func(ch chan domain.EventLoginAccount) {
-					ch <- event
-				}(channel)
-			}
+			subscribers = append(subscribers, s)
 		}
 	}
+
+	return subscribers
 }
 
 func (e *eventBus) SubscribeOn(channel interface{}, f domain.EventFilter) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
 	e.subscribers = append(e.subscribers, subscriberOn{
 		channel: channel,
 		filter:  f,
+		done:    make(chan struct{}),
 	})
 }
 
+// Unsubscribe Убирает канал из подписчиков, недоставленные события отбрасываются
+// Канал подписчика шина не закрывает, поэтому закрывать его после отписки не нужно
+func (e *eventBus) Unsubscribe(channel interface{}) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	for i, s := range e.subscribers {
+		if s.channel == channel {
+			close(s.done)
+			e.subscribers = append(e.subscribers[:i], e.subscribers[i+1:]...)
+			return
+		}
+	}
+}
+
 func (e *eventBus) SubscribeOnAccountCreated(f domain.EventFilter) chan domain.EventAccountCreated {
 	ch := make(chan domain.EventAccountCreated)
 	e.SubscribeOn(ch, f)
@@ -177,3 +236,10 @@ func (e *eventBus) SubscribeOnLoginAccount(f domain.EventFilter) chan domain.Eve
 
 	return ch
 }
+
+func (e *eventBus) SubscribeOnStartBackfill(f domain.EventFilter) chan domain.EventStartBackfill {
+	ch := make(chan domain.EventStartBackfill)
+	e.SubscribeOn(ch, f)
+
+	return ch
+}
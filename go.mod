@@ -12,4 +12,5 @@ require (
 	github.com/nats-io/stan.go v0.8.2
 	go.mongodb.org/mongo-driver v1.4.6
 	google.golang.org/protobuf v1.25.0 // indirect
+	gopkg.in/yaml.v2 v2.4.0
 )
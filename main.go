@@ -25,6 +25,7 @@ type Config struct {
 	DBHost    string
 	DBName    string
 	SlotsURI  string
+	RecordDir string
 	MQHost    string
 	MQCluster string
 	MQClient  string
@@ -37,6 +38,7 @@ func main() {
 		DBHost:    os.Getenv("DB_HOST"),
 		DBName:    os.Getenv("DB_NAME"),
 		SlotsURI:  os.Getenv("SLOTS_URI"),
+		RecordDir: os.Getenv("RECORD_DIR"),
 		MQHost:    os.Getenv("MQ_HOST"),
 		MQCluster: os.Getenv("MQ_CLUSTER"),
 		MQClient:  os.Getenv("MQ_CLIENT"),
@@ -78,6 +80,8 @@ func main() {
 
 	eventBus := EventBus()
 
+	statusRegistry := StatusRegistry()
+
 	repositoryFactory, err := repository.Factory(
 		mainContext,
 		logger.Copy("REPOSITORY"),
@@ -92,6 +96,8 @@ func main() {
 		mainContext,
 		logger.Copy("IG_SERVICE"),
 		cfg.SlotsURI,
+		cfg.RecordDir,
+		repositoryFactory,
 	)
 	if err != nil {
 		panic(err)
@@ -116,6 +122,7 @@ func main() {
 		mqFactory,
 		eventBus,
 		syncer,
+		statusRegistry,
 	)
 
 	router := mux.NewRouter()
@@ -0,0 +1,522 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"channels-instagram-dm/api"
+	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/model"
+	"channels-instagram-dm/domain/model/channels"
+	"channels-instagram-dm/mq"
+	"channels-instagram-dm/repository/fake_repository"
+	"channels-instagram-dm/service"
+	"channels-instagram-dm/service/fake_slot"
+	"channels-instagram-dm/sync"
+	"github.com/gorilla/mux"
+)
+
+const (
+	testTimeout    = 15 * time.Second
+	testExternalID = "ext-1"
+)
+
+// testEnv Сервис целиком: API, синхронизация аккаунтов и фабрика слотов поверх fake_slot и репозитория в памяти
+type testEnv struct {
+	repository domain.Repository
+	eventBus   domain.EventBus
+	status     domain.StatusRegistry
+	mq         *testMQ
+	slots      []*fake_slot.Server
+	server     *httptest.Server
+}
+
+// newTestEnv Запускает count слотов, prepare вызывается до запуска фабрики и синхронизации
+func newTestEnv(t *testing.T, count int, prepare func(rep domain.Repository, slots []*fake_slot.Server)) *testEnv {
+	t.Helper()
+
+	var out io.Writer = ioutil.Discard
+	if testing.Verbose() {
+		out = os.Stderr
+	}
+
+	logger := NewLogger(out, "")
+
+	slots := make([]*fake_slot.Server, 0, count)
+	hosts := make([]string, 0, count)
+
+	for i := 0; i < count; i++ {
+		slot := fake_slot.NewServer()
+		if err := slot.Start(""); err != nil {
+			t.Fatal(err)
+		}
+
+		t.Cleanup(func() { _ = slot.Close() })
+
+		slots = append(slots, slot)
+		hosts = append(hosts, slot.Host())
+	}
+
+	slotsURI := filepath.Join(t.TempDir(), "instagram.slots")
+	if err := ioutil.WriteFile(slotsURI, []byte(strings.Join(hosts, "\n")), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	repositoryFactory := fake_repository.NewRepository()
+
+	if prepare != nil {
+		prepare(repositoryFactory, slots)
+	}
+
+	mainContext, mainCancel := context.WithCancel(context.Background())
+
+	serviceFactory, err := service.Factory(mainContext, logger.Copy("IG_SERVICE"), slotsURI, "", repositoryFactory)
+	if err != nil {
+		mainCancel()
+		t.Fatal(err)
+	}
+
+	env := &testEnv{
+		repository: repositoryFactory,
+		eventBus:   EventBus(),
+		status:     StatusRegistry(),
+		mq:         newTestMQ(),
+		slots:      slots,
+	}
+
+	runtimeContext := api.RuntimeContext(
+		mainContext,
+		repositoryFactory,
+		serviceFactory,
+		logger,
+		env.mq,
+		env.eventBus,
+		Syncer(),
+		env.status,
+	)
+
+	router := mux.NewRouter()
+	api.InitRoutes(runtimeContext.WithLogger(logger.Copy("API")), router)
+
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			c := context.WithValue(req.Context(), "session", api.NewSession(req))
+			next.ServeHTTP(w, req.WithContext(c))
+		})
+	})
+
+	env.server = httptest.NewServer(router)
+	t.Cleanup(env.server.Close)
+
+	sync.Run(runtimeContext.WithLogger(logger.Copy("SYNC")))
+
+	// Run оформляет подписки на пять событий аккаунтов в горутине, события до подписки теряются
+	bus := env.eventBus.(*eventBus)
+
+	waitFor(t, "sync subscriptions", func() bool {
+		bus.mux.RLock()
+		defer bus.mux.RUnlock()
+
+		return len(bus.subscribers) >= 5
+	})
+
+	// Останавливаем синхронизацию раньше слотов, как при штатном завершении сервиса
+	t.Cleanup(func() {
+		mainCancel()
+
+		select {
+		case <-runtimeContext.Syncer().Done():
+		case <-time.After(testTimeout):
+			t.Error("sync was not stopped")
+		}
+	})
+
+	return env
+}
+
+// prepareAccount Аккаунт alice с сохраненной сессией на слоте и тредом t1 с bob
+func prepareAccount(slot *fake_slot.Server) {
+	slot.AddAccount(fake_slot.Account{Username: "alice", Password: "password", UserID: "1"})
+	slot.AddSession("alice")
+	slot.AddThread("t1", fake_slot.User{ID: "2", Username: "bob"}, false)
+}
+
+// storeAccount Аккаунт, работавший до перезапуска сервиса
+func storeAccount(t *testing.T, rep domain.Repository) {
+	t.Helper()
+
+	if _, err := rep.CredentialsRepository().Store(model.Credentials{
+		ExternalID: testExternalID,
+		Username:   "alice",
+		Password:   "password",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	account := model.NewAccount(testExternalID, "alice")
+	account.State = model.AccountStateActive
+
+	if _, err := rep.AccountRepository().Store(account); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// resume Запускает сохраненный аккаунт, как restoreAccounts, но без задержки восстановления
+func (env *testEnv) resume(t *testing.T) model.Account {
+	t.Helper()
+
+	account, err := env.repository.AccountRepository().WhereExternalID(testExternalID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env.eventBus.PublishAccountResumed(domain.EventAccountResumed{Account: account})
+	env.waitRunning(t, account)
+
+	return account
+}
+
+// waitRunning Ждет, пока аккаунт подпишется на исходящие сообщения: это последний шаг запуска
+func (env *testEnv) waitRunning(t *testing.T, account model.Account) {
+	t.Helper()
+
+	waitFor(t, fmt.Sprintf("account %s running", account.ExternalID), func() bool {
+		if _, running := env.status.Get(account.ID); !running {
+			return false
+		}
+
+		env.mq.mux.Lock()
+		defer env.mq.mux.Unlock()
+
+		consumer, ok := env.mq.consumers[account.ExternalID]
+		if !ok {
+			return false
+		}
+
+		consumer.mux.Lock()
+		defer consumer.mux.Unlock()
+
+		return consumer.handler != nil
+	})
+}
+
+func (env *testEnv) request(t *testing.T, method, path string, body interface{}) (int, map[string]interface{}) {
+	t.Helper()
+
+	var reader io.Reader = http.NoBody
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, env.server.URL+path, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := make(map[string]interface{})
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &result); err != nil {
+			t.Fatalf("%s %s: unexpected body %s", method, path, data)
+		}
+	}
+
+	return resp.StatusCode, result
+}
+
+// attribute Значение data.attributes.<name> ответа JSON:API
+func attribute(result map[string]interface{}, name string) interface{} {
+	data, _ := result["data"].(map[string]interface{})
+	attributes, _ := data["attributes"].(map[string]interface{})
+
+	return attributes[name]
+}
+
+// storeConversation Беседа, уже собранная синхронизацией из треда
+func (env *testEnv) storeConversation(t *testing.T, account model.Account, threadID string) model.Conversation {
+	t.Helper()
+
+	conversation := model.NewConversation(account.ID)
+	conversation.Attributes.ThreadAttributes.ID = threadID
+
+	conversation, err := env.repository.ConversationRepository().Store(conversation)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return conversation
+}
+
+func (env *testEnv) assignedHost(username string) string {
+	assignment, err := env.repository.SlotAssignmentRepository().WhereUsername(username)
+	if err != nil {
+		return ""
+	}
+
+	return assignment.Host
+}
+
+// publishOutbound Отправляет в очередь сообщение оператора из Channels
+func (env *testEnv) publishOutbound(t *testing.T, conversationID, messageID, text string) {
+	t.Helper()
+
+	env.publishOutboundAt(t, time.Now(), conversationID, messageID, text)
+}
+
+func (env *testEnv) publishOutboundAt(t *testing.T, createdAt time.Time, conversationID, messageID, text string) {
+	t.Helper()
+
+	data, err := json.Marshal(mq.Packet{
+		Uuid:        messageID,
+		App:         mq.AppName,
+		Integration: testExternalID,
+		Direction:   mq.OutboundDirection,
+		CreatedAt:   createdAt,
+		Data: mq.Payload{
+			Message: mq.Message{
+				ID:   messageID,
+				Type: channels.MessageTypeText,
+				Text: text,
+			},
+			Conversation: mq.Conversation{
+				ID: conversationID,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := env.mq.Publish(mq.ChannelsOutboundSubject, data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(testTimeout)
+
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for %s", what)
+		}
+
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestAccountLifecycle(t *testing.T) {
+	env := newTestEnv(t, 1, func(rep domain.Repository, slots []*fake_slot.Server) {
+		prepareAccount(slots[0])
+	})
+
+	code, _ := env.request(t, http.MethodPost, "/login", map[string]interface{}{
+		"data": map[string]interface{}{
+			"id": testExternalID,
+			"attributes": map[string]interface{}{
+				"credentials": map[string]string{"username": "alice"},
+			},
+		},
+	})
+	if code != http.StatusBadRequest {
+		t.Fatalf("login without password: want %d, got %d", http.StatusBadRequest, code)
+	}
+
+	code, result := env.request(t, http.MethodPost, "/login", map[string]interface{}{
+		"data": map[string]interface{}{
+			"id": testExternalID,
+			"attributes": map[string]interface{}{
+				"credentials": map[string]string{"username": "alice", "password": "password"},
+			},
+		},
+	})
+	if code != http.StatusCreated {
+		t.Fatalf("login: want %d, got %d %v", http.StatusCreated, code, result)
+	}
+
+	if host := env.assignedHost("alice"); host != env.slots[0].Host() {
+		t.Fatalf("assignment: want %s, got %q", env.slots[0].Host(), host)
+	}
+
+	code, result = env.request(t, http.MethodPost, "/account", map[string]interface{}{
+		"data": map[string]interface{}{
+			"attributes": map[string]string{"external_id": testExternalID},
+		},
+	})
+	if code != http.StatusCreated {
+		t.Fatalf("add account: want %d, got %d %v", http.StatusCreated, code, result)
+	}
+
+	account, err := env.repository.AccountRepository().WhereExternalID(testExternalID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env.waitRunning(t, account)
+
+	code, result = env.request(t, http.MethodGet, "/account/sync/"+testExternalID, nil)
+	if code != http.StatusOK || attribute(result, "running") != true {
+		t.Fatalf("sync status: want running, got %d %v", code, result)
+	}
+
+	code, result = env.request(t, http.MethodGet, "/account/all", nil)
+	if data, _ := result["data"].([]interface{}); code != http.StatusOK || len(data) != 1 {
+		t.Fatalf("all accounts: want one account, got %d %v", code, result)
+	}
+
+	if code, result = env.request(t, http.MethodPost, "/logout/"+testExternalID, nil); code >= http.StatusBadRequest {
+		t.Fatalf("logout: got %d %v", code, result)
+	}
+
+	waitFor(t, "account stopped", func() bool {
+		_, running := env.status.Get(account.ID)
+		return !running
+	})
+
+	code, result = env.request(t, http.MethodGet, "/account/"+testExternalID, nil)
+	if code != http.StatusOK || attribute(result, "state_reason") != model.AccountStateReasonNoLoggedIn {
+		t.Fatalf("account after logout: want %s, got %d %v", model.AccountStateReasonNoLoggedIn, code, result)
+	}
+
+	if host := env.assignedHost("alice"); host != "" {
+		t.Fatalf("assignment after logout: want released, got %q", host)
+	}
+}
+
+func TestAccountOutbound(t *testing.T) {
+	env := newTestEnv(t, 1, func(rep domain.Repository, slots []*fake_slot.Server) {
+		prepareAccount(slots[0])
+		storeAccount(t, rep)
+	})
+
+	slot := env.slots[0]
+	account := env.resume(t)
+	conversation := env.storeConversation(t, account, "t1")
+
+	env.publishOutbound(t, conversation.ID, "c-1", "hi bob")
+
+	sent := slot.Sent()
+	if len(sent) != 1 || sent[0].ThreadID != "t1" || sent[0].Text != "hi bob" {
+		t.Fatalf("outbound: unexpected sent %+v", sent)
+	}
+
+	message, err := env.repository.MessageRepository().WhereChannelsAttributeID("c-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if message.AccountID != account.ID || message.Delivered.Status != model.MessageDeliveryStatusSuccess {
+		t.Fatalf("outbound: want delivered message of %s, got %s %v", account.ID, message.AccountID, message.Delivered.Status)
+	}
+
+	// Повторная доставка пакета не отправляет сообщение второй раз
+	env.publishOutbound(t, conversation.ID, "c-1", "hi bob")
+
+	if sent := slot.Sent(); len(sent) != 1 {
+		t.Fatalf("outbound redelivery: want one message, got %+v", sent)
+	}
+
+	// Беседа другого аккаунта: пакет подтверждается без отправки
+	other := env.storeConversation(t, model.Account{ID: "other"}, "t1")
+
+	env.publishOutbound(t, other.ID, "c-2", "not mine")
+
+	if sent := slot.Sent(); len(sent) != 1 {
+		t.Fatalf("outbound to foreign conversation: want no message, got %+v", sent)
+	}
+
+	// Пакет пролежал в очереди дольше допустимого: подтверждается без отправки
+	env.publishOutboundAt(t, time.Now().Add(-2*mq.OutboundPacketMaxAge), conversation.ID, "c-3", "too late")
+
+	if sent := slot.Sent(); len(sent) != 1 {
+		t.Fatalf("expired outbound: want no message, got %+v", sent)
+	}
+}
+
+func TestAccountMigrate(t *testing.T) {
+	env := newTestEnv(t, 2, func(rep domain.Repository, slots []*fake_slot.Server) {
+		prepareAccount(slots[0])
+		slots[1].AddAccount(fake_slot.Account{Username: "alice", Password: "password", UserID: "1"})
+		slots[1].AddThread("t1", fake_slot.User{ID: "2", Username: "bob"}, false)
+		storeAccount(t, rep)
+
+		if _, err := rep.SlotAssignmentRepository().Assign("alice", slots[0].Host()); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	account := env.resume(t)
+
+	if host := env.assignedHost("alice"); host != env.slots[0].Host() {
+		t.Fatalf("assignment: want %s, got %q", env.slots[0].Host(), host)
+	}
+
+	// Слот без учетной записи: вход не удается, аккаунт возвращается на прежний слот
+	code, _ := env.request(t, http.MethodPost, "/account/migrate/"+testExternalID, map[string]string{"host": "127.0.0.1:1"})
+	if code < http.StatusBadRequest {
+		t.Fatalf("migrate to unknown slot: want error, got %d", code)
+	}
+
+	if host := env.assignedHost("alice"); host != env.slots[0].Host() {
+		t.Fatalf("assignment after failed migration: want %s, got %q", env.slots[0].Host(), host)
+	}
+
+	env.waitRunning(t, account)
+
+	code, result := env.request(t, http.MethodPost, "/account/migrate/"+testExternalID, map[string]string{"host": env.slots[1].Host()})
+	if code != http.StatusCreated {
+		t.Fatalf("migrate: want %d, got %d %v", http.StatusCreated, code, result)
+	}
+
+	if host := env.assignedHost("alice"); host != env.slots[1].Host() {
+		t.Fatalf("assignment after migration: want %s, got %q", env.slots[1].Host(), host)
+	}
+
+	env.waitRunning(t, account)
+
+	code, result = env.request(t, http.MethodGet, "/slots/assignments", nil)
+	if code != http.StatusOK || !strings.Contains(fmt.Sprint(result), env.slots[1].Host()) {
+		t.Fatalf("assignments: want %s, got %d %v", env.slots[1].Host(), code, result)
+	}
+
+	// Исходящие сообщения уходят через новый слот
+	conversation := env.storeConversation(t, account, "t1")
+
+	env.publishOutbound(t, conversation.ID, "c-1", "from the new slot")
+
+	if sent := env.slots[1].Sent(); len(sent) != 1 || sent[0].Text != "from the new slot" {
+		t.Fatalf("outbound after migration: unexpected sent %+v", sent)
+	}
+
+	if sent := env.slots[0].Sent(); len(sent) != 0 {
+		t.Fatalf("outbound after migration: old slot sent %+v", sent)
+	}
+}
@@ -14,14 +14,16 @@ type consumer struct {
 	conn   stan.Conn
 	logger domain.Logger
 	subs   stan.Subscription
+	name   string
 }
 
-func makeConsumer(ctx context.Context, logger domain.Logger, conn stan.Conn) *consumer {
+func makeConsumer(ctx context.Context, logger domain.Logger, conn stan.Conn, name string) *consumer {
 	return &consumer{
 		ctx:    ctx,
 		conn:   conn,
 		logger: logger,
 		subs:   nil,
+		name:   name,
 	}
 }
 
@@ -62,8 +64,10 @@ func (c *consumer) listen(subject string, handler domain.ConsumerHandler) error
 			}
 		}
 	},
-		stan.DurableName("outbound"),
-		stan.DeliverAllAvailable(),
+		stan.DurableName("outbound-"+c.name),
+		// Позиция применяется только при создании подписки, дальше durable продолжает с последнего подтверждения
+		// Вся история канала не нужна: устаревшие пакеты все равно не отправляются
+		stan.StartAtTimeDelta(OutboundPacketMaxAge),
 		stan.SetManualAckMode(),
 		stan.MaxInflight(1),
 	)
@@ -56,6 +56,7 @@ func (f *factory) Producer() domain.Producer {
 	return f.producer
 }
 
-func (f *factory) Consumer() domain.Consumer {
-	return makeConsumer(f.ctx, f.logger, f.conn)
+// Consumer Имя определяет durable-подписку, поэтому у каждого потребителя оно должно быть своим
+func (f *factory) Consumer(name string) domain.Consumer {
+	return makeConsumer(f.ctx, f.logger, f.conn, name)
 }
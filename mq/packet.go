@@ -14,7 +14,13 @@ const (
 )
 
 const (
-	ChannelsSubject = "inbound-messages"
+	ChannelsSubject         = "inbound-messages"
+	ChannelsOutboundSubject = "outbound-messages"
+	AppName                 = "instagram"
+)
+
+const (
+	OutboundPacketMaxAge = time.Hour // Сообщение оператора старше этого срока уже не отправляется в Instagram
 )
 
 type Direction string
@@ -30,6 +36,11 @@ type Packet struct {
 	Error       string    `json:"error"`
 }
 
+// IsExpired Пакет создан раньше допустимого срока. Пакеты без времени создания не устаревают
+func (p Packet) IsExpired(maxAge time.Duration) bool {
+	return !p.CreatedAt.IsZero() && time.Since(p.CreatedAt) > maxAge
+}
+
 type Payload struct {
 	Message      Message      `json:"message"`
 	Conversation Conversation `json:"conversation"`
@@ -38,20 +49,42 @@ type Payload struct {
 }
 
 type Message struct {
-	ID    string               `json:"id"`
-	Type  channels.MessageType `json:"type"`
-	Text  string               `json:"text"`
-	Media Media                `json:"media"`
+	ID       string               `json:"id"`
+	Type     channels.MessageType `json:"type"`
+	Text     string               `json:"text"`
+	Media    Media                `json:"media"`
+	Link     Link                 `json:"link"`
+	Reaction Reaction             `json:"reaction"`
+	Seen     Seen                 `json:"seen"`
 }
 
 type Media struct {
-	ID string `json:"id"`
-	// Type string `json:"type"`
-	Url string `json:"url"`
+	ID   string             `json:"id"`
+	Type channels.MediaType `json:"type"`
+	Url  string             `json:"url"`
+}
+
+type Link struct {
+	Url             string `json:"url"`
+	Title           string `json:"title"`
+	Summary         string `json:"summary"`
+	ImagePreviewUrl string `json:"image_preview_url"`
+}
+
+type Reaction struct {
+	MessageID string `json:"message_id"`
+	Emoji     string `json:"emoji"`
+	Remove    bool   `json:"remove"` // Снять реакцию вместо установки
+}
+
+type Seen struct {
+	MessageID string `json:"message_id"`
+	Timestamp int64  `json:"timestamp"`
 }
 
 type Conversation struct {
-	ID string `json:"id"`
+	ID      string `json:"id"`
+	Pending bool   `json:"pending"` // Запрос на переписку, еще не принятый аккаунтом
 }
 
 type Sender struct {
@@ -60,6 +93,34 @@ type Sender struct {
 	Avatar   string `json:"avatar"`
 }
 
+func NewMessage(m channels.Message) Message {
+	return Message{
+		ID:   m.ID,
+		Type: m.Type,
+		Text: m.Text,
+		Media: Media{
+			ID:   m.Media.ID,
+			Type: m.Media.Type,
+			Url:  m.Media.Url,
+		},
+		Link: Link{
+			Url:             m.Link.Url,
+			Title:           m.Link.Title,
+			Summary:         m.Link.Summary,
+			ImagePreviewUrl: m.Link.ImagePreviewUrl,
+		},
+		Reaction: Reaction{
+			MessageID: m.Reaction.MessageID,
+			Emoji:     m.Reaction.Emoji,
+			Remove:    m.Reaction.Remove,
+		},
+		Seen: Seen{
+			MessageID: m.Seen.MessageID,
+			Timestamp: m.Seen.Timestamp,
+		},
+	}
+}
+
 func Marshal(appName string, dir Direction, integration string, data Payload) ([]byte, error) {
 	packet := Packet{
 		Uuid:        uuid.New().String(),
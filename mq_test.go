@@ -0,0 +1,98 @@
+package main
+
+import (
+	"sync"
+
+	"channels-instagram-dm/domain"
+	"channels-instagram-dm/mq"
+)
+
+// testMQ Очередь в памяти: исходящие пакеты сразу передаются подписчикам, входящие отбрасываются
+type testMQ struct {
+	mux       sync.Mutex
+	consumers map[string]*testConsumer
+}
+
+type testConsumer struct {
+	mux     sync.Mutex
+	subject string
+	handler domain.ConsumerHandler
+}
+
+func newTestMQ() *testMQ {
+	return &testMQ{
+		consumers: make(map[string]*testConsumer),
+	}
+}
+
+func (m *testMQ) Producer() domain.Producer {
+	return m
+}
+
+func (m *testMQ) Consumer(name string) domain.Consumer {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	consumer := &testConsumer{}
+	m.consumers[name] = consumer
+
+	return consumer
+}
+
+// Publish Ошибка обработчика возвращается вызывающему вместо повторной доставки
+func (m *testMQ) Publish(subject string, data []byte) error {
+	if subject != mq.ChannelsOutboundSubject {
+		return nil
+	}
+
+	m.mux.Lock()
+	consumers := make([]*testConsumer, 0, len(m.consumers))
+	for _, consumer := range m.consumers {
+		consumers = append(consumers, consumer)
+	}
+	m.mux.Unlock()
+
+	for _, consumer := range consumers {
+		if err := consumer.deliver(subject, data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *testConsumer) Subscribe(subject string, handler domain.ConsumerHandler) error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	c.subject = subject
+	c.handler = handler
+
+	return nil
+}
+
+func (c *testConsumer) IsActive() bool {
+	return true
+}
+
+func (c *testConsumer) Close() error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	c.handler = nil
+
+	return nil
+}
+
+func (c *testConsumer) deliver(subject string, data []byte) error {
+	c.mux.Lock()
+	handler := c.handler
+	active := handler != nil && c.subject == subject
+	c.mux.Unlock()
+
+	if !active {
+		return nil
+	}
+
+	return handler(data)
+}
@@ -23,11 +23,24 @@ type Account struct {
 }
 
 type AccountAttributes struct {
-	ExternalID  string             `json:"external_id"`
-	Username    string             `json:"username"`
-	State       model.AccountState `json:"state"`
-	StateReason string             `json:"state_reason"`
-	CreatedAt   time.Time          `json:"created_at"`
+	ExternalID    string             `json:"external_id"`
+	Username      string             `json:"username"`
+	State         model.AccountState `json:"state"`
+	StateReason   string             `json:"state_reason"`
+	CreatedAt     time.Time          `json:"created_at"`
+	PendingPolicy PendingPolicy      `json:"pending_policy"`
+	Polling       Polling            `json:"polling"`
+}
+
+// Polling Границы интервала опроса inbox в секундах, 0 - граница по умолчанию
+type Polling struct {
+	Min int64 `json:"min"`
+	Max int64 `json:"max"`
+}
+
+type PendingPolicy struct {
+	Mode      model.PendingPolicyMode `json:"mode"`
+	AllowList []string                `json:"allow_list"`
 }
 
 func NewAccountPresenter() AccountPresenter {
@@ -95,4 +108,12 @@ func (a *Account) fromModel(acc model.Account) {
 	a.Attributes.State = acc.State
 	a.Attributes.StateReason = acc.StateReason
 	a.Attributes.CreatedAt = acc.CreatedAt
+	a.Attributes.PendingPolicy = PendingPolicy{
+		Mode:      acc.PendingPolicy.Mode,
+		AllowList: acc.PendingPolicy.AllowList,
+	}
+	a.Attributes.Polling = Polling{
+		Min: int64(acc.Polling.Min / time.Second),
+		Max: int64(acc.Polling.Max / time.Second),
+	}
 }
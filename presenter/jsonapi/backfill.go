@@ -0,0 +1,90 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"time"
+
+	"channels-instagram-dm/domain/model"
+)
+
+type BackfillPresenter interface {
+	Marshal(model.Backfill) ([]byte, error)
+	MarshalList([]model.Backfill) ([]byte, error)
+}
+
+type backfillPresenter struct{}
+
+type Backfill struct {
+	Type
+	Attributes BackfillAttributes `json:"attributes"`
+}
+
+type BackfillAttributes struct {
+	ConversationID string               `json:"conversation_id"`
+	Depth          int                  `json:"depth"`
+	Until          int64                `json:"until"`
+	Deliver        bool                 `json:"deliver"`
+	Status         model.BackfillStatus `json:"status"`
+	Progress       BackfillProgress     `json:"progress"`
+	Error          string               `json:"error"`
+	CreatedAt      time.Time            `json:"created_at"`
+	UpdatedAt      time.Time            `json:"updated_at"`
+}
+
+type BackfillProgress struct {
+	ConversationsTotal int `json:"conversations_total"`
+	ConversationsDone  int `json:"conversations_done"`
+	Messages           int `json:"messages"`
+}
+
+func NewBackfillPresenter() BackfillPresenter {
+	return &backfillPresenter{}
+}
+
+func (p *backfillPresenter) Marshal(m model.Backfill) ([]byte, error) {
+	b := Backfill{}
+	b.fromModel(m)
+
+	result := struct {
+		Data Backfill `json:"data"`
+	}{
+		Data: b,
+	}
+
+	return json.Marshal(result)
+}
+
+func (p *backfillPresenter) MarshalList(list []model.Backfill) ([]byte, error) {
+	backfills := make([]Backfill, 0, len(list))
+
+	for _, item := range list {
+		backfill := Backfill{}
+		backfill.fromModel(item)
+		backfills = append(backfills, backfill)
+	}
+
+	result := struct {
+		Data []Backfill `json:"data"`
+	}{
+		Data: backfills,
+	}
+
+	return json.Marshal(result)
+}
+
+func (b *Backfill) fromModel(m model.Backfill) {
+	b.Type.ID = m.ID
+	b.Type.Type = "backfill"
+
+	b.Attributes.ConversationID = m.ConversationID
+	b.Attributes.Depth = m.Depth
+	b.Attributes.Until = m.Until
+	b.Attributes.Deliver = m.Deliver
+	b.Attributes.Status = m.Status
+	b.Attributes.Progress.ConversationsTotal = m.Progress.ConversationsTotal
+	b.Attributes.Progress.ConversationsDone = m.Progress.ConversationsDone
+	b.Attributes.Progress.Messages = m.Progress.Messages
+	b.Attributes.Error = m.Error
+	b.Attributes.CreatedAt = m.CreatedAt
+	b.Attributes.UpdatedAt = m.UpdatedAt
+}
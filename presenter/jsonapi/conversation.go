@@ -0,0 +1,63 @@
+package jsonapi
+
+import (
+	"encoding/json"
+
+	"channels-instagram-dm/domain/model"
+)
+
+type ConversationPresenter interface {
+	MarshalList([]model.Conversation) ([]byte, error)
+}
+
+type conversationPresenter struct{}
+
+type Conversation struct {
+	Type
+	Attributes ConversationAttributes `json:"attributes"`
+}
+
+type ConversationAttributes struct {
+	ThreadID       string `json:"thread_id"`
+	UserID         string `json:"user_id"`
+	Username       string `json:"username"`
+	Avatar         string `json:"avatar"`
+	Pending        bool   `json:"pending"`
+	Archived       bool   `json:"archived"`
+	LastActivityAt int64  `json:"last_activity_at"`
+}
+
+func NewConversationPresenter() ConversationPresenter {
+	return &conversationPresenter{}
+}
+
+func (p *conversationPresenter) MarshalList(list []model.Conversation) ([]byte, error) {
+	conversations := make([]Conversation, 0, len(list))
+
+	for _, item := range list {
+		conversation := Conversation{}
+		conversation.fromModel(item)
+		conversations = append(conversations, conversation)
+	}
+
+	result := struct {
+		Data []Conversation `json:"data"`
+	}{
+		Data: conversations,
+	}
+
+	return json.Marshal(result)
+}
+
+func (c *Conversation) fromModel(m model.Conversation) {
+	c.Type.ID = m.ID
+	c.Type.Type = "conversation"
+
+	c.Attributes.ThreadID = m.Attributes.ThreadAttributes.ID
+	c.Attributes.UserID = m.Attributes.UserAttributes.ID
+	c.Attributes.Username = m.Attributes.UserAttributes.Username
+	c.Attributes.Avatar = m.Attributes.UserAttributes.Avatar
+	c.Attributes.Pending = m.Attributes.ThreadAttributes.Pending
+	c.Attributes.Archived = m.Attributes.ThreadAttributes.Archived
+	c.Attributes.LastActivityAt = m.Attributes.ThreadAttributes.LastActivityAt
+}
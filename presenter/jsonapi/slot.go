@@ -2,6 +2,7 @@ package jsonapi
 
 import (
 	"encoding/json"
+	"time"
 
 	"channels-instagram-dm/domain"
 )
@@ -20,11 +21,28 @@ type Slot struct {
 }
 
 type SlotAttributes struct {
-	Host       string   `json:"host"`
-	Status     string   `json:"status"`
-	Username   string   `json:"username"`
-	Users      []string `json:"users"`
-	ActiveUser string   `json:"active_user"`
+	Host        string     `json:"host"`
+	Status      string     `json:"status"`
+	Mode        string     `json:"mode"`
+	Weight      int        `json:"weight"`
+	Tags        []string   `json:"tags"`
+	MaxAccounts int        `json:"max_accounts"`
+	Disabled    bool       `json:"disabled"`
+	Transport   string     `json:"transport"`
+	Username    string     `json:"username"`
+	Users       []string   `json:"users"`
+	ActiveUser  string     `json:"active_user"`
+	Connection  string     `json:"connection"`
+	Health      SlotHealth `json:"health"`
+}
+
+type SlotHealth struct {
+	Status        string     `json:"status"`
+	LatencyMs     int64      `json:"latency_ms"`
+	ErrorRate     float64    `json:"error_rate"`
+	LastCheckAt   *time.Time `json:"last_check_at"`
+	LastSuccessAt *time.Time `json:"last_success_at"`
+	LastError     string     `json:"last_error"`
 }
 
 func NewSlotPresenter() SlotPresenter {
@@ -55,7 +73,34 @@ func (s *Slot) fromModel(sc domain.SlotContainer) {
 
 	s.Attributes.Host = sc.Slot.Host
 	s.Attributes.Status = string(sc.Slot.Status)
+	s.Attributes.Mode = string(sc.Slot.Mode)
+	s.Attributes.Weight = sc.Slot.Attributes.Weight
+	s.Attributes.Tags = sc.Slot.Attributes.Tags
+	s.Attributes.MaxAccounts = sc.Slot.Attributes.MaxAccounts
+	s.Attributes.Disabled = sc.Slot.Attributes.Disabled
+	s.Attributes.Transport = sc.Slot.Attributes.Transport
+
+	if s.Attributes.Transport == "" {
+		s.Attributes.Transport = "ws"
+	}
 	s.Attributes.Username = sc.Username
 	s.Attributes.Users = sc.Metadata.Users
 	s.Attributes.ActiveUser = sc.Metadata.ActiveUser
+
+	s.Attributes.Health = SlotHealth{
+		Status:        string(sc.Health.Status),
+		LatencyMs:     sc.Health.Latency.Milliseconds(),
+		ErrorRate:     sc.Health.ErrorRate,
+		LastCheckAt:   timeOrNil(sc.Health.LastCheckAt),
+		LastSuccessAt: timeOrNil(sc.Health.LastSuccessAt),
+		LastError:     sc.Health.LastError,
+	}
+
+	if s.Attributes.Health.Status == "" {
+		s.Attributes.Health.Status = string(domain.SlotHealthUnknown)
+	}
+
+	if sc.Service != nil {
+		s.Attributes.Connection = string(sc.Service.ConnectionState())
+	}
 }
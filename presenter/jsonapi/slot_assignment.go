@@ -0,0 +1,72 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"time"
+
+	"channels-instagram-dm/domain/model"
+)
+
+type SlotAssignmentPresenter interface {
+	Marshal(model.SlotAssignment) ([]byte, error)
+	MarshalList([]model.SlotAssignment) ([]byte, error)
+}
+
+type slotAssignmentPresenter struct{}
+
+type SlotAssignment struct {
+	Type
+	Attributes SlotAssignmentAttributes `json:"attributes"`
+}
+
+type SlotAssignmentAttributes struct {
+	Username  string    `json:"username"`
+	Host      string    `json:"host"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func NewSlotAssignmentPresenter() SlotAssignmentPresenter {
+	return &slotAssignmentPresenter{}
+}
+
+func (p *slotAssignmentPresenter) Marshal(m model.SlotAssignment) ([]byte, error) {
+	a := SlotAssignment{}
+	a.fromModel(m)
+
+	result := struct {
+		Data SlotAssignment `json:"data"`
+	}{
+		Data: a,
+	}
+
+	return json.Marshal(result)
+}
+
+func (p *slotAssignmentPresenter) MarshalList(list []model.SlotAssignment) ([]byte, error) {
+	assignments := make([]SlotAssignment, 0, len(list))
+
+	for _, item := range list {
+		assignment := SlotAssignment{}
+		assignment.fromModel(item)
+		assignments = append(assignments, assignment)
+	}
+
+	result := struct {
+		Data []SlotAssignment `json:"data"`
+	}{
+		Data: assignments,
+	}
+
+	return json.Marshal(result)
+}
+
+func (a *SlotAssignment) fromModel(m model.SlotAssignment) {
+	a.Type.ID = m.ID
+	a.Type.Type = "slot_assignment"
+
+	a.Attributes.Username = m.Username
+	a.Attributes.Host = m.Host
+	a.Attributes.CreatedAt = m.CreatedAt
+	a.Attributes.UpdatedAt = m.UpdatedAt
+}
@@ -0,0 +1,108 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"time"
+
+	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/model"
+)
+
+type SyncStatusPresenter interface {
+	Marshal(account model.Account, running bool, status domain.SyncStatus, undelivered domain.UndeliveredCount) ([]byte, error)
+}
+
+type syncStatusPresenter struct{}
+
+type SyncStatus struct {
+	Type
+	Attributes SyncStatusAttributes `json:"attributes"`
+}
+
+type SyncStatusAttributes struct {
+	ExternalID  string                `json:"external_id"`
+	State       model.AccountState    `json:"state"`
+	Running     bool                  `json:"running"`
+	StartedAt   *time.Time            `json:"started_at"`
+	LastSyncAt  *time.Time            `json:"last_sync_at"`
+	Scheduler   *SchedulerStatus      `json:"scheduler"`
+	Realtime    *RealtimeStatus       `json:"realtime"`
+	Undelivered UndeliveredAttributes `json:"undelivered"`
+}
+
+type SchedulerStatus struct {
+	RoundDuration float64    `json:"round_duration"` // Секунды
+	AttemptMode   bool       `json:"attempt_mode"`
+	Attempts      int        `json:"attempts"`
+	NextRunAt     *time.Time `json:"next_run_at"`
+}
+
+type RealtimeStatus struct {
+	Connected    bool       `json:"connected"`
+	Since        *time.Time `json:"since"`
+	LastUpdateAt *time.Time `json:"last_update_at"`
+}
+
+type UndeliveredAttributes struct {
+	InstagramNone     int64 `json:"instagram_none"`
+	InstagramFailed   int64 `json:"instagram_failed"`
+	InstagramRejected int64 `json:"instagram_rejected"`
+	ChannelsNone      int64 `json:"channels_none"`
+	ChannelsFailed    int64 `json:"channels_failed"`
+	ChannelsRejected  int64 `json:"channels_rejected"`
+}
+
+func NewSyncStatusPresenter() SyncStatusPresenter {
+	return &syncStatusPresenter{}
+}
+
+func (p *syncStatusPresenter) Marshal(account model.Account, running bool, status domain.SyncStatus, undelivered domain.UndeliveredCount) ([]byte, error) {
+	s := SyncStatus{}
+	s.Type.ID = account.ID
+	s.Type.Type = "sync_status"
+
+	s.Attributes.ExternalID = account.ExternalID
+	s.Attributes.State = account.State
+	s.Attributes.Running = running
+	s.Attributes.Undelivered = UndeliveredAttributes{
+		InstagramNone:     undelivered.InstagramNone,
+		InstagramFailed:   undelivered.InstagramFailed,
+		InstagramRejected: undelivered.InstagramRejected,
+		ChannelsNone:      undelivered.ChannelsNone,
+		ChannelsFailed:    undelivered.ChannelsFailed,
+		ChannelsRejected:  undelivered.ChannelsRejected,
+	}
+
+	// Состояние синхронизации есть только у запущенного аккаунта
+	if running {
+		s.Attributes.StartedAt = timeOrNil(status.StartedAt)
+		s.Attributes.LastSyncAt = timeOrNil(status.LastSyncAt)
+		s.Attributes.Scheduler = &SchedulerStatus{
+			RoundDuration: status.Scheduler.RoundDuration.Seconds(),
+			AttemptMode:   status.Scheduler.IsAttemptMode,
+			Attempts:      status.Scheduler.Attempts,
+			NextRunAt:     timeOrNil(status.Scheduler.NextRunAt),
+		}
+		s.Attributes.Realtime = &RealtimeStatus{
+			Connected:    status.Realtime.Connected,
+			Since:        timeOrNil(status.Realtime.Since),
+			LastUpdateAt: timeOrNil(status.Realtime.LastUpdateAt),
+		}
+	}
+
+	result := struct {
+		Data SyncStatus `json:"data"`
+	}{
+		Data: s,
+	}
+
+	return json.Marshal(result)
+}
+
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
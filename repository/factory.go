@@ -59,3 +59,15 @@ func (f *factory) MessageRepository() domain.MessageRepository {
 func (f *factory) ActivityLogRepository() domain.ActivityLogRepository {
 	return mongoRepository.ActivityLogRepository(f.db)
 }
+
+func (f *factory) BackfillRepository() domain.BackfillRepository {
+	return mongoRepository.BackfillRepository(f.db)
+}
+
+func (f *factory) SlotAssignmentRepository() domain.SlotAssignmentRepository {
+	return mongoRepository.SlotAssignmentRepository(f.db)
+}
+
+func (f *factory) SlotMaintenanceRepository() domain.SlotMaintenanceRepository {
+	return mongoRepository.SlotMaintenanceRepository(f.db)
+}
@@ -0,0 +1,134 @@
+package fake_repository
+
+import (
+	"fmt"
+
+	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/model"
+)
+
+const accountCollectionName = "account"
+
+type accountRepository struct {
+	db *Database
+}
+
+func AccountRepository(db *Database) domain.AccountRepository {
+	return &accountRepository{
+		db: db,
+	}
+}
+
+func (r *accountRepository) Store(acc model.Account) (model.Account, error) {
+	r.db.mux.Lock()
+	defer r.db.mux.Unlock()
+
+	if acc.ID == "" {
+		acc.ID = newID()
+		r.db.accounts = append(r.db.accounts, copyAccount(acc))
+
+		return toAccount(acc), nil
+	}
+
+	if err := validID(acc.ID); err != nil {
+		return model.Account{}, err
+	}
+
+	// Как и replaceOne без upsert, отсутствующий документ не создается
+	for i, account := range r.db.accounts {
+		if account.ID == acc.ID {
+			r.db.accounts[i] = copyAccount(acc)
+			break
+		}
+	}
+
+	return toAccount(acc), nil
+}
+
+func (r *accountRepository) Delete(id string) error {
+	if err := validID(id); err != nil {
+		return newErrorInvalidValue(accountCollectionName, id, err)
+	}
+
+	r.db.mux.Lock()
+	defer r.db.mux.Unlock()
+
+	for i, account := range r.db.accounts {
+		if account.ID == id {
+			r.db.accounts = append(r.db.accounts[:i], r.db.accounts[i+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+func (r *accountRepository) All() ([]model.Account, error) {
+	return r.where(func(model.Account) bool { return true }), nil
+}
+
+func (r *accountRepository) WhereID(id string) (model.Account, error) {
+	if err := validID(id); err != nil {
+		return model.Account{}, fmt.Errorf("Invalid value %s with error %s ", id, err)
+	}
+
+	return r.first(id, func(account model.Account) bool { return account.ID == id })
+}
+
+func (r *accountRepository) WhereExternalID(externalID string) (model.Account, error) {
+	return r.first(externalID, func(account model.Account) bool { return account.ExternalID == externalID })
+}
+
+func (r *accountRepository) WhereUsername(username string) (model.Account, error) {
+	return r.first(username, func(account model.Account) bool { return account.Username == username })
+}
+
+func (r *accountRepository) WhereState(state model.AccountState) ([]model.Account, error) {
+	return r.where(func(account model.Account) bool { return account.State == state }), nil
+}
+
+func (r *accountRepository) first(value string, match func(model.Account) bool) (model.Account, error) {
+	r.db.mux.Lock()
+	defer r.db.mux.Unlock()
+
+	for _, account := range r.db.accounts {
+		if match(account) {
+			return toAccount(account), nil
+		}
+	}
+
+	return model.Account{}, newErrorNotFound(accountCollectionName, value)
+}
+
+func (r *accountRepository) where(match func(model.Account) bool) []model.Account {
+	r.db.mux.Lock()
+	defer r.db.mux.Unlock()
+
+	accounts := make([]model.Account, 0)
+	for _, account := range r.db.accounts {
+		if match(account) {
+			accounts = append(accounts, toAccount(account))
+		}
+	}
+
+	return accounts
+}
+
+func copyAccount(acc model.Account) model.Account {
+	if acc.PendingPolicy.AllowList != nil {
+		acc.PendingPolicy.AllowList = append([]string{}, acc.PendingPolicy.AllowList...)
+	}
+
+	return acc
+}
+
+func toAccount(acc model.Account) model.Account {
+	acc = copyAccount(acc)
+
+	// Аккаунты, созданные до появления политики
+	if acc.PendingPolicy.Mode == "" {
+		acc.PendingPolicy.Mode = model.PendingPolicyNever
+	}
+
+	return acc
+}
@@ -0,0 +1,74 @@
+package fake_repository
+
+import (
+	"sort"
+	"time"
+
+	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/model"
+)
+
+type activityLogRepository struct {
+	db *Database
+}
+
+func ActivityLogRepository(db *Database) domain.ActivityLogRepository {
+	return &activityLogRepository{
+		db: db,
+	}
+}
+
+func (r *activityLogRepository) Store(al model.ActivityLog) (model.ActivityLog, error) {
+	r.db.mux.Lock()
+	defer r.db.mux.Unlock()
+
+	r.db.activityLogs = append(r.db.activityLogs, al)
+
+	return al, nil
+}
+
+func (r *activityLogRepository) WhereAccountID(accountID string, limit, offset int) ([]model.ActivityLog, error) {
+	r.db.mux.Lock()
+	defer r.db.mux.Unlock()
+
+	result := make([]model.ActivityLog, 0)
+	for _, al := range r.db.activityLogs {
+		if al.AccountID == accountID {
+			result = append(result, al)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+
+	if offset >= len(result) {
+		return []model.ActivityLog{}, nil
+	}
+
+	result = result[offset:]
+
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+
+	return result, nil
+}
+
+func (r *activityLogRepository) DeleteWhereAccountIDCreatedAtBefore(accountID string, before time.Time) error {
+	r.db.mux.Lock()
+	defer r.db.mux.Unlock()
+
+	activityLogs := r.db.activityLogs[:0]
+	for _, al := range r.db.activityLogs {
+		if al.AccountID == accountID && al.CreatedAt.Before(before) {
+			continue
+		}
+
+		activityLogs = append(activityLogs, al)
+	}
+
+	r.db.activityLogs = activityLogs
+
+	return nil
+}
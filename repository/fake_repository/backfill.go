@@ -0,0 +1,120 @@
+package fake_repository
+
+import (
+	"sort"
+
+	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/model"
+)
+
+const backfillCollectionName = "backfill"
+
+type backfillRepository struct {
+	db *Database
+}
+
+func BackfillRepository(db *Database) domain.BackfillRepository {
+	return &backfillRepository{
+		db: db,
+	}
+}
+
+func (r *backfillRepository) Store(b model.Backfill) (model.Backfill, error) {
+	r.db.mux.Lock()
+	defer r.db.mux.Unlock()
+
+	if b.ID == "" {
+		b.ID = newID()
+		r.db.backfills = append(r.db.backfills, copyBackfill(b))
+
+		return copyBackfill(b), nil
+	}
+
+	if err := validID(b.ID); err != nil {
+		return model.Backfill{}, err
+	}
+
+	for i, backfill := range r.db.backfills {
+		if backfill.ID == b.ID {
+			r.db.backfills[i] = copyBackfill(b)
+			break
+		}
+	}
+
+	return copyBackfill(b), nil
+}
+
+func (r *backfillRepository) WhereID(id string) (model.Backfill, error) {
+	if err := validID(id); err != nil {
+		return model.Backfill{}, newErrorInvalidValue(backfillCollectionName, id, err)
+	}
+
+	r.db.mux.Lock()
+	defer r.db.mux.Unlock()
+
+	for _, backfill := range r.db.backfills {
+		if backfill.ID == id {
+			return copyBackfill(backfill), nil
+		}
+	}
+
+	return model.Backfill{}, newErrorNotFound(backfillCollectionName, id)
+}
+
+func (r *backfillRepository) WhereAccountID(accountID string, limit int) ([]model.Backfill, error) {
+	result := r.where(func(backfill model.Backfill) bool { return backfill.AccountID == accountID })
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+
+	return result, nil
+}
+
+func (r *backfillRepository) WhereAccountIDStatus(accountID string, statuses ...model.BackfillStatus) ([]model.Backfill, error) {
+	result := r.where(func(backfill model.Backfill) bool {
+		if backfill.AccountID != accountID {
+			return false
+		}
+
+		for _, status := range statuses {
+			if backfill.Status == status {
+				return true
+			}
+		}
+
+		return false
+	})
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+
+	return result, nil
+}
+
+func (r *backfillRepository) where(match func(model.Backfill) bool) []model.Backfill {
+	r.db.mux.Lock()
+	defer r.db.mux.Unlock()
+
+	result := make([]model.Backfill, 0)
+	for _, backfill := range r.db.backfills {
+		if match(backfill) {
+			result = append(result, copyBackfill(backfill))
+		}
+	}
+
+	return result
+}
+
+func copyBackfill(b model.Backfill) model.Backfill {
+	if b.Progress.ThreadsDone != nil {
+		b.Progress.ThreadsDone = append([]string{}, b.Progress.ThreadsDone...)
+	}
+
+	return b
+}
@@ -0,0 +1,123 @@
+package fake_repository
+
+import (
+	"sort"
+
+	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/model"
+)
+
+const conversationCollectionName = "conversation"
+
+type conversationRepository struct {
+	db *Database
+}
+
+func ConversationRepository(db *Database) domain.ConversationRepository {
+	return &conversationRepository{
+		db: db,
+	}
+}
+
+func (r *conversationRepository) Store(conv model.Conversation) (model.Conversation, error) {
+	r.db.mux.Lock()
+	defer r.db.mux.Unlock()
+
+	if conv.ID == "" {
+		conv.ID = newID()
+		r.db.conversations = append(r.db.conversations, copyConversation(conv))
+
+		return copyConversation(conv), nil
+	}
+
+	if err := validID(conv.ID); err != nil {
+		return model.Conversation{}, err
+	}
+
+	for i, conversation := range r.db.conversations {
+		if conversation.ID == conv.ID {
+			r.db.conversations[i] = copyConversation(conv)
+			break
+		}
+	}
+
+	return copyConversation(conv), nil
+}
+
+func (r *conversationRepository) WhereID(id string) (model.Conversation, error) {
+	if err := validID(id); err != nil {
+		return model.Conversation{}, newErrorInvalidValue(conversationCollectionName, id, err)
+	}
+
+	return r.first(id, func(conversation model.Conversation) bool { return conversation.ID == id })
+}
+
+func (r *conversationRepository) WhereAttributeThreadID(id string) (model.Conversation, error) {
+	return r.first(id, func(conversation model.Conversation) bool {
+		return conversation.Attributes.ThreadAttributes.ID == id
+	})
+}
+
+func (r *conversationRepository) WhereAccountIDPending(accountID string) ([]model.Conversation, error) {
+	return r.where(0, func(conversation model.Conversation) bool {
+		return conversation.AccountID == accountID && conversation.Attributes.ThreadAttributes.Pending
+	}), nil
+}
+
+// WhereAccountIDUnsynced Беседы, в которых последнее сообщение треда не совпадает с последним синхронизированным
+func (r *conversationRepository) WhereAccountIDUnsynced(accountID string, limit int) ([]model.Conversation, error) {
+	return r.where(limit, func(conversation model.Conversation) bool {
+		lastThreadItemID := conversation.Attributes.ThreadAttributes.LastThreadItemID
+
+		return conversation.AccountID == accountID &&
+			lastThreadItemID != "" &&
+			lastThreadItemID != conversation.Attributes.LastSyncedThreadItemID
+	}), nil
+}
+
+func (r *conversationRepository) first(value string, match func(model.Conversation) bool) (model.Conversation, error) {
+	r.db.mux.Lock()
+	defer r.db.mux.Unlock()
+
+	for _, conversation := range r.db.conversations {
+		if match(conversation) {
+			return copyConversation(conversation), nil
+		}
+	}
+
+	return model.Conversation{}, newErrorNotFound(conversationCollectionName, value)
+}
+
+// where Беседы сортируются по последней активности треда, как в MongoDB, 0 - без ограничения
+func (r *conversationRepository) where(limit int, match func(model.Conversation) bool) []model.Conversation {
+	r.db.mux.Lock()
+	defer r.db.mux.Unlock()
+
+	conversations := make([]model.Conversation, 0)
+	for _, conversation := range r.db.conversations {
+		if match(conversation) {
+			conversations = append(conversations, copyConversation(conversation))
+		}
+	}
+
+	sort.SliceStable(conversations, func(i, j int) bool {
+		return conversations[i].Attributes.ThreadAttributes.LastActivityAt > conversations[j].Attributes.ThreadAttributes.LastActivityAt
+	})
+
+	if limit > 0 && len(conversations) > limit {
+		conversations = conversations[:limit]
+	}
+
+	return conversations
+}
+
+func copyConversation(conv model.Conversation) model.Conversation {
+	lastSeenAt := make(map[string]model.LastSeen, len(conv.Attributes.LastSeenAt))
+	for userID, seen := range conv.Attributes.LastSeenAt {
+		lastSeenAt[userID] = seen
+	}
+
+	conv.Attributes.LastSeenAt = lastSeenAt
+
+	return conv
+}
@@ -0,0 +1,82 @@
+package fake_repository
+
+import (
+	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/model"
+)
+
+const credentialsCollectionName = "credentials"
+
+type credentialsRepository struct {
+	db *Database
+}
+
+func CredentialsRepository(db *Database) domain.CredentialsRepository {
+	return &credentialsRepository{
+		db: db,
+	}
+}
+
+func (r *credentialsRepository) Store(cred model.Credentials) (model.Credentials, error) {
+	r.db.mux.Lock()
+	defer r.db.mux.Unlock()
+
+	if cred.ID == "" {
+		cred.ID = newID()
+		r.db.credentials = append(r.db.credentials, cred)
+
+		return cred, nil
+	}
+
+	if err := validID(cred.ID); err != nil {
+		return model.Credentials{}, err
+	}
+
+	for i, credentials := range r.db.credentials {
+		if credentials.ID == cred.ID {
+			r.db.credentials[i] = cred
+			break
+		}
+	}
+
+	return cred, nil
+}
+
+func (r *credentialsRepository) Delete(id string) error {
+	if err := validID(id); err != nil {
+		return newErrorInvalidValue(credentialsCollectionName, id, err)
+	}
+
+	r.db.mux.Lock()
+	defer r.db.mux.Unlock()
+
+	for i, credentials := range r.db.credentials {
+		if credentials.ID == id {
+			r.db.credentials = append(r.db.credentials[:i], r.db.credentials[i+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+func (r *credentialsRepository) WhereExternalID(externalID string) (model.Credentials, error) {
+	return r.first(externalID, func(credentials model.Credentials) bool { return credentials.ExternalID == externalID })
+}
+
+func (r *credentialsRepository) WhereUsername(username string) (model.Credentials, error) {
+	return r.first(username, func(credentials model.Credentials) bool { return credentials.Username == username })
+}
+
+func (r *credentialsRepository) first(value string, match func(model.Credentials) bool) (model.Credentials, error) {
+	r.db.mux.Lock()
+	defer r.db.mux.Unlock()
+
+	for _, credentials := range r.db.credentials {
+		if match(credentials) {
+			return credentials, nil
+		}
+	}
+
+	return model.Credentials{}, newErrorNotFound(credentialsCollectionName, value)
+}
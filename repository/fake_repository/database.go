@@ -0,0 +1,38 @@
+package fake_repository
+
+import (
+	"sync"
+
+	"channels-instagram-dm/domain/model"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Database Коллекции в памяти процесса для тестов
+// Идентификаторы и ошибки совпадают с репозиторием MongoDB, модели хранятся копиями
+type Database struct {
+	mux             sync.Mutex
+	accounts        []model.Account
+	credentials     []model.Credentials
+	conversations   []model.Conversation
+	messages        []model.Message
+	activityLogs    []model.ActivityLog
+	backfills       []model.Backfill
+	slotAssignments []model.SlotAssignment
+	slotMaintenance []model.SlotMaintenance
+}
+
+func NewDatabase() *Database {
+	return &Database{}
+}
+
+// newID Идентификатор в формате ObjectID, как у документов MongoDB
+func newID() string {
+	return primitive.NewObjectID().Hex()
+}
+
+// validID Идентификатор, который MongoDB смог бы преобразовать в ObjectID
+func validID(id string) error {
+	_, err := primitive.ObjectIDFromHex(id)
+	return err
+}
@@ -0,0 +1,276 @@
+package fake_repository
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/model"
+)
+
+const messageCollectionName = "message"
+
+type messageRepository struct {
+	db *Database
+}
+
+func MessageRepository(db *Database) domain.MessageRepository {
+	return &messageRepository{
+		db: db,
+	}
+}
+
+func (r *messageRepository) Store(msg model.Message) (model.Message, error) {
+	if msg.Payload == nil {
+		return model.Message{}, errors.New("Payload is empty")
+	}
+
+	r.db.mux.Lock()
+	defer r.db.mux.Unlock()
+
+	if msg.ID == "" {
+		msg.ID = newID()
+		r.db.messages = append(r.db.messages, copyMessage(msg))
+
+		return copyMessage(msg), nil
+	}
+
+	if err := validID(msg.ID); err != nil {
+		return model.Message{}, err
+	}
+
+	for i, message := range r.db.messages {
+		if message.ID == msg.ID {
+			r.db.messages[i] = copyMessage(msg)
+			break
+		}
+	}
+
+	return copyMessage(msg), nil
+}
+
+func (r *messageRepository) WhereID(id string) (model.Message, error) {
+	if err := validID(id); err != nil {
+		return model.Message{}, newErrorInvalidValue(messageCollectionName, id, err)
+	}
+
+	return r.first(id, func(message model.Message) bool { return message.ID == id })
+}
+
+func (r *messageRepository) CountDelivered(filter domain.MessageRepositoryFilter, status model.MessageDeliveryStatus) (int64, error) {
+	f, ok := filter.(*MessageRepositoryFilter)
+	if !ok {
+		return 0, fmt.Errorf("Filter has wrong type")
+	}
+
+	messages := r.where(func(message model.Message) bool {
+		return f.match(message) && message.Delivered.Status == status
+	})
+
+	return int64(len(messages)), nil
+}
+
+func (r *messageRepository) WhereChannelsDeliveredNone(filter domain.MessageRepositoryFilter, limit int) ([]model.Message, error) {
+	return r.whereDelivered(filter, model.MessageSourceChannels, limit, func(message model.Message) bool {
+		return message.Delivered.Status == model.MessageDeliveryStatusNone
+	})
+}
+
+func (r *messageRepository) WhereInstagramDeliveredNone(filter domain.MessageRepositoryFilter, limit int) ([]model.Message, error) {
+	return r.whereDelivered(filter, model.MessageSourceInstagram, limit, func(message model.Message) bool {
+		return message.Delivered.Status == model.MessageDeliveryStatusNone
+	})
+}
+
+func (r *messageRepository) WhereChannelsDeliveredFailedRecentAt(filter domain.MessageRepositoryFilter, recentAt time.Duration, limit int) ([]model.Message, error) {
+	recent := time.Now().Add(-1 * recentAt)
+
+	return r.whereDelivered(filter, model.MessageSourceChannels, limit, func(message model.Message) bool {
+		return message.Delivered.Status == model.MessageDeliveryStatusFailed && !message.CreatedAt.Before(recent)
+	})
+}
+
+func (r *messageRepository) WhereInstagramDeliveredFailedRecentAt(filter domain.MessageRepositoryFilter, recentAt time.Duration, limit int) ([]model.Message, error) {
+	recent := time.Now().Add(-1 * recentAt)
+
+	return r.whereDelivered(filter, model.MessageSourceInstagram, limit, func(message model.Message) bool {
+		return message.Delivered.Status == model.MessageDeliveryStatusFailed && !message.CreatedAt.Before(recent)
+	})
+}
+
+func (r *messageRepository) WhereChannelsDeliveredWaitingStaleAt(filter domain.MessageRepositoryFilter, staleAt time.Duration, limit int) ([]model.Message, error) {
+	stale := time.Now().Add(-1 * staleAt)
+
+	return r.whereDelivered(filter, model.MessageSourceChannels, limit, func(message model.Message) bool {
+		return message.Delivered.Status == model.MessageDeliveryStatusWaiting && message.Delivered.AttemptAt.Before(stale)
+	})
+}
+
+func (r *messageRepository) WhereInstagramDeliveredWaitingStaleAt(filter domain.MessageRepositoryFilter, staleAt time.Duration, limit int) ([]model.Message, error) {
+	stale := time.Now().Add(-1 * staleAt)
+
+	return r.whereDelivered(filter, model.MessageSourceInstagram, limit, func(message model.Message) bool {
+		return message.Delivered.Status == model.MessageDeliveryStatusWaiting && message.Delivered.AttemptAt.Before(stale)
+	})
+}
+
+func (r *messageRepository) WhereInstagramAttributeID(id string) (model.Message, error) {
+	return r.first(id, func(message model.Message) bool {
+		return message.Attributes.InstagramAttributes.ID == id
+	})
+}
+
+func (r *messageRepository) WhereChannelsAttributeID(id string) (model.Message, error) {
+	return r.first(id, func(message model.Message) bool {
+		return message.Attributes.ChannelsAttributes.ID == id
+	})
+}
+
+func (r *messageRepository) WhereInstagramAttribute(filter domain.MessageRepositoryInstagramAttributeFilter, limit int) ([]model.Message, error) {
+	f, ok := filter.(*MessageRepositoryInstagramAttributeFilter)
+	if !ok {
+		return nil, fmt.Errorf("Filter has wrong type")
+	}
+
+	messages := r.where(f.match)
+
+	if limit > 0 && len(messages) > limit {
+		messages = messages[:limit]
+	}
+
+	return messages, nil
+}
+
+// whereDelivered Сообщения источника сортируются как в MongoDB: из Channels по созданию, из Instagram по времени Instagram
+func (r *messageRepository) whereDelivered(filter domain.MessageRepositoryFilter, source model.MessageSource, limit int, match func(model.Message) bool) ([]model.Message, error) {
+	f, ok := filter.(*MessageRepositoryFilter)
+	if !ok {
+		return nil, fmt.Errorf("Filter has wrong type")
+	}
+
+	f.WithSource(source)
+
+	messages := r.where(func(message model.Message) bool {
+		return f.match(message) && match(message)
+	})
+
+	sort.SliceStable(messages, func(i, j int) bool {
+		if source == model.MessageSourceInstagram {
+			return messages[i].Attributes.InstagramAttributes.Timestamp < messages[j].Attributes.InstagramAttributes.Timestamp
+		}
+
+		return messages[i].CreatedAt.Before(messages[j].CreatedAt)
+	})
+
+	if limit > 0 && len(messages) > limit {
+		messages = messages[:limit]
+	}
+
+	return messages, nil
+}
+
+func (r *messageRepository) first(value string, match func(model.Message) bool) (model.Message, error) {
+	r.db.mux.Lock()
+	defer r.db.mux.Unlock()
+
+	for _, message := range r.db.messages {
+		if match(message) {
+			return copyMessage(message), nil
+		}
+	}
+
+	return model.Message{}, newErrorNotFound(messageCollectionName, value)
+}
+
+func (r *messageRepository) where(match func(model.Message) bool) []model.Message {
+	r.db.mux.Lock()
+	defer r.db.mux.Unlock()
+
+	messages := make([]model.Message, 0)
+	for _, message := range r.db.messages {
+		if match(message) {
+			messages = append(messages, copyMessage(message))
+		}
+	}
+
+	return messages
+}
+
+type MessageRepositoryFilter struct {
+	accountID []string
+	source    model.MessageSource
+}
+
+func (r *messageRepository) Filter() domain.MessageRepositoryFilter {
+	return &MessageRepositoryFilter{
+		accountID: make([]string, 0),
+	}
+}
+
+func (f *MessageRepositoryFilter) WithAccountID(id string) domain.MessageRepositoryFilter {
+	f.accountID = append(f.accountID, id)
+	return f
+}
+
+func (f *MessageRepositoryFilter) WithSource(source model.MessageSource) domain.MessageRepositoryFilter {
+	f.source = source
+	return f
+}
+
+func (f *MessageRepositoryFilter) match(message model.Message) bool {
+	if f.source != "" && message.Source != f.source {
+		return false
+	}
+
+	if len(f.accountID) == 0 {
+		return true
+	}
+
+	for _, id := range f.accountID {
+		if message.AccountID == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+type MessageRepositoryInstagramAttributeFilter struct {
+	ID []string
+}
+
+func (r *messageRepository) InstagramAttributeFilter() domain.MessageRepositoryInstagramAttributeFilter {
+	return &MessageRepositoryInstagramAttributeFilter{}
+}
+
+func (f *MessageRepositoryInstagramAttributeFilter) WithID(id string) domain.MessageRepositoryInstagramAttributeFilter {
+	f.ID = append(f.ID, id)
+	return f
+}
+
+func (f *MessageRepositoryInstagramAttributeFilter) match(message model.Message) bool {
+	if len(f.ID) == 0 {
+		return true
+	}
+
+	for _, id := range f.ID {
+		if message.Attributes.InstagramAttributes.ID == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func copyMessage(msg model.Message) model.Message {
+	// Как и у MongoDB, пустой список реакций не сохраняется
+	if len(msg.Reactions) == 0 {
+		msg.Reactions = nil
+	} else {
+		msg.Reactions = append([]model.Reaction{}, msg.Reactions...)
+	}
+
+	return msg
+}
@@ -0,0 +1,48 @@
+package fake_repository
+
+import (
+	"channels-instagram-dm/domain"
+)
+
+type repository struct {
+	db *Database
+}
+
+// NewRepository Репозиторий в памяти для тестов, повторяет поведение репозитория MongoDB
+func NewRepository() domain.Repository {
+	return &repository{
+		db: NewDatabase(),
+	}
+}
+
+func (r *repository) AccountRepository() domain.AccountRepository {
+	return AccountRepository(r.db)
+}
+
+func (r *repository) CredentialsRepository() domain.CredentialsRepository {
+	return CredentialsRepository(r.db)
+}
+
+func (r *repository) ConversationRepository() domain.ConversationRepository {
+	return ConversationRepository(r.db)
+}
+
+func (r *repository) MessageRepository() domain.MessageRepository {
+	return MessageRepository(r.db)
+}
+
+func (r *repository) ActivityLogRepository() domain.ActivityLogRepository {
+	return ActivityLogRepository(r.db)
+}
+
+func (r *repository) BackfillRepository() domain.BackfillRepository {
+	return BackfillRepository(r.db)
+}
+
+func (r *repository) SlotAssignmentRepository() domain.SlotAssignmentRepository {
+	return SlotAssignmentRepository(r.db)
+}
+
+func (r *repository) SlotMaintenanceRepository() domain.SlotMaintenanceRepository {
+	return SlotMaintenanceRepository(r.db)
+}
@@ -0,0 +1,105 @@
+package fake_repository
+
+import (
+	"sort"
+	"time"
+
+	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/model"
+)
+
+const slotAssignmentCollectionName = "slot_assignment"
+
+type slotAssignmentRepository struct {
+	db *Database
+}
+
+func SlotAssignmentRepository(db *Database) domain.SlotAssignmentRepository {
+	return &slotAssignmentRepository{
+		db: db,
+	}
+}
+
+// Assign Закрепляет аккаунт за хостом, прежние закрепления этого хоста за другими аккаунтами снимаются
+func (r *slotAssignmentRepository) Assign(username, host string) (model.SlotAssignment, error) {
+	r.db.mux.Lock()
+	defer r.db.mux.Unlock()
+
+	now := time.Now()
+
+	var assignment model.SlotAssignment
+	found := false
+
+	assignments := r.db.slotAssignments[:0]
+	for _, a := range r.db.slotAssignments {
+		if a.Username == username {
+			a.Host = host
+			a.UpdatedAt = now
+
+			assignment = a
+			found = true
+		} else if a.Host == host {
+			continue
+		}
+
+		assignments = append(assignments, a)
+	}
+
+	if !found {
+		assignment = model.SlotAssignment{
+			ID:        newID(),
+			Username:  username,
+			Host:      host,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+
+		assignments = append(assignments, assignment)
+	}
+
+	r.db.slotAssignments = assignments
+
+	return assignment, nil
+}
+
+// Release Снимает закрепление, только если аккаунт все еще закреплен за указанным хостом
+func (r *slotAssignmentRepository) Release(username, host string) error {
+	r.db.mux.Lock()
+	defer r.db.mux.Unlock()
+
+	for i, a := range r.db.slotAssignments {
+		if a.Username == username && a.Host == host {
+			r.db.slotAssignments = append(r.db.slotAssignments[:i], r.db.slotAssignments[i+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+func (r *slotAssignmentRepository) All() ([]model.SlotAssignment, error) {
+	r.db.mux.Lock()
+	defer r.db.mux.Unlock()
+
+	result := make([]model.SlotAssignment, len(r.db.slotAssignments))
+	copy(result, r.db.slotAssignments)
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Username < result[j].Username
+	})
+
+	return result, nil
+}
+
+func (r *slotAssignmentRepository) WhereUsername(username string) (model.SlotAssignment, error) {
+	r.db.mux.Lock()
+	defer r.db.mux.Unlock()
+
+	for _, a := range r.db.slotAssignments {
+		if a.Username == username {
+			return a, nil
+		}
+	}
+
+	return model.SlotAssignment{}, newErrorNotFound(slotAssignmentCollectionName, username)
+}
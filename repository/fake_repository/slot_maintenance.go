@@ -0,0 +1,60 @@
+package fake_repository
+
+import (
+	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/model"
+)
+
+type slotMaintenanceRepository struct {
+	db *Database
+}
+
+func SlotMaintenanceRepository(db *Database) domain.SlotMaintenanceRepository {
+	return &slotMaintenanceRepository{
+		db: db,
+	}
+}
+
+// Store Сохраняет режим слота, запись определяется host
+func (r *slotMaintenanceRepository) Store(m model.SlotMaintenance) (model.SlotMaintenance, error) {
+	r.db.mux.Lock()
+	defer r.db.mux.Unlock()
+
+	for i, maintenance := range r.db.slotMaintenance {
+		if maintenance.Host == m.Host {
+			r.db.slotMaintenance[i].Mode = m.Mode
+			r.db.slotMaintenance[i].UpdatedAt = m.UpdatedAt
+
+			return r.db.slotMaintenance[i], nil
+		}
+	}
+
+	m.ID = newID()
+	r.db.slotMaintenance = append(r.db.slotMaintenance, m)
+
+	return m, nil
+}
+
+func (r *slotMaintenanceRepository) DeleteHost(host string) error {
+	r.db.mux.Lock()
+	defer r.db.mux.Unlock()
+
+	for i, maintenance := range r.db.slotMaintenance {
+		if maintenance.Host == host {
+			r.db.slotMaintenance = append(r.db.slotMaintenance[:i], r.db.slotMaintenance[i+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+func (r *slotMaintenanceRepository) All() ([]model.SlotMaintenance, error) {
+	r.db.mux.Lock()
+	defer r.db.mux.Unlock()
+
+	result := make([]model.SlotMaintenance, len(r.db.slotMaintenance))
+	copy(result, r.db.slotMaintenance)
+
+	return result, nil
+}
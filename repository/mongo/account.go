@@ -27,10 +27,20 @@ type account struct {
 	StateReason string             `bson:"state_reason"`
 	CreatedAt   time.Time          `bson:"created_at"`
 	InboxSync   struct {
-		SeqID                int64 `bson:"seq_id"`
-		PendingRequestsTotal int   `bson:"pending_requests_total"`
-		SnapshotAt           int64 `bson:"snapshot_at"`
+		SeqID                int64     `bson:"seq_id"`
+		PendingRequestsTotal int       `bson:"pending_requests_total"`
+		SnapshotAt           int64     `bson:"snapshot_at"`
+		UnseenCount          int       `bson:"unseen_count"`
+		PendingSyncAt        time.Time `bson:"pending_sync_at"`
 	} `bson:"inbox_sync"`
+	PendingPolicy struct {
+		Mode      model.PendingPolicyMode `bson:"mode"`
+		AllowList []string                `bson:"allow_list"`
+	} `bson:"pending_policy"`
+	Polling struct {
+		Min time.Duration `bson:"min"`
+		Max time.Duration `bson:"max"`
+	} `bson:"polling"`
 }
 
 func AccountRepository(db *mongo.Database) domain.AccountRepository {
@@ -175,6 +185,13 @@ func (a *account) fromModel(acc model.Account) error {
 	a.CreatedAt = acc.CreatedAt
 	a.InboxSync.SeqID = acc.InboxSync.SeqID
 	a.InboxSync.SnapshotAt = acc.InboxSync.SnapshotAt
+	a.InboxSync.PendingRequestsTotal = acc.InboxSync.PendingRequestsTotal
+	a.InboxSync.UnseenCount = acc.InboxSync.UnseenCount
+	a.InboxSync.PendingSyncAt = acc.InboxSync.PendingSyncAt
+	a.PendingPolicy.Mode = acc.PendingPolicy.Mode
+	a.PendingPolicy.AllowList = acc.PendingPolicy.AllowList
+	a.Polling.Min = acc.Polling.Min
+	a.Polling.Max = acc.Polling.Max
 
 	if acc.ID == "" {
 		a.ID = primitive.NewObjectID()
@@ -198,9 +215,25 @@ func (a account) toModel() model.Account {
 		StateReason: a.StateReason,
 		CreatedAt:   a.CreatedAt,
 		InboxSync: model.InboxSync{
-			SeqID:      a.InboxSync.SeqID,
-			SnapshotAt: a.InboxSync.SnapshotAt,
+			SeqID:                a.InboxSync.SeqID,
+			SnapshotAt:           a.InboxSync.SnapshotAt,
+			PendingRequestsTotal: a.InboxSync.PendingRequestsTotal,
+			UnseenCount:          a.InboxSync.UnseenCount,
+			PendingSyncAt:        a.InboxSync.PendingSyncAt,
 		},
+		PendingPolicy: model.PendingPolicy{
+			Mode:      a.PendingPolicy.Mode,
+			AllowList: a.PendingPolicy.AllowList,
+		},
+		Polling: model.PollingBounds{
+			Min: a.Polling.Min,
+			Max: a.Polling.Max,
+		},
+	}
+
+	// Аккаунты, созданные до появления политики
+	if acc.PendingPolicy.Mode == "" {
+		acc.PendingPolicy.Mode = model.PendingPolicyNever
 	}
 
 	return acc
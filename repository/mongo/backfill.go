@@ -0,0 +1,177 @@
+package mongo
+
+import (
+	"time"
+
+	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const backfillCollectionName = "backfill"
+
+type backfillRepository struct {
+	collection *mongo.Collection
+	timeout    time.Duration
+}
+
+type backfill struct {
+	ID             primitive.ObjectID   `bson:"_id"`
+	AccountID      string               `bson:"account_id"`
+	ConversationID string               `bson:"conversation_id"`
+	Depth          int                  `bson:"depth"`
+	Until          int64                `bson:"until"`
+	Deliver        bool                 `bson:"deliver"`
+	Status         model.BackfillStatus `bson:"status"`
+	Progress       struct {
+		ConversationsTotal int      `bson:"conversations_total"`
+		ConversationsDone  int      `bson:"conversations_done"`
+		Messages           int      `bson:"messages"`
+		ThreadsDone        []string `bson:"threads_done"`
+	} `bson:"progress"`
+	Error     string    `bson:"error"`
+	CreatedAt time.Time `bson:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at"`
+}
+
+func BackfillRepository(db *mongo.Database) domain.BackfillRepository {
+	return &backfillRepository{
+		collection: db.Collection(backfillCollectionName),
+		timeout:    120 * time.Second,
+	}
+}
+
+func (r *backfillRepository) Collection() *mongo.Collection {
+	return r.collection
+}
+
+func (r *backfillRepository) GetContextTimeout() time.Duration {
+	return r.timeout
+}
+
+func (r *backfillRepository) Store(b model.Backfill) (model.Backfill, error) {
+	dbModel := backfill{}
+	if err := dbModel.fromModel(b); err != nil {
+		return model.Backfill{}, err
+	}
+
+	var err error
+	if b.ID == "" {
+		_, err = insertOne(r, dbModel)
+	} else {
+		_, err = replaceOne(r, &bson.M{"_id": dbModel.ID}, dbModel)
+	}
+
+	if err != nil {
+		return model.Backfill{}, err
+	}
+
+	return dbModel.toModel(), nil
+}
+
+func (r *backfillRepository) WhereID(id string) (b model.Backfill, err error) {
+	bsonID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return b, newErrorInvalidValue(backfillCollectionName, id, err)
+	}
+
+	dbModel := backfill{}
+	result := findOne(r, &bson.M{"_id": bsonID})
+	if result.Err() != nil {
+		if result.Err() == mongo.ErrNoDocuments {
+			return b, newErrorNotFound(backfillCollectionName, id)
+		}
+
+		return b, result.Err()
+	}
+
+	if err := result.Decode(&dbModel); err != nil {
+		return b, err
+	}
+
+	return dbModel.toModel(), nil
+}
+
+func (r *backfillRepository) WhereAccountID(accountID string, limit int) ([]model.Backfill, error) {
+	findOptions := options.Find().
+		SetLimit(int64(limit)).
+		SetSort(bson.M{"created_at": -1})
+
+	return r.find(&bson.M{"account_id": accountID}, findOptions)
+}
+
+func (r *backfillRepository) WhereAccountIDStatus(accountID string, statuses ...model.BackfillStatus) ([]model.Backfill, error) {
+	findOptions := options.Find().
+		SetSort(bson.M{"created_at": 1})
+
+	return r.find(&bson.M{"account_id": accountID, "status": bson.M{"$in": statuses}}, findOptions)
+}
+
+func (r *backfillRepository) find(query interface{}, opts ...*options.FindOptions) ([]model.Backfill, error) {
+	var dbResult []backfill
+
+	err := findAndDecode(r, query, &dbResult, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]model.Backfill, 0, len(dbResult))
+	for _, r := range dbResult {
+		result = append(result, r.toModel())
+	}
+
+	return result, nil
+}
+
+func (b *backfill) fromModel(m model.Backfill) error {
+	if m.ID == "" {
+		b.ID = primitive.NewObjectID()
+	} else {
+		objectID, err := primitive.ObjectIDFromHex(m.ID)
+		if err != nil {
+			return err
+		}
+		b.ID = objectID
+	}
+
+	b.AccountID = m.AccountID
+	b.ConversationID = m.ConversationID
+	b.Depth = m.Depth
+	b.Until = m.Until
+	b.Deliver = m.Deliver
+	b.Status = m.Status
+	b.Progress.ConversationsTotal = m.Progress.ConversationsTotal
+	b.Progress.ConversationsDone = m.Progress.ConversationsDone
+	b.Progress.Messages = m.Progress.Messages
+	b.Progress.ThreadsDone = m.Progress.ThreadsDone
+	b.Error = m.Error
+	b.CreatedAt = m.CreatedAt
+	b.UpdatedAt = m.UpdatedAt
+
+	return nil
+}
+
+func (b backfill) toModel() model.Backfill {
+	return model.Backfill{
+		ID:             b.ID.Hex(),
+		AccountID:      b.AccountID,
+		ConversationID: b.ConversationID,
+		Depth:          b.Depth,
+		Until:          b.Until,
+		Deliver:        b.Deliver,
+		Status:         b.Status,
+		Progress: model.BackfillProgress{
+			ConversationsTotal: b.Progress.ConversationsTotal,
+			ConversationsDone:  b.Progress.ConversationsDone,
+			Messages:           b.Progress.Messages,
+			ThreadsDone:        b.Progress.ThreadsDone,
+		},
+		Error:     b.Error,
+		CreatedAt: b.CreatedAt,
+		UpdatedAt: b.UpdatedAt,
+	}
+}
@@ -1,7 +1,6 @@
 package mongo
 
 import (
-	"fmt"
 	"time"
 
 	"channels-instagram-dm/domain"
@@ -10,6 +9,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 const conversationCollectionName = "conversation"
@@ -29,7 +29,14 @@ type conversation struct {
 type ConversationAttributes struct {
 	UserAttributes         `bson:"user"`
 	ThreadAttributes       `bson:"thread"`
-	LastSyncedThreadItemID string `bson:"last_synced_thread_item_id"`
+	LastSyncedThreadItemID string              `bson:"last_synced_thread_item_id"`
+	LastSeenAt             map[string]LastSeen `bson:"last_seen_at,omitempty"`
+	ReconcileAttempts      int                 `bson:"reconcile_attempts,omitempty"`
+}
+
+type LastSeen struct {
+	ItemID    string `bson:"item_id"`
+	Timestamp int64  `bson:"timestamp"`
 }
 
 type UserAttributes struct {
@@ -46,6 +53,7 @@ type ThreadAttributes struct {
 	Archived         bool   `bson:"archived"`
 	ThreadType       string `bson:"thread_type"`
 	InviterUserID    string `bson:"inviter"`
+	ViewerUserID     string `bson:"viewer"`
 	LastThreadItemID string `bson:"last_thread_item_id"`
 }
 
@@ -97,7 +105,7 @@ func (r *conversationRepository) Delete(id string) error {
 func (r *conversationRepository) WhereID(id string) (conv model.Conversation, err error) {
 	bsonID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return conv, fmt.Errorf("Invalid value %s with error %s ", id, err)
+		return conv, newErrorInvalidValue(conversationCollectionName, id, err)
 	}
 
 	conversation := conversation{}
@@ -135,6 +143,54 @@ func (r *conversationRepository) WhereAttributeThreadID(id string) (conv model.C
 	return conversation.toModel(), nil
 }
 
+func (r *conversationRepository) WhereAccountIDPending(accountID string) ([]model.Conversation, error) {
+	result := make([]conversation, 0)
+
+	findOptions := options.Find().
+		SetSort(bson.M{"attributes.thread.last_activity_at": -1})
+
+	err := findAndDecode(r, &bson.M{"account_id": accountID, "attributes.thread.pending": true}, &result, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	conversations := make([]model.Conversation, 0, len(result))
+	for _, conversation := range result {
+		conversations = append(conversations, conversation.toModel())
+	}
+
+	return conversations, nil
+}
+
+// WhereAccountIDUnsynced Беседы, в которых последнее сообщение треда не совпадает с последним синхронизированным
+func (r *conversationRepository) WhereAccountIDUnsynced(accountID string, limit int) ([]model.Conversation, error) {
+	result := make([]conversation, 0)
+
+	findOptions := options.Find().
+		SetLimit(int64(limit)).
+		SetSort(bson.M{"attributes.thread.last_activity_at": -1})
+
+	query := bson.M{
+		"account_id":                            accountID,
+		"attributes.thread.last_thread_item_id": bson.M{"$ne": ""},
+		"$expr": bson.M{
+			"$ne": bson.A{"$attributes.thread.last_thread_item_id", "$attributes.last_synced_thread_item_id"},
+		},
+	}
+
+	err := findAndDecode(r, &query, &result, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	conversations := make([]model.Conversation, 0, len(result))
+	for _, conversation := range result {
+		conversations = append(conversations, conversation.toModel())
+	}
+
+	return conversations, nil
+}
+
 func (c *conversation) fromModel(conv model.Conversation) error {
 	if conv.ID == "" {
 		c.ID = primitive.NewObjectID()
@@ -162,9 +218,19 @@ func (c *conversation) fromModel(conv model.Conversation) error {
 			Archived:         conv.Attributes.ThreadAttributes.Archived,
 			ThreadType:       conv.Attributes.ThreadAttributes.ThreadType,
 			InviterUserID:    conv.Attributes.ThreadAttributes.InviterUserID,
+			ViewerUserID:     conv.Attributes.ThreadAttributes.ViewerUserID,
 			LastThreadItemID: conv.Attributes.ThreadAttributes.LastThreadItemID,
 		},
-		LastSyncedThreadItemID: "",
+		LastSyncedThreadItemID: conv.Attributes.LastSyncedThreadItemID,
+		LastSeenAt:             make(map[string]LastSeen, len(conv.Attributes.LastSeenAt)),
+		ReconcileAttempts:      conv.Attributes.ReconcileAttempts,
+	}
+
+	for userID, seen := range conv.Attributes.LastSeenAt {
+		c.Attributes.LastSeenAt[userID] = LastSeen{
+			ItemID:    seen.ItemID,
+			Timestamp: seen.Timestamp,
+		}
 	}
 
 	return nil
@@ -189,10 +255,21 @@ func (c conversation) toModel() model.Conversation {
 				Archived:         c.Attributes.ThreadAttributes.Archived,
 				ThreadType:       c.Attributes.ThreadAttributes.ThreadType,
 				InviterUserID:    c.Attributes.ThreadAttributes.InviterUserID,
+				ViewerUserID:     c.Attributes.ThreadAttributes.ViewerUserID,
 				LastThreadItemID: c.Attributes.ThreadAttributes.LastThreadItemID,
 			},
+			LastSyncedThreadItemID: c.Attributes.LastSyncedThreadItemID,
+			LastSeenAt:             make(map[string]model.LastSeen, len(c.Attributes.LastSeenAt)),
+			ReconcileAttempts:      c.Attributes.ReconcileAttempts,
 		},
 	}
 
+	for userID, seen := range c.Attributes.LastSeenAt {
+		conv.Attributes.LastSeenAt[userID] = model.LastSeen{
+			ItemID:    seen.ItemID,
+			Timestamp: seen.Timestamp,
+		}
+	}
+
 	return conv
 }
@@ -88,6 +88,24 @@ func (r *credentialsRepository) WhereExternalID(externalID string) (cred model.C
 	return credentials.toModel(), nil
 }
 
+func (r *credentialsRepository) WhereUsername(username string) (cred model.Credentials, err error) {
+	credentials := credentials{}
+	result := findOne(r, &bson.M{"username": username})
+	if result.Err() != nil {
+		if result.Err() == mongo.ErrNoDocuments {
+			return cred, newErrorNotFound(credentialsCollectionName, username)
+		}
+
+		return cred, result.Err()
+	}
+
+	if err := result.Decode(&credentials); err != nil {
+		return cred, err
+	}
+
+	return credentials.toModel(), nil
+}
+
 func (c *credentials) fromModel(cred model.Credentials) error {
 	c.ExternalID = cred.ExternalID
 	c.Username = cred.Username
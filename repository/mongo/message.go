@@ -30,6 +30,7 @@ type message struct {
 	Payload        MessagePayload      `bson:"payload"`
 	Attributes     MessageAttributes   `bson:"attributes"`
 	Delivered      MessageDelivered    `bson:"delivered"`
+	Reactions      []MessageReaction   `bson:"reactions,omitempty"`
 	CreatedAt      time.Time           `bson:"created_at"`
 }
 
@@ -53,6 +54,12 @@ type MessageDelivered struct {
 	AttemptAt time.Time                   `bson:"attempt_at"`
 }
 
+type MessageReaction struct {
+	UserID    string `bson:"user_id"`
+	Emoji     string `bson:"emoji"`
+	Timestamp int64  `bson:"timestamp"`
+}
+
 type MessagePayload struct {
 	ID              string `bson:"id,omitempty"`
 	Text            string `bson:"text,omitempty"`
@@ -63,6 +70,11 @@ type MessagePayload struct {
 	Title           string `bson:"title,omitempty"`
 	Summary         string `bson:"summary,omitempty"`
 	ImagePreviewUrl string `bson:"image_preview_url,omitempty"`
+	MessageID       string `bson:"message_id,omitempty"`
+	ItemID          string `bson:"item_id,omitempty"`
+	Emoji           string `bson:"emoji,omitempty"`
+	Remove          bool   `bson:"remove,omitempty"`
+	Timestamp       int64  `bson:"timestamp,omitempty"`
 }
 
 func MessageRepository(db *mongo.Database) domain.MessageRepository {
@@ -113,7 +125,7 @@ func (r *messageRepository) Delete(id string) error {
 func (r *messageRepository) WhereID(id string) (model.Message, error) {
 	bsonID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return model.Message{}, fmt.Errorf("Invalid value %s with error %s ", id, err)
+		return model.Message{}, newErrorInvalidValue(messageCollectionName, id, err)
 	}
 
 	var dbResult message
@@ -134,6 +146,18 @@ func (r *messageRepository) WhereID(id string) (model.Message, error) {
 	return dbResult.toModel(), nil
 }
 
+func (r *messageRepository) CountDelivered(filter domain.MessageRepositoryFilter, status model.MessageDeliveryStatus) (int64, error) {
+	f, ok := filter.(*MessageRepositoryFilter)
+	if !ok {
+		return 0, fmt.Errorf("Filter has wrong type")
+	}
+
+	query := f.toMap()
+	query["delivered.status"] = status
+
+	return countDocuments(r, query)
+}
+
 func (r *messageRepository) WhereChannelsDeliveredNone(filter domain.MessageRepositoryFilter, limit int) ([]model.Message, error) {
 	var dbResult []message
 
@@ -256,6 +280,68 @@ func (r *messageRepository) WhereInstagramDeliveredFailedRecentAt(filter domain.
 	return result, nil
 }
 
+func (r *messageRepository) WhereChannelsDeliveredWaitingStaleAt(filter domain.MessageRepositoryFilter, staleAt time.Duration, limit int) ([]model.Message, error) {
+	var dbResult []message
+
+	f, ok := filter.(*MessageRepositoryFilter)
+	if !ok {
+		return nil, fmt.Errorf("Filter has wrong type")
+	}
+
+	f.WithSource(model.MessageSourceChannels)
+
+	query := f.toMap()
+	query["delivered.status"] = model.MessageDeliveryStatusWaiting
+	query["delivered.attempt_at"] = bson.M{"$lt": time.Now().Add(-1 * staleAt)}
+
+	findOptions := options.Find().
+		SetLimit(int64(limit)).
+		SetSort(bson.M{"created_at": 1})
+
+	err := findAndDecode(r, query, &dbResult, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]model.Message, 0, len(dbResult))
+	for _, r := range dbResult {
+		result = append(result, r.toModel())
+	}
+
+	return result, nil
+}
+
+func (r *messageRepository) WhereInstagramDeliveredWaitingStaleAt(filter domain.MessageRepositoryFilter, staleAt time.Duration, limit int) ([]model.Message, error) {
+	var dbResult []message
+
+	f, ok := filter.(*MessageRepositoryFilter)
+	if !ok {
+		return nil, fmt.Errorf("Filter has wrong type")
+	}
+
+	f.WithSource(model.MessageSourceInstagram)
+
+	query := f.toMap()
+	query["delivered.status"] = model.MessageDeliveryStatusWaiting
+	query["delivered.attempt_at"] = bson.M{"$lt": time.Now().Add(-1 * staleAt)}
+
+	findOptions := options.Find().
+		SetLimit(int64(limit)).
+		SetSort(bson.M{"attributes.instagram.timestamp": 1})
+
+	err := findAndDecode(r, query, &dbResult, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]model.Message, 0, len(dbResult))
+	for _, r := range dbResult {
+		result = append(result, r.toModel())
+	}
+
+	return result, nil
+}
+
 func (r *messageRepository) WhereInstagramAttributeID(id string) (msg model.Message, err error) {
 	var dbResult message
 
@@ -275,6 +361,25 @@ func (r *messageRepository) WhereInstagramAttributeID(id string) (msg model.Mess
 	return dbResult.toModel(), nil
 }
 
+func (r *messageRepository) WhereChannelsAttributeID(id string) (msg model.Message, err error) {
+	var dbResult message
+
+	result := findOne(r, bson.M{"attributes.channels.id": id})
+	if result.Err() != nil {
+		if result.Err() == mongo.ErrNoDocuments {
+			return msg, newErrorNotFound(messageCollectionName, id)
+		}
+
+		return msg, result.Err()
+	}
+
+	if err := result.Decode(&dbResult); err != nil {
+		return msg, err
+	}
+
+	return dbResult.toModel(), nil
+}
+
 func (r *messageRepository) WhereInstagramAttribute(filter domain.MessageRepositoryInstagramAttributeFilter, limit int) ([]model.Message, error) {
 	var dbResult []message
 
@@ -382,6 +487,14 @@ func (m message) toModel() model.Message {
 		},
 	}
 
+	for _, reaction := range m.Reactions {
+		msg.Reactions = append(msg.Reactions, model.Reaction{
+			UserID:    reaction.UserID,
+			Emoji:     reaction.Emoji,
+			Timestamp: reaction.Timestamp,
+		})
+	}
+
 	switch m.Type {
 	case model.MessageTypeText:
 		msg.Payload = model.MessageText{
@@ -454,6 +567,19 @@ func (m message) toModel() model.Message {
 				Url: m.Payload.Url,
 			},
 		}
+	case model.MessageTypeReaction:
+		msg.Payload = model.MessageReaction{
+			MessageID: m.Payload.MessageID,
+			ItemID:    m.Payload.ItemID,
+			Emoji:     m.Payload.Emoji,
+			Remove:    m.Payload.Remove,
+		}
+	case model.MessageTypeSeen:
+		msg.Payload = model.MessageSeen{
+			MessageID: m.Payload.MessageID,
+			ItemID:    m.Payload.ItemID,
+			Timestamp: m.Payload.Timestamp,
+		}
 	case model.MessageTypeUndefined:
 		msg.Payload = model.MessageText{
 			Text: m.Payload.Text,
@@ -494,6 +620,15 @@ func (m *message) fromModel(msg model.Message) error {
 		},
 	}
 
+	m.Reactions = make([]MessageReaction, 0, len(msg.Reactions))
+	for _, reaction := range msg.Reactions {
+		m.Reactions = append(m.Reactions, MessageReaction{
+			UserID:    reaction.UserID,
+			Emoji:     reaction.Emoji,
+			Timestamp: reaction.Timestamp,
+		})
+	}
+
 	if msg.Payload == nil {
 		return errors.New("Payload is empty")
 	}
@@ -538,6 +673,15 @@ func (m *message) fromModel(msg model.Message) error {
 	case model.MessageMediaVoice:
 		m.Payload.ID = payload.ID
 		m.Payload.Url = payload.Url
+	case model.MessageReaction:
+		m.Payload.MessageID = payload.MessageID
+		m.Payload.ItemID = payload.ItemID
+		m.Payload.Emoji = payload.Emoji
+		m.Payload.Remove = payload.Remove
+	case model.MessageSeen:
+		m.Payload.MessageID = payload.MessageID
+		m.Payload.ItemID = payload.ItemID
+		m.Payload.Timestamp = payload.Timestamp
 	case model.MessageUndefined:
 		m.Payload.Text = payload.Text
 	}
@@ -0,0 +1,133 @@
+package mongo
+
+import (
+	"time"
+
+	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const slotAssignmentCollectionName = "slot_assignment"
+
+type slotAssignmentRepository struct {
+	collection *mongo.Collection
+	timeout    time.Duration
+}
+
+type slotAssignment struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	Username  string             `bson:"username"`
+	Host      string             `bson:"host"`
+	CreatedAt time.Time          `bson:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at"`
+}
+
+func SlotAssignmentRepository(db *mongo.Database) domain.SlotAssignmentRepository {
+	return &slotAssignmentRepository{
+		collection: db.Collection(slotAssignmentCollectionName),
+		timeout:    120 * time.Second,
+	}
+}
+
+func (r *slotAssignmentRepository) Collection() *mongo.Collection {
+	return r.collection
+}
+
+func (r *slotAssignmentRepository) GetContextTimeout() time.Duration {
+	return r.timeout
+}
+
+// Assign Закрепляет аккаунт за хостом одной операцией upsert по username
+// Прежние закрепления этого хоста за другими аккаунтами снимаются
+func (r *slotAssignmentRepository) Assign(username, host string) (model.SlotAssignment, error) {
+	now := time.Now()
+
+	update := bson.M{
+		"$set": bson.M{
+			"host":       host,
+			"updated_at": now,
+		},
+		"$setOnInsert": bson.M{
+			"_id":        primitive.NewObjectID(),
+			"created_at": now,
+		},
+	}
+
+	findOptions := options.FindOneAndUpdate().
+		SetUpsert(true).
+		SetReturnDocument(options.After)
+
+	dbModel := slotAssignment{}
+	result := findOneAndUpdate(r, &bson.M{"username": username}, update, findOptions)
+	if result.Err() != nil {
+		return model.SlotAssignment{}, result.Err()
+	}
+
+	if err := result.Decode(&dbModel); err != nil {
+		return model.SlotAssignment{}, err
+	}
+
+	if err := deleteMany(r, &bson.M{"host": host, "username": bson.M{"$ne": username}}); err != nil {
+		return model.SlotAssignment{}, err
+	}
+
+	return dbModel.toModel(), nil
+}
+
+// Release Снимает закрепление, только если аккаунт все еще закреплен за указанным хостом
+func (r *slotAssignmentRepository) Release(username, host string) error {
+	_, err := deleteOne(r, &bson.M{"username": username, "host": host})
+	return err
+}
+
+func (r *slotAssignmentRepository) All() ([]model.SlotAssignment, error) {
+	var dbResult []slotAssignment
+
+	findOptions := options.Find().
+		SetSort(bson.M{"username": 1})
+
+	err := findAndDecode(r, &bson.M{}, &dbResult, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]model.SlotAssignment, 0, len(dbResult))
+	for _, r := range dbResult {
+		result = append(result, r.toModel())
+	}
+
+	return result, nil
+}
+
+func (r *slotAssignmentRepository) WhereUsername(username string) (a model.SlotAssignment, err error) {
+	dbModel := slotAssignment{}
+	result := findOne(r, &bson.M{"username": username})
+	if result.Err() != nil {
+		if result.Err() == mongo.ErrNoDocuments {
+			return a, newErrorNotFound(slotAssignmentCollectionName, username)
+		}
+
+		return a, result.Err()
+	}
+
+	if err := result.Decode(&dbModel); err != nil {
+		return a, err
+	}
+
+	return dbModel.toModel(), nil
+}
+
+func (a slotAssignment) toModel() model.SlotAssignment {
+	return model.SlotAssignment{
+		ID:        a.ID.Hex(),
+		Username:  a.Username,
+		Host:      a.Host,
+		CreatedAt: a.CreatedAt,
+		UpdatedAt: a.UpdatedAt,
+	}
+}
@@ -0,0 +1,101 @@
+package mongo
+
+import (
+	"time"
+
+	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const slotMaintenanceCollectionName = "slot_maintenance"
+
+type slotMaintenanceRepository struct {
+	collection *mongo.Collection
+	timeout    time.Duration
+}
+
+type slotMaintenance struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	Host      string             `bson:"host"`
+	Mode      model.SlotMode     `bson:"mode"`
+	UpdatedAt time.Time          `bson:"updated_at"`
+}
+
+func SlotMaintenanceRepository(db *mongo.Database) domain.SlotMaintenanceRepository {
+	return &slotMaintenanceRepository{
+		collection: db.Collection(slotMaintenanceCollectionName),
+		timeout:    120 * time.Second,
+	}
+}
+
+func (r *slotMaintenanceRepository) Collection() *mongo.Collection {
+	return r.collection
+}
+
+func (r *slotMaintenanceRepository) GetContextTimeout() time.Duration {
+	return r.timeout
+}
+
+// Store Сохраняет режим слота одной операцией upsert по host
+func (r *slotMaintenanceRepository) Store(m model.SlotMaintenance) (model.SlotMaintenance, error) {
+	update := bson.M{
+		"$set": bson.M{
+			"mode":       m.Mode,
+			"updated_at": m.UpdatedAt,
+		},
+		"$setOnInsert": bson.M{
+			"_id": primitive.NewObjectID(),
+		},
+	}
+
+	findOptions := options.FindOneAndUpdate().
+		SetUpsert(true).
+		SetReturnDocument(options.After)
+
+	dbModel := slotMaintenance{}
+	result := findOneAndUpdate(r, &bson.M{"host": m.Host}, update, findOptions)
+	if result.Err() != nil {
+		return model.SlotMaintenance{}, result.Err()
+	}
+
+	if err := result.Decode(&dbModel); err != nil {
+		return model.SlotMaintenance{}, err
+	}
+
+	return dbModel.toModel(), nil
+}
+
+func (r *slotMaintenanceRepository) DeleteHost(host string) error {
+	_, err := deleteOne(r, &bson.M{"host": host})
+	return err
+}
+
+func (r *slotMaintenanceRepository) All() ([]model.SlotMaintenance, error) {
+	var dbResult []slotMaintenance
+
+	err := findAndDecode(r, &bson.M{}, &dbResult)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]model.SlotMaintenance, 0, len(dbResult))
+	for _, r := range dbResult {
+		result = append(result, r.toModel())
+	}
+
+	return result, nil
+}
+
+func (m slotMaintenance) toModel() model.SlotMaintenance {
+	return model.SlotMaintenance{
+		ID:        m.ID.Hex(),
+		Host:      m.Host,
+		Mode:      m.Mode,
+		UpdatedAt: m.UpdatedAt,
+	}
+}
@@ -19,6 +19,12 @@ func findOne(r Repository, query interface{}, opts ...*options.FindOneOptions) *
 	return r.Collection().FindOne(ctx, query, opts...)
 }
 
+func findOneAndUpdate(r Repository, query interface{}, update interface{}, opts ...*options.FindOneAndUpdateOptions) *mongo.SingleResult {
+	ctx, cancel := context.WithTimeout(context.Background(), r.GetContextTimeout())
+	defer cancel()
+	return r.Collection().FindOneAndUpdate(ctx, query, update, opts...)
+}
+
 func insertOne(r Repository, document interface{}, opts ...*options.InsertOneOptions) (*mongo.InsertOneResult, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), r.GetContextTimeout())
 	defer cancel()
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/model"
+)
+
+func newTestSlot(host string, users ...string) domain.SlotContainer {
+	return domain.SlotContainer{
+		Slot: domain.Slot{
+			Host:       host,
+			Status:     domain.SlotStatusFree,
+			Attributes: domain.SlotAttributes{Weight: 1},
+		},
+		Metadata: domain.SlotMetadata{
+			Users: users,
+		},
+		Health: domain.SlotHealth{
+			Status: domain.SlotHealthHealthy,
+		},
+	}
+}
+
+func TestChooseSlot(t *testing.T) {
+	tests := []struct {
+		name  string
+		tag   string
+		slots func() []domain.SlotContainer
+		want  string
+	}{
+		{
+			name: "session",
+			slots: func() []domain.SlotContainer {
+				a := newTestSlot("a", "bob", "carol")
+				b := newTestSlot("b", "alice", "bob", "carol")
+				b.Health.Status = domain.SlotHealthDegraded
+
+				return []domain.SlotContainer{a, b}
+			},
+			want: "b",
+		},
+		{
+			name: "healthy",
+			slots: func() []domain.SlotContainer {
+				a := newTestSlot("a")
+				a.Health.Status = domain.SlotHealthDegraded
+
+				return []domain.SlotContainer{a, newTestSlot("b", "bob")}
+			},
+			want: "b",
+		},
+		{
+			name: "proxy tag",
+			tag:  "proxy-1",
+			slots: func() []domain.SlotContainer {
+				b := newTestSlot("b", "bob")
+				b.Slot.Attributes.Tags = []string{"proxy-1"}
+
+				return []domain.SlotContainer{newTestSlot("a"), b}
+			},
+			want: "b",
+		},
+		{
+			name: "load by weight",
+			slots: func() []domain.SlotContainer {
+				b := newTestSlot("b", "bob", "carol")
+				b.Slot.Attributes.Weight = 4
+
+				return []domain.SlotContainer{newTestSlot("a", "dave"), b}
+			},
+			want: "b",
+		},
+		{
+			name: "latency",
+			slots: func() []domain.SlotContainer {
+				a := newTestSlot("a")
+				a.Health.Latency = time.Second
+				b := newTestSlot("b")
+				b.Health.Latency = time.Millisecond
+
+				return []domain.SlotContainer{a, b}
+			},
+			want: "b",
+		},
+		{
+			name: "skips unavailable, busy, disabled, cordoned and full",
+			slots: func() []domain.SlotContainer {
+				unavailable := newTestSlot("unavailable")
+				unavailable.Health.Status = domain.SlotHealthUnavailable
+				busy := newTestSlot("busy")
+				busy.Slot.Status = domain.SlotStatusBusy
+				disabled := newTestSlot("disabled")
+				disabled.Slot.Attributes.Disabled = true
+				cordoned := newTestSlot("cordoned")
+				cordoned.Slot.Mode = model.SlotModeCordoned
+				full := newTestSlot("full", "bob")
+				full.Slot.Attributes.MaxAccounts = 1
+
+				return []domain.SlotContainer{unavailable, busy, disabled, cordoned, full, newTestSlot("free", "bob", "carol", "dave")}
+			},
+			want: "free",
+		},
+		{
+			name: "full slot with session",
+			slots: func() []domain.SlotContainer {
+				full := newTestSlot("full", "alice")
+				full.Slot.Attributes.MaxAccounts = 1
+
+				return []domain.SlotContainer{newTestSlot("free"), full}
+			},
+			want: "full",
+		},
+		{
+			name: "none",
+			slots: func() []domain.SlotContainer {
+				busy := newTestSlot("busy")
+				busy.Slot.Status = domain.SlotStatusBusy
+
+				return []domain.SlotContainer{busy}
+			},
+			want: "",
+		},
+	}
+
+	for _, test := range tests {
+		f := &factory{slots: test.slots()}
+
+		got := ""
+		if i := f.chooseSlot("alice", test.tag); i >= 0 {
+			got = f.slots[i].Slot.Host
+		}
+
+		if got != test.want {
+			t.Errorf("%s: want %q, got %q", test.name, test.want, got)
+		}
+	}
+}
@@ -9,11 +9,11 @@ import (
 	"net/url"
 	"os"
 	"sort"
-	"strings"
 	"sync"
 	"time"
 
 	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/model"
 	"channels-instagram-dm/service/instagram_api"
 )
 
@@ -22,25 +22,67 @@ const (
 )
 
 type factory struct {
-	ctx      context.Context
-	mx       *sync.Mutex
-	logger   domain.Logger
-	slotsURI string
-	slots    []domain.SlotContainer
+	ctx         context.Context
+	mx          *sync.Mutex
+	logger      domain.Logger
+	slotsURI    string
+	slots       []domain.SlotContainer
+	assignments domain.SlotAssignmentRepository
+	maintenance domain.SlotMaintenanceRepository
+	credentials domain.CredentialsRepository
+	assigned    map[string]string         // Закрепленные хосты слотов по username
+	modes       map[string]model.SlotMode // Административные режимы слотов по host
+	health      map[string]*healthWindow  // Проверки слотов по host
+	releasing   map[string]string         // Покинутые аккаунтом слоты по host, освобождаются после завершения его сессии
+	recorder    *instagram_api.Recorder   // Запись кадров слотов, nil - запись выключена
 }
 
-func Factory(ctx context.Context, logger domain.Logger, slotsURI string) (domain.Service, error) {
+// Factory Пустой recordDir - кадры слотов не записываются
+func Factory(ctx context.Context, logger domain.Logger, slotsURI string, recordDir string, repository domain.Repository) (domain.Service, error) {
 	f := &factory{
-		ctx:      ctx,
-		slotsURI: slotsURI,
-		logger:   logger,
-		mx:       &sync.Mutex{},
+		ctx:         ctx,
+		slotsURI:    slotsURI,
+		logger:      logger,
+		mx:          &sync.Mutex{},
+		assignments: repository.SlotAssignmentRepository(),
+		maintenance: repository.SlotMaintenanceRepository(),
+		credentials: repository.CredentialsRepository(),
+		assigned:    make(map[string]string),
+		modes:       make(map[string]model.SlotMode),
+		health:      make(map[string]*healthWindow),
+		releasing:   make(map[string]string),
+	}
+
+	if recordDir != "" {
+		recorder, err := instagram_api.NewRecorder(recordDir)
+		if err != nil {
+			return nil, fmt.Errorf("NewRecorder: Error %s", err)
+		}
+
+		f.recorder = recorder
+
+		go func() {
+			<-ctx.Done()
+			recorder.Close()
+		}()
+	}
+
+	// Режимы восстанавливаются до первого сканирования, чтобы новые слоты сразу получили свой режим
+	if err := f.restoreModes(); err != nil {
+		return nil, fmt.Errorf("RestoreModes: Error %s", err)
 	}
 
 	if err := f.RefreshSlots(); err != nil {
 		return nil, fmt.Errorf("ScanSlots: Error %s", err)
 	}
 
+	// Закрепления восстанавливаются до первого login, иначе аккаунты разойдутся по свободным слотам
+	if err := f.restoreAssignments(); err != nil {
+		return nil, fmt.Errorf("RestoreAssignments: Error %s", err)
+	}
+
+	go f.monitorHealth()
+
 	go func() {
 		for {
 			select {
@@ -63,16 +105,16 @@ func (f *factory) RefreshSlots() error {
 	f.mx.Lock()
 	defer f.mx.Unlock()
 
-	hosts, err := f.readSlots()
+	slots, err := f.readSlots()
 	if err != nil {
 		return err
 	}
 
-	if len(hosts) == 0 {
+	if len(slots) == 0 {
 		f.logger.Info("RefreshSlots: Hosts is empty", nil)
 	}
 
-	if err := f.scanSlots(hosts); err != nil {
+	if err := f.scanSlots(slots); err != nil {
 		return err
 	}
 
@@ -81,33 +123,35 @@ func (f *factory) RefreshSlots() error {
 	return nil
 }
 
-func (f *factory) readSlots() ([]string, error) {
+func (f *factory) readSlots() ([]domain.Slot, error) {
 	u, err := url.Parse(f.slotsURI)
 	if err != nil {
-		return []string{}, err
+		return []domain.Slot{}, err
 	}
 
 	var reader io.Reader
+	contentType := ""
 
 	switch u.Scheme {
 	case "http", "https":
 		resp, err := http.Get(u.String())
 		if err != nil {
-			return []string{}, err
+			return []domain.Slot{}, err
 		}
 
 		reader = resp.Body
+		contentType = resp.Header.Get("Content-Type")
 	default:
 		file, err := os.Open(u.String())
 		if err != nil {
-			return []string{}, err
+			return []domain.Slot{}, err
 		}
 
 		reader = file
 	}
 
 	if reader == nil {
-		return []string{}, nil
+		return []domain.Slot{}, nil
 	}
 
 	defer func() {
@@ -120,38 +164,20 @@ func (f *factory) readSlots() ([]string, error) {
 
 	bs, err := ioutil.ReadAll(reader)
 	if err != nil {
-		return []string{}, err
+		return []domain.Slot{}, err
 	}
 
 	if len(bs) == 0 {
-		return []string{}, nil
-	}
-
-	data := string(bs[:])
-	rows := strings.Split(data, "\n")
-
-	hosts := make([]string, 0, len(rows))
-
-	for _, row := range rows {
-		if row == "" {
-			continue
-		}
-
-		hosts = append(hosts, strings.TrimSpace(row))
+		return []domain.Slot{}, nil
 	}
 
-	return hosts, nil
+	return parseSlots(u.Path, contentType, bs)
 }
 
 // Attention: Операция должна использоваться только в атомарном вызове f.mx.Lock()
-func (f *factory) scanSlots(hosts []string) error {
-	slots := make([]domain.Slot, 0, len(hosts))
-
-	for _, host := range hosts {
-		slots = append(slots, domain.Slot{
-			Host:   host,
-			Status: domain.SlotStatusFree,
-		})
+func (f *factory) scanSlots(slots []domain.Slot) error {
+	for i := range slots {
+		slots[i].Mode = f.slotMode(slots[i].Host)
 	}
 
 	slotContainers := make([]domain.SlotContainer, 0, len(slots))
@@ -160,8 +186,9 @@ func (f *factory) scanSlots(hosts []string) error {
 REMOVE_LIST:
 	for _, sc := range f.slots {
 		for _, s := range slots {
-			// Копируем совпадающие слоты
+			// Копируем совпадающие слоты, атрибуты обновляются из источника
 			if sc.Slot.Host == s.Host {
+				sc.Slot.Attributes = s.Attributes
 				slotContainers = append(slotContainers, sc)
 				continue REMOVE_LIST
 			}
@@ -269,7 +296,43 @@ func (f *factory) discoverySlots() {
 		}
 
 		if sc.Slot.Status == domain.SlotStatusUnavailable {
+			// Слот выводится из работы: аккаунт остановлен, сессия остается на хосте до его обновления
+			if sc.Slot.Mode == model.SlotModeDraining {
+				if sc.Username != "" {
+					f.unassign(sc.Username, sc.Slot.Host)
+				}
+
+				delete(f.releasing, sc.Slot.Host)
+
+				sc.Username = ""
+				sc.Slot.Status = domain.SlotStatusFree
+
+				// Фиксируем изменения
+				f.slots[i] = sc
+
+				f.logger.Info(fmt.Sprintf("DiscoveryService: Slot %s was drained", sc.Slot.Host), nil)
+				continue
+			}
+
+			// Слот покинут аккаунтом, освобождаем только после завершения его сессии
+			if username, ok := f.releasing[sc.Slot.Host]; ok {
+				if discovered.ActiveUser == username {
+					// Фиксируем изменения
+					f.slots[i] = sc
+
+					f.logger.Info(fmt.Sprintf("DiscoveryService: Slot %s, Session of %s is still active", sc.Slot.Host, username), nil)
+
+					go f.logoutReleasing(sc.Slot.Host, sc.Service, username)
+					continue
+				}
+
+				delete(f.releasing, sc.Slot.Host)
+
+				f.logger.Info(fmt.Sprintf("DiscoveryService: Slot %s, Session of %s is gone", sc.Slot.Host, username), nil)
+			}
+
 			// Слот был освобожден через logout или перезапущен. Зачищаем окончательно
+			// Закрепление остается: аккаунт вернется на тот же хост при следующем обращении к сервису
 			if discovered.ActiveUser == "" {
 				sc.Username = ""
 				sc.Slot.Status = domain.SlotStatusFree
@@ -308,99 +371,631 @@ func (f *factory) Slots() []domain.SlotContainer {
 	return f.slots
 }
 
-func (f *factory) InstagramAPI(username string) (domain.InstagramAPI, error) {
-	service := f.takeService(username)
-	if service == nil {
-		return nil, fmt.Errorf("No available servers")
+// AssignSlot Вручную закрепляет аккаунт за хостом слота
+// Если аккаунт занимает другой слот, аккаунт займет новый слот при следующем обращении к сервису,
+// а прежний слот освободится после завершения на нем сессии аккаунта
+func (f *factory) AssignSlot(username, host string) error {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	target := -1
+
+	for i, sc := range f.slots {
+		if sc.Slot.Host == host {
+			target = i
+			break
+		}
 	}
 
-	if service.IsClosed() {
-		if err := f.RefreshSlots(); err != nil {
-			f.logger.Error(fmt.Sprintf("RefreshSlots: Error %s", err), nil)
+	if target < 0 {
+		return domain.NewErrorNotFound(fmt.Sprintf("Slot %s not found", host))
+	}
+
+	if f.slots[target].Slot.Mode == model.SlotModeDraining {
+		return domain.NewErrorInvalidArgument(fmt.Sprintf("Slot %s is %s", host, f.slots[target].Slot.Mode))
+	}
+
+	if f.slots[target].Slot.Status == domain.SlotStatusBusy && f.slots[target].Username != username {
+		return domain.NewErrorInvalidArgument(fmt.Sprintf("Slot %s is busy by %s", host, f.slots[target].Username))
+	}
+
+	if _, err := f.assignments.Assign(username, host); err != nil {
+		return err
+	}
+
+	f.setAssigned(username, host)
+
+	// Слот, покинутый аккаунтом (например, при откате переноса), возвращается ему сразу,
+	// чтобы запуск аккаунта не застал слот недоступным до следующего discovery
+	if f.releasing[host] == username {
+		delete(f.releasing, host)
+
+		f.slots[target].Username = username
+		f.rebindSlot(target, username)
+	}
+
+	for i, sc := range f.slots {
+		if sc.Username != username || sc.Slot.Host == host {
+			continue
 		}
+
+		f.release(i, username)
+
+		f.logger.Info(fmt.Sprintf("AssignSlot: Slot %s was released by %s", sc.Slot.Host, username), nil)
 	}
 
-	return service, nil
-}
+	f.logger.Info(fmt.Sprintf("AssignSlot: Slot %s was assigned to %s", host, username), nil)
 
-func (f *factory) createService(slot domain.Slot) (domain.InstagramAPI, error) {
-	return instagram_api.NewService(f.ctx, f.logger.Copy(fmt.Sprintf("(host=%s)", slot.Host)), slot.Host)
+	return nil
 }
 
-func (f *factory) takeService(username string) domain.InstagramAPI {
+// MigrateSlot Переносит аккаунт на другой слот: прежний слот закрывается, выбранный закрепляется за аккаунтом
+// Пустой host - слот выбирается автоматически
+func (f *factory) MigrateSlot(username, host string) (domain.SlotContainer, error) {
+	// Метка прокси читается из репозитория до блокировки
+	tag := ""
+	if host == "" {
+		tag = f.proxyTag(username)
+	}
+
 	f.mx.Lock()
 	defer f.mx.Unlock()
 
-	// Ищем свой активный слот
-	for _, sc := range f.slots {
-		if sc.Username == username {
-			// Аккаунт не должен занимать соседних слотов, в случае проблем с текущим
-			// Иначе может возникнуть ситуация, когда неисправный аккаунт способен забрать все свободные слоты
-			// Решение проблемы: отозвать слот
-			if sc.Slot.Status == domain.SlotStatusUnavailable {
-				return nil
-			}
-
-			return sc.Service
+	target := f.migrationTarget(username, host, tag)
+	if target < 0 {
+		if host != "" {
+			return domain.SlotContainer{}, domain.NewErrorInvalidArgument(fmt.Sprintf("Slot %s is not available", host))
 		}
+
+		return domain.SlotContainer{}, fmt.Errorf("No available servers")
 	}
 
-	// Ищем наиболее подходящий слот
 	for i, sc := range f.slots {
-		if sc.Slot.Status == domain.SlotStatusUnavailable {
+		if sc.Username != username {
 			continue
 		}
 
-		if sc.Slot.Status == domain.SlotStatusBusy {
+		if sc.Service != nil {
+			sc.Service.Close()
+		}
+
+		f.release(i, username)
+		f.slots[i].Service = nil
+
+		f.logger.Info(fmt.Sprintf("MigrateSlot: Slot %s was released by %s", sc.Slot.Host, username), nil)
+	}
+
+	f.bindSlot(target, username)
+
+	f.logger.Info(fmt.Sprintf("MigrateSlot: Slot %s was taken by %s", f.slots[target].Slot.Host, username), nil)
+
+	return f.slots[target], nil
+}
+
+// Attention: Операция должна использоваться только в атомарном вызове f.mx.Lock()
+// release Слот покидается аккаунтом, но остается недоступным, пока discovery не подтвердит завершение его сессии
+func (f *factory) release(i int, username string) {
+	f.releasing[f.slots[i].Slot.Host] = username
+
+	f.slots[i].Username = ""
+	f.slots[i].Slot.Status = domain.SlotStatusUnavailable
+}
+
+// Attention: Операция должна использоваться только в атомарном вызове f.mx.Lock()
+// rebindSlot Проверяет через discovery слот, возвращенный аккаунту. Активная сессия аккаунта занимает слот,
+// без сессии слот становится свободным и будет занят аккаунтом по закреплению. Сбой оставляет слот следующему discovery
+func (f *factory) rebindSlot(i int, username string) {
+	sc := f.slots[i]
+
+	if sc.Service == nil || sc.Service.IsClosed() {
+		service, err := f.createService(sc.Slot)
+		if err != nil {
+			f.logger.Error(fmt.Sprintf("RebindSlot: Slot %s, Error. %s", sc.Slot.Host, err), nil)
+			return
+		}
+
+		sc.Service = service
+	}
+
+	discovered, err := sc.Service.Discovery()
+	if err != nil {
+		sc.Service.Close()
+		sc.Service = nil
+
+		// Фиксируем изменения
+		f.slots[i] = sc
+
+		f.logger.Error(fmt.Sprintf("RebindSlot: Slot %s, Discovery error. %s", sc.Slot.Host, err), nil)
+		return
+	}
+
+	sc.Metadata = domain.SlotMetadata{
+		Users:      discovered.Users,
+		ActiveUser: discovered.ActiveUser,
+	}
+
+	switch discovered.ActiveUser {
+	case username:
+		sc.Slot.Status = domain.SlotStatusBusy
+	case "":
+		sc.Username = ""
+		sc.Slot.Status = domain.SlotStatusFree
+	default:
+		f.logger.Error(fmt.Sprintf("RebindSlot: Slot %s, Users is mismatch, want %s, got %s", sc.Slot.Host, username, discovered.ActiveUser), nil)
+	}
+
+	// Фиксируем изменения
+	f.slots[i] = sc
+
+	f.logger.Info(fmt.Sprintf("RebindSlot: Slot %s is %s for %s", sc.Slot.Host, sc.Slot.Status, username), nil)
+}
+
+// logoutReleasing Завершает сессию аккаунта, оставшуюся на покинутом слоте
+// Выполняется вне блокировки, результат проверит следующий discovery
+func (f *factory) logoutReleasing(host string, service domain.InstagramAPI, username string) {
+	if err := service.Logout(); err != nil {
+		f.logger.Error(fmt.Sprintf("LogoutReleasing: Slot %s, Failed to logout %s. %s", host, username, err), nil)
+		return
+	}
+
+	f.logger.Info(fmt.Sprintf("LogoutReleasing: Slot %s, Session of %s was closed", host, username), nil)
+}
+
+// Attention: Операция должна использоваться только в атомарном вызове f.mx.Lock()
+func (f *factory) migrationTarget(username, host, tag string) int {
+	if host == "" {
+		return f.chooseSlot(username, tag)
+	}
+
+	for i, sc := range f.slots {
+		if sc.Slot.Host != host || sc.Username == username || sc.Service == nil {
 			continue
 		}
 
-		for _, user := range sc.Metadata.Users {
-			if user == username {
-				f.slots[i].Slot.Status = domain.SlotStatusBusy
-				f.slots[i].Username = username
-				return sc.Service
-			}
+		if sc.Slot.Status != domain.SlotStatusFree || !sc.Slot.IsAssignable() || !hasCapacity(sc, username) {
+			continue
 		}
+
+		return i
 	}
 
-	// Ищем слот из совсех свободных
+	return -1
+}
+
+// Attention: Операция должна использоваться только в атомарном вызове f.mx.Lock()
+// chooseSlot Выбирает свободный слот: сначала слот с сессией аккаунта, затем исправный слот, затем слот с меткой прокси аккаунта,
+// затем наименее загруженный с учетом веса и, наконец, самый быстрый
+func (f *factory) chooseSlot(username, tag string) int {
+	best := -1
+
 	for i, sc := range f.slots {
-		if sc.Slot.Status == domain.SlotStatusUnavailable {
+		if sc.Slot.Status != domain.SlotStatusFree || !sc.Slot.IsAssignable() {
 			continue
 		}
 
-		if sc.Slot.Status == domain.SlotStatusBusy {
+		if sc.Health.Status == domain.SlotHealthUnavailable {
 			continue
 		}
 
-		if len(sc.Metadata.Users) == 0 {
-			f.slots[i].Slot.Status = domain.SlotStatusBusy
-			f.slots[i].Username = username
+		if !hasCapacity(sc, username) {
+			continue
+		}
 
-			return sc.Service
+		if best < 0 || betterSlot(sc, f.slots[best], username, tag) {
+			best = i
 		}
 	}
 
-	// Ищем слот с минимальным количеством аккаунтов
-	sort.Slice(f.slots, func(i, j int) bool {
-		return len(f.slots[i].Metadata.Users) < len(f.slots[j].Metadata.Users)
-	})
+	return best
+}
+
+// proxyTag Метка слота, соответствующая прокси аккаунта (хост прокси)
+func (f *factory) proxyTag(username string) string {
+	credentials, err := f.credentials.WhereUsername(username)
+	if err != nil {
+		f.logger.Debug(fmt.Sprintf("ProxyTag: Credentials of %s, Error. %s", username, err), nil)
+		return ""
+	}
+
+	if credentials.Proxy == "" {
+		return ""
+	}
+
+	u, err := url.Parse(credentials.Proxy)
+	if err != nil {
+		return ""
+	}
+
+	return u.Hostname()
+}
+
+func betterSlot(a, b domain.SlotContainer, username, tag string) bool {
+	if hasSession(a, username) != hasSession(b, username) {
+		return hasSession(a, username)
+	}
+
+	if isDegraded(a) != isDegraded(b) {
+		return isDegraded(b)
+	}
+
+	if hasTag(a, tag) != hasTag(b, tag) {
+		return hasTag(a, tag)
+	}
+
+	loadA, loadB := slotLoad(a), slotLoad(b)
+	if loadA != loadB {
+		return loadA < loadB
+	}
+
+	if a.Slot.Attributes.Weight != b.Slot.Attributes.Weight {
+		return a.Slot.Attributes.Weight > b.Slot.Attributes.Weight
+	}
+
+	return a.Health.Latency < b.Health.Latency
+}
+
+func isDegraded(sc domain.SlotContainer) bool {
+	return sc.Health.Status == domain.SlotHealthDegraded
+}
+
+func hasSession(sc domain.SlotContainer, username string) bool {
+	if sc.Metadata.ActiveUser == username {
+		return true
+	}
+
+	for _, user := range sc.Metadata.Users {
+		if user == username {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasTag(sc domain.SlotContainer, tag string) bool {
+	if tag == "" {
+		return false
+	}
+
+	for _, t := range sc.Slot.Attributes.Tags {
+		if t == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasCapacity Аккаунт с сессией на слоте не увеличивает количество сессий
+func hasCapacity(sc domain.SlotContainer, username string) bool {
+	max := sc.Slot.Attributes.MaxAccounts
+
+	return max == 0 || len(sc.Metadata.Users) < max || hasSession(sc, username)
+}
+
+// slotLoad Количество сессий на слоте относительно его веса
+func slotLoad(sc domain.SlotContainer) float64 {
+	weight := sc.Slot.Attributes.Weight
+	if weight <= 0 {
+		weight = 1
+	}
+
+	return float64(len(sc.Metadata.Users)) / float64(weight)
+}
+
+// SetSlotMode Изменяет административный режим слота, занятый слот остается за своим аккаунтом
+func (f *factory) SetSlotMode(host string, mode model.SlotMode) (domain.SlotContainer, error) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
 
 	for i, sc := range f.slots {
-		if sc.Slot.Status == domain.SlotStatusUnavailable {
+		if sc.Slot.Host != host {
 			continue
 		}
 
-		if sc.Slot.Status == domain.SlotStatusBusy {
+		var err error
+		if mode == model.SlotModeActive {
+			err = f.maintenance.DeleteHost(host)
+		} else {
+			_, err = f.maintenance.Store(model.NewSlotMaintenance(host, mode))
+		}
+
+		if err != nil {
+			return sc, err
+		}
+
+		if mode == model.SlotModeActive {
+			delete(f.modes, host)
+		} else {
+			f.modes[host] = mode
+		}
+
+		f.slots[i].Slot.Mode = mode
+
+		f.logger.Info(fmt.Sprintf("SetSlotMode: Slot %s is %s", host, mode), nil)
+
+		return f.slots[i], nil
+	}
+
+	return domain.SlotContainer{}, domain.NewErrorNotFound(fmt.Sprintf("Slot %s not found", host))
+}
+
+// ReleaseSlot Освобождает слот от аккаунта, который не запущен
+// Сервис закрывается, слот вернется в работу после discovery
+func (f *factory) ReleaseSlot(host string) error {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	for i, sc := range f.slots {
+		if sc.Slot.Host != host {
 			continue
 		}
 
-		f.slots[i].Slot.Status = domain.SlotStatusBusy
-		f.slots[i].Username = username
+		if sc.Service != nil {
+			sc.Service.Close()
+		}
+
+		if sc.Username != "" {
+			f.unassign(sc.Username, host)
+			f.release(i, sc.Username)
+		}
+
+		f.slots[i].Username = ""
+		f.slots[i].Slot.Status = domain.SlotStatusUnavailable
+		f.slots[i].Service = nil
+
+		f.logger.Info(fmt.Sprintf("ReleaseSlot: Slot %s was released by %s", host, sc.Username), nil)
+
+		return nil
+	}
+
+	return domain.NewErrorNotFound(fmt.Sprintf("Slot %s not found", host))
+}
+
+func (f *factory) restoreModes() error {
+	list, err := f.maintenance.All()
+	if err != nil {
+		return err
+	}
+
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	for _, maintenance := range list {
+		f.modes[maintenance.Host] = maintenance.Mode
+	}
+
+	return nil
+}
+
+func (f *factory) slotMode(host string) model.SlotMode {
+	if mode, ok := f.modes[host]; ok {
+		return mode
+	}
 
-		return sc.Service
+	return model.SlotModeActive
+}
+
+func (f *factory) restoreAssignments() error {
+	assignments, err := f.assignments.All()
+	if err != nil {
+		return err
+	}
+
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	for _, assignment := range assignments {
+		f.assigned[assignment.Username] = assignment.Host
+
+		for i, sc := range f.slots {
+			if sc.Slot.Host != assignment.Host || sc.Slot.Status != domain.SlotStatusFree {
+				continue
+			}
+
+			// Аккаунт выводимого из работы слота займет другой слот
+			if sc.Slot.Mode == model.SlotModeDraining {
+				continue
+			}
+
+			// Без сессии аккаунта на хосте слот не занимается, закрепление остается только предпочтением
+			if !hasSession(sc, assignment.Username) {
+				continue
+			}
+
+			f.slots[i].Slot.Status = domain.SlotStatusBusy
+			f.slots[i].Username = assignment.Username
+
+			f.logger.Info(fmt.Sprintf("RestoreAssignments: Slot %s was restored for %s", sc.Slot.Host, assignment.Username), nil)
+		}
 	}
 
 	return nil
 }
+
+// Attention: Операция должна использоваться только в атомарном вызове f.mx.Lock()
+func (f *factory) bindSlot(i int, username string) domain.InstagramAPI {
+	sc := f.slots[i]
+
+	f.slots[i].Slot.Status = domain.SlotStatusBusy
+	f.slots[i].Username = username
+
+	if f.assigned[username] != sc.Slot.Host {
+		// Слот уже занят аккаунтом, поэтому сбой записи не должен лишать его сервиса
+		if _, err := f.assignments.Assign(username, sc.Slot.Host); err != nil {
+			f.logger.Error(fmt.Sprintf("BindSlot: Slot %s, Failed to store assignment for %s. %s", sc.Slot.Host, username, err), nil)
+		}
+
+		f.setAssigned(username, sc.Slot.Host)
+	}
+
+	return sc.Service
+}
+
+// Attention: Операция должна использоваться только в атомарном вызове f.mx.Lock()
+func (f *factory) unassign(username, host string) {
+	if f.assigned[username] != host {
+		return
+	}
+
+	if err := f.assignments.Release(username, host); err != nil {
+		f.logger.Error(fmt.Sprintf("Unassign: Slot %s, Failed to release assignment for %s. %s", host, username, err), nil)
+		return
+	}
+
+	delete(f.assigned, username)
+
+	f.logger.Info(fmt.Sprintf("Unassign: Slot %s was released by %s", host, username), nil)
+}
+
+// Attention: Операция должна использоваться только в атомарном вызове f.mx.Lock()
+func (f *factory) setAssigned(username, host string) {
+	for user, assignedHost := range f.assigned {
+		if assignedHost == host {
+			delete(f.assigned, user)
+		}
+	}
+
+	f.assigned[username] = host
+}
+
+func (f *factory) InstagramAPI(username string) (domain.InstagramAPI, error) {
+	service := f.takeService(username)
+	if service == nil {
+		return nil, fmt.Errorf("No available servers")
+	}
+
+	if service.IsClosed() {
+		if err := f.RefreshSlots(); err != nil {
+			f.logger.Error(fmt.Sprintf("RefreshSlots: Error %s", err), nil)
+		}
+	}
+
+	return service, nil
+}
+
+func (f *factory) createService(slot domain.Slot) (domain.InstagramAPI, error) {
+	service, err := instagram_api.NewService(f.ctx, f.logger.Copy(fmt.Sprintf("(host=%s)", slot.Host)), slot.Host, slot.Attributes.Transport)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.recorder != nil {
+		service.SetRecorder(f.recorder)
+	}
+
+	go f.watchService(slot.Host, service)
+
+	return service, nil
+}
+
+// watchService Реагирует на изменения соединения слота, не дожидаясь RefreshSlots
+func (f *factory) watchService(host string, service domain.InstagramAPI) {
+	states, unsubscribe := service.SubscribeOnConnectionState()
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-f.ctx.Done():
+			return
+		case state := <-states:
+			f.logger.Info(fmt.Sprintf("WatchService: Slot %s, Connection is %s", host, state), nil)
+
+			if state == domain.ConnectionStateClosed || service.IsClosed() {
+				f.releaseService(service)
+				return
+			}
+		}
+	}
+}
+
+// releaseService Слот закрытого сервиса недоступен до следующего discovery
+func (f *factory) releaseService(service domain.InstagramAPI) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	for i, sc := range f.slots {
+		if sc.Service != service {
+			continue
+		}
+
+		f.slots[i].Slot.Status = domain.SlotStatusUnavailable
+		f.slots[i].Service = nil
+
+		f.logger.Info(fmt.Sprintf("ReleaseService: Slot %s is unavailable", sc.Slot.Host), nil)
+
+		return
+	}
+}
+
+func (f *factory) takeService(username string) domain.InstagramAPI {
+	f.mx.Lock()
+	service, ok := f.ownService(username)
+	f.mx.Unlock()
+
+	if ok {
+		return service
+	}
+
+	// Метка прокси читается из репозитория вне блокировки, за это время слоты могли измениться
+	tag := f.proxyTag(username)
+
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	if service, ok := f.ownService(username); ok {
+		return service
+	}
+
+	// Ищем наиболее подходящий слот из свободных
+	if i := f.chooseSlot(username, tag); i >= 0 {
+		return f.bindSlot(i, username)
+	}
+
+	return nil
+}
+
+// Attention: Операция должна использоваться только в атомарном вызове f.mx.Lock()
+// ownService Возвращает сервис активного или закрепленного за аккаунтом слота, false - нужно выбрать свободный слот
+func (f *factory) ownService(username string) (domain.InstagramAPI, bool) {
+	// Ищем свой активный слот
+	for _, sc := range f.slots {
+		if sc.Username == username {
+			// Аккаунт не должен занимать соседних слотов, в случае проблем с текущим
+			// Иначе может возникнуть ситуация, когда неисправный аккаунт способен забрать все свободные слоты
+			// Решение проблемы: отозвать слот
+			if sc.Slot.Status == domain.SlotStatusUnavailable {
+				return nil, true
+			}
+
+			return sc.Service, true
+		}
+	}
+
+	// Ищем закрепленный за аккаунтом слот
+	if host, ok := f.assigned[username]; ok {
+		for i, sc := range f.slots {
+			if sc.Slot.Host != host {
+				continue
+			}
+
+			// Слот выводится из работы, аккаунт переезжает на другой слот
+			if sc.Slot.Mode == model.SlotModeDraining {
+				break
+			}
+
+			// Закрепленный слот восстановится после discovery, соседние слоты не занимаем
+			if sc.Slot.Status == domain.SlotStatusUnavailable {
+				return nil, true
+			}
+
+			if sc.Slot.Status == domain.SlotStatusFree {
+				return f.bindSlot(i, username), true
+			}
+
+			f.logger.Error(fmt.Sprintf("TakeService: Slot %s, Assigned to %s, but busy by %s", sc.Slot.Host, username, sc.Username), nil)
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,509 @@
+package service
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/model/instagram"
+	"channels-instagram-dm/repository/fake_repository"
+	"channels-instagram-dm/service/fake_slot"
+)
+
+// testLogger Пишет в stderr только при go test -v, горутины фабрики переживают тест
+type testLogger struct {
+	path   string
+	logger *log.Logger
+}
+
+func newTestLogger() domain.Logger {
+	var out io.Writer = ioutil.Discard
+	if testing.Verbose() {
+		out = os.Stderr
+	}
+
+	return &testLogger{
+		logger: log.New(out, "", log.LstdFlags),
+	}
+}
+
+func (l *testLogger) Copy(prefix string) domain.Logger {
+	return &testLogger{
+		path:   l.path + " " + prefix,
+		logger: l.logger,
+	}
+}
+
+func (l *testLogger) Writer() io.Writer {
+	return l.logger.Writer()
+}
+
+func (l *testLogger) Critical(message string, payload interface{}) {
+	l.logger.Println("C:" + l.path + " " + message)
+}
+
+func (l *testLogger) Debug(message string, payload interface{}) {
+	l.logger.Println("D:" + l.path + " " + message)
+}
+
+func (l *testLogger) Info(message string, payload interface{}) {
+	l.logger.Println("I:" + l.path + " " + message)
+}
+
+func (l *testLogger) Error(message string, payload interface{}) {
+	l.logger.Println("E:" + l.path + " " + message)
+}
+
+type testFactory struct {
+	*factory
+	repository domain.Repository
+	slots      []*fake_slot.Server
+}
+
+func (tf *testFactory) host(i int) string {
+	return tf.slots[i].Host()
+}
+
+func (tf *testFactory) slot(t *testing.T, i int) domain.SlotContainer {
+	t.Helper()
+
+	tf.mx.Lock()
+	defer tf.mx.Unlock()
+
+	for _, sc := range tf.factory.slots {
+		if sc.Slot.Host == tf.host(i) {
+			return sc
+		}
+	}
+
+	t.Fatalf("slot %s not found", tf.host(i))
+	return domain.SlotContainer{}
+}
+
+func (tf *testFactory) assignedHost(t *testing.T, username string) string {
+	t.Helper()
+
+	assignment, err := tf.repository.SlotAssignmentRepository().WhereUsername(username)
+	if err != nil {
+		return ""
+	}
+
+	return assignment.Host
+}
+
+// newTestFactory Запускает count слотов fake_slot, prepare вызывается до создания фабрики
+func newTestFactory(t *testing.T, count int, prepare func(rep domain.Repository, slots []*fake_slot.Server)) *testFactory {
+	t.Helper()
+
+	slots := make([]*fake_slot.Server, 0, count)
+	hosts := make([]string, 0, count)
+
+	for i := 0; i < count; i++ {
+		server := fake_slot.NewServer()
+		if err := server.Start(""); err != nil {
+			t.Fatal(err)
+		}
+
+		t.Cleanup(func() { _ = server.Close() })
+
+		slots = append(slots, server)
+		hosts = append(hosts, server.Host())
+	}
+
+	slotsURI := filepath.Join(t.TempDir(), "instagram.slots")
+	if err := ioutil.WriteFile(slotsURI, []byte(strings.Join(hosts, "\n")), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := newTestLogger()
+	rep := fake_repository.NewRepository()
+
+	if prepare != nil {
+		prepare(rep, slots)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	service, err := Factory(ctx, logger, slotsURI, "", rep)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testFactory{
+		factory:    service.(*factory),
+		repository: rep,
+		slots:      slots,
+	}
+}
+
+func login(t *testing.T, api domain.InstagramAPI, username string) {
+	t.Helper()
+
+	required, err := api.Login(instagram.Credentials{
+		Username: username,
+		Password: "password",
+	})
+	if err != nil {
+		t.Fatalf("login %s: %s", username, err)
+	}
+
+	if required.Case != instagram.RequiredStepNone {
+		t.Fatalf("login %s: unexpected required %s", username, required.Case)
+	}
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition was not met in time")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestFactoryTakeService(t *testing.T) {
+	tf := newTestFactory(t, 2, func(rep domain.Repository, slots []*fake_slot.Server) {
+		// Сессия alice сохранилась на втором слоте
+		slots[1].AddAccount(fake_slot.Account{Username: "alice", Password: "password"})
+		slots[1].AddSession("alice")
+	})
+
+	api, err := tf.InstagramAPI("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sc := tf.slot(t, 1); sc.Username != "alice" || sc.Slot.Status != domain.SlotStatusBusy || sc.Service != api {
+		t.Fatalf("alice: want slot with session, got %s %s", sc.Username, sc.Slot.Status)
+	}
+
+	if host := tf.assignedHost(t, "alice"); host != tf.host(1) {
+		t.Fatalf("alice: want assignment %s, got %q", tf.host(1), host)
+	}
+
+	if again, err := tf.InstagramAPI("alice"); err != nil || again != api {
+		t.Fatalf("alice: want the same service, got %v", err)
+	}
+
+	if _, err := tf.InstagramAPI("bob"); err != nil {
+		t.Fatal(err)
+	}
+
+	if sc := tf.slot(t, 0); sc.Username != "bob" {
+		t.Fatalf("bob: want free slot, got %q", sc.Username)
+	}
+
+	if _, err := tf.InstagramAPI("carol"); err == nil {
+		t.Fatal("carol: want no available servers")
+	}
+}
+
+func TestFactoryRestoreAssignments(t *testing.T) {
+	tf := newTestFactory(t, 2, func(rep domain.Repository, slots []*fake_slot.Server) {
+		slots[1].AddAccount(fake_slot.Account{Username: "alice", Password: "password"})
+		slots[1].AddSession("alice")
+
+		if _, err := rep.SlotAssignmentRepository().Assign("alice", slots[1].Host()); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	// Закрепленный слот с сессией аккаунта занят до первого обращения аккаунта
+	if sc := tf.slot(t, 1); sc.Username != "alice" || sc.Slot.Status != domain.SlotStatusBusy {
+		t.Fatalf("alice: want restored slot, got %q %s", sc.Username, sc.Slot.Status)
+	}
+
+	api, err := tf.InstagramAPI("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sc := tf.slot(t, 1); sc.Service != api {
+		t.Fatal("alice: want service of assigned slot")
+	}
+
+	if _, err := tf.InstagramAPI("bob"); err != nil {
+		t.Fatal(err)
+	}
+
+	if sc := tf.slot(t, 0); sc.Username != "bob" {
+		t.Fatalf("bob: want free slot, got %q", sc.Username)
+	}
+}
+
+func TestFactoryAssignSlotRebindsReleased(t *testing.T) {
+	tf := newTestFactory(t, 2, func(rep domain.Repository, slots []*fake_slot.Server) {
+		slots[0].AddAccount(fake_slot.Account{Username: "alice", Password: "password"})
+	})
+
+	if err := tf.AssignSlot("alice", tf.host(0)); err != nil {
+		t.Fatal(err)
+	}
+
+	api, err := tf.InstagramAPI("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	login(t, api, "alice")
+
+	if _, err := tf.MigrateSlot("alice", tf.host(1)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Откат переноса: новый слот освобождается, аккаунт возвращается на прежний
+	if err := tf.ReleaseSlot(tf.host(1)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tf.AssignSlot("alice", tf.host(0)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Сессия alice на прежнем слоте активна: слот занят ею без ожидания discovery
+	sc := tf.slot(t, 0)
+	if sc.Username != "alice" || sc.Slot.Status != domain.SlotStatusBusy || sc.Service == nil {
+		t.Fatalf("rollback: want busy slot of alice, got %q %s", sc.Username, sc.Slot.Status)
+	}
+
+	rebound, err := tf.InstagramAPI("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rebound != sc.Service {
+		t.Fatal("rollback: want service of previous slot")
+	}
+}
+
+func TestFactoryRestoreAssignmentsWithoutSession(t *testing.T) {
+	tf := newTestFactory(t, 2, func(rep domain.Repository, slots []*fake_slot.Server) {
+		if _, err := rep.SlotAssignmentRepository().Assign("alice", slots[1].Host()); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	// Сессии alice на слоте нет: слот остается свободным
+	if sc := tf.slot(t, 1); sc.Username != "" || sc.Slot.Status != domain.SlotStatusFree {
+		t.Fatalf("alice: want free slot, got %q %s", sc.Username, sc.Slot.Status)
+	}
+
+	if _, err := tf.InstagramAPI("alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Закрепление сохраняется как предпочтение
+	if sc := tf.slot(t, 1); sc.Username != "alice" {
+		t.Fatalf("alice: want assigned slot, got %q", sc.Username)
+	}
+}
+
+func TestFactoryMigrateSlotReleasesSession(t *testing.T) {
+	tf := newTestFactory(t, 2, func(rep domain.Repository, slots []*fake_slot.Server) {
+		slots[0].AddAccount(fake_slot.Account{Username: "alice", Password: "password"})
+	})
+
+	if err := tf.AssignSlot("alice", tf.host(0)); err != nil {
+		t.Fatal(err)
+	}
+
+	api, err := tf.InstagramAPI("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	login(t, api, "alice")
+
+	sc, err := tf.MigrateSlot("alice", tf.host(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sc.Slot.Host != tf.host(1) || sc.Username != "alice" || sc.Slot.Status != domain.SlotStatusBusy {
+		t.Fatalf("migrate: want busy %s, got %s %q %s", tf.host(1), sc.Slot.Host, sc.Username, sc.Slot.Status)
+	}
+
+	if !api.IsClosed() {
+		t.Fatal("migrate: want service of previous slot closed")
+	}
+
+	if host := tf.assignedHost(t, "alice"); host != tf.host(1) {
+		t.Fatalf("migrate: want assignment %s, got %q", tf.host(1), host)
+	}
+
+	// Сессия alice осталась на прежнем слоте: слот не освобождается, пока она не завершится
+	if err := tf.RefreshSlots(); err != nil {
+		t.Fatal(err)
+	}
+
+	if sc := tf.slot(t, 0); sc.Slot.Status != domain.SlotStatusUnavailable {
+		t.Fatalf("previous slot: want unavailable while session is active, got %s", sc.Slot.Status)
+	}
+
+	waitFor(t, func() bool { return tf.slots[0].Calls("auth@logout") > 0 })
+
+	if err := tf.RefreshSlots(); err != nil {
+		t.Fatal(err)
+	}
+
+	if sc := tf.slot(t, 0); sc.Slot.Status != domain.SlotStatusFree || sc.Username != "" {
+		t.Fatalf("previous slot: want free, got %q %s", sc.Username, sc.Slot.Status)
+	}
+}
+
+func TestFactoryMigrateSlotRollback(t *testing.T) {
+	tf := newTestFactory(t, 3, func(rep domain.Repository, slots []*fake_slot.Server) {
+		slots[0].AddAccount(fake_slot.Account{Username: "alice", Password: "password"})
+	})
+
+	if err := tf.AssignSlot("alice", tf.host(0)); err != nil {
+		t.Fatal(err)
+	}
+
+	api, err := tf.InstagramAPI("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	login(t, api, "alice")
+
+	// Порядок migrate_account: logout на прежнем слоте, перенос, неудачный вход, возврат на прежний слот
+	if err := api.Logout(); err != nil {
+		t.Fatal(err)
+	}
+
+	sc, err := tf.MigrateSlot("alice", tf.host(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := sc.Service.Login(instagram.Credentials{Username: "alice", Password: "password"}); err == nil {
+		t.Fatal("login on new slot: want error")
+	}
+
+	if err := tf.ReleaseSlot(tf.host(1)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tf.AssignSlot("alice", tf.host(0)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tf.RefreshSlots(); err != nil {
+		t.Fatal(err)
+	}
+
+	if host := tf.assignedHost(t, "alice"); host != tf.host(0) {
+		t.Fatalf("rollback: want assignment %s, got %q", tf.host(0), host)
+	}
+
+	for i := 1; i < 3; i++ {
+		if sc := tf.slot(t, i); sc.Slot.Status != domain.SlotStatusFree || sc.Username != "" {
+			t.Fatalf("rollback: want slot %s free, got %q %s", tf.host(i), sc.Username, sc.Slot.Status)
+		}
+	}
+
+	// Свободных слотов несколько, но аккаунт возвращается на закрепленный
+	api, err = tf.InstagramAPI("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sc := tf.slot(t, 0); sc.Username != "alice" || sc.Service != api {
+		t.Fatalf("rollback: want alice on %s, got %q", tf.host(0), sc.Username)
+	}
+
+	login(t, api, "alice")
+}
+
+func TestFactoryRollbackKeepsActiveSession(t *testing.T) {
+	tf := newTestFactory(t, 2, func(rep domain.Repository, slots []*fake_slot.Server) {
+		slots[0].AddAccount(fake_slot.Account{Username: "alice", Password: "password"})
+	})
+
+	if err := tf.AssignSlot("alice", tf.host(0)); err != nil {
+		t.Fatal(err)
+	}
+
+	api, err := tf.InstagramAPI("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	login(t, api, "alice")
+
+	if _, err := tf.MigrateSlot("alice", tf.host(1)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Возврат до discovery: сессия прежнего слота не завершается
+	if err := tf.AssignSlot("alice", tf.host(0)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tf.RefreshSlots(); err != nil {
+		t.Fatal(err)
+	}
+
+	if sc := tf.slot(t, 0); sc.Username != "alice" || sc.Slot.Status != domain.SlotStatusBusy {
+		t.Fatalf("rollback: want alice on %s, got %q %s", tf.host(0), sc.Username, sc.Slot.Status)
+	}
+
+	if calls := tf.slots[0].Calls("auth@logout"); calls != 0 {
+		t.Fatalf("rollback: want session kept, got %d logout calls", calls)
+	}
+
+	if sc := tf.slot(t, 1); sc.Slot.Status != domain.SlotStatusFree {
+		t.Fatalf("rollback: want new slot free, got %s", sc.Slot.Status)
+	}
+}
+
+func TestFactoryHealthClosesFreeSlot(t *testing.T) {
+	tf := newTestFactory(t, 2, nil)
+
+	if _, err := tf.InstagramAPI("alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	busy, free := 0, 1
+	if tf.slot(t, 0).Username == "" {
+		busy, free = 1, 0
+	}
+
+	tf.slots[busy].FailNext("system@discovery", "timeout", HealthFailuresMax)
+	tf.slots[free].FailNext("system@discovery", "timeout", HealthFailuresMax)
+
+	for i := 0; i < HealthFailuresMax; i++ {
+		tf.checkHealth()
+	}
+
+	if sc := tf.slot(t, free); sc.Health.Status != domain.SlotHealthUnavailable || sc.Slot.Status != domain.SlotStatusUnavailable || sc.Service != nil {
+		t.Fatalf("free slot: want closed, got %s %s", sc.Health.Status, sc.Slot.Status)
+	}
+
+	// Занятый слот остается за аккаунтом
+	if sc := tf.slot(t, busy); sc.Health.Status != domain.SlotHealthUnavailable || sc.Slot.Status != domain.SlotStatusBusy || sc.Username != "alice" {
+		t.Fatalf("busy slot: want kept, got %s %s %q", sc.Health.Status, sc.Slot.Status, sc.Username)
+	}
+
+	// Восстановившийся слот возвращается в работу после discovery
+	if err := tf.RefreshSlots(); err != nil {
+		t.Fatal(err)
+	}
+
+	if sc := tf.slot(t, free); sc.Slot.Status != domain.SlotStatusFree || sc.Service == nil {
+		t.Fatalf("free slot: want restored, got %s", sc.Slot.Status)
+	}
+}
@@ -0,0 +1,629 @@
+package fake_slot
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/model/instagram"
+	"channels-instagram-dm/service/instagram_api"
+	"channels-instagram-dm/service/instagram_api/types"
+)
+
+const resultOK = "ok"
+
+// call Выполняет метод JSON-RPC и возвращает результат для ответа
+func (st *state) call(method string, params json.RawMessage) (interface{}, error) {
+	st.mux.Lock()
+	defer st.mux.Unlock()
+
+	st.calls[method]++
+
+	if err := st.fail(method); err != nil {
+		return nil, err
+	}
+
+	switch method {
+	case "system@discovery":
+		return st.discovery(), nil
+	case "auth@login":
+		return st.login(params)
+	case "auth@login2f":
+		return st.login2f(params)
+	case "auth@challenge":
+		return st.challenge(params)
+	case "auth@logout":
+		return st.logout()
+	}
+
+	if err := st.authorizedLocked(); err != nil {
+		return nil, err
+	}
+
+	switch method {
+	case "direct@inbox":
+		return st.inbox(params)
+	case "direct@inbox_pending":
+		return st.inboxPending()
+	case "direct@accept_inbox_pending":
+		return st.resolvePending(params, true)
+	case "direct@decline_inbox_pending":
+		return st.resolvePending(params, false)
+	case "direct@thread":
+		return st.thread(params)
+	case "direct@send_text":
+		return st.sendText(params)
+	case "realtime@send_text":
+		return st.realtimeSendText(params)
+	case "direct@send_photo":
+		return st.sendMedia(params, "photo")
+	case "direct@send_video":
+		return st.sendMedia(params, "video")
+	case "direct@send_voice":
+		return st.sendMedia(params, "voice")
+	case "direct@send_like":
+		return st.sendLike(params)
+	case "direct@send_link":
+		return st.sendLink(params)
+	case "direct@send_reaction":
+		return st.sendReaction(params, false)
+	case "direct@delete_reaction":
+		return st.sendReaction(params, true)
+	case "direct@mark_seen":
+		return st.markSeen(params)
+	default:
+		return nil, fmt.Errorf("Method [%s] is not supported", method)
+	}
+}
+
+func (st *state) discovery() domain.DiscoveryRow {
+	users := make([]string, 0, len(st.sessions))
+	for username := range st.sessions {
+		users = append(users, username)
+	}
+
+	return domain.DiscoveryRow{
+		Host:       st.host,
+		Active:     st.authorizedLocked() == nil,
+		Users:      users,
+		ActiveUser: st.active,
+	}
+}
+
+func (st *state) login(params json.RawMessage) (interface{}, error) {
+	p := struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+		Proxy    string `json:"proxy"`
+	}{}
+
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	account, ok := st.accounts[p.Username]
+	if !ok {
+		return nil, errors.New(ErrorTextInvalidUsername)
+	}
+
+	if account.Password != p.Password {
+		return nil, errors.New(ErrorTextBadPassword)
+	}
+
+	// Вход с сохраненной сессией не требует подтверждения
+	if _, ok := st.sessions[p.Username]; ok {
+		st.active = p.Username
+		st.required = ""
+
+		return instagram_api.LoginRequired{}, nil
+	}
+
+	st.active = p.Username
+
+	switch {
+	case account.TwoFactorCode != "":
+		st.required = string(instagram.RequiredStep2F)
+
+		return instagram_api.LoginRequired{
+			Required: st.required,
+			Data: instagram_api.LoginRequiredData{
+				Identifier: "fake_" + p.Username,
+				Method:     "sms",
+			},
+		}, nil
+
+	case account.ChallengeCode != "":
+		st.required = string(instagram.RequiredStepChallenge)
+
+		return instagram_api.LoginRequired{
+			Required: st.required,
+			Data: instagram_api.LoginRequiredData{
+				Step:          "verify_code",
+				CheckpointUrl: "/challenge/fake_" + p.Username,
+			},
+		}, nil
+	}
+
+	st.required = ""
+	st.sessions[p.Username] = struct{}{}
+
+	return instagram_api.LoginRequired{}, nil
+}
+
+func (st *state) login2f(params json.RawMessage) (interface{}, error) {
+	p := instagram_api.Login2FParams{}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	account, ok := st.accounts[p.Username]
+	if !ok || st.active != p.Username || st.required != string(instagram.RequiredStep2F) {
+		return nil, errors.New(ErrorTextNoLoggedIn)
+	}
+
+	if p.Code != account.TwoFactorCode {
+		return nil, errors.New(ErrorTextInvalidCode)
+	}
+
+	st.required = ""
+	st.sessions[p.Username] = struct{}{}
+
+	return resultOK, nil
+}
+
+func (st *state) challenge(params json.RawMessage) (interface{}, error) {
+	p := instagram_api.ChallengeParams{}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	account, ok := st.accounts[st.active]
+	if !ok || st.required != string(instagram.RequiredStepChallenge) {
+		return nil, errors.New(ErrorTextChallengeRequired)
+	}
+
+	if p.Code != account.ChallengeCode {
+		return nil, errors.New(ErrorTextInvalidCode)
+	}
+
+	st.required = ""
+	st.sessions[st.active] = struct{}{}
+
+	return resultOK, nil
+}
+
+func (st *state) logout() (interface{}, error) {
+	delete(st.sessions, st.active)
+
+	st.active = ""
+	st.required = ""
+
+	return resultOK, nil
+}
+
+func (st *state) inbox(params json.RawMessage) (interface{}, error) {
+	p := struct {
+		Cursor string `json:"cursor"`
+		Limit  int    `json:"limit"`
+	}{}
+
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	if p.Limit <= 0 {
+		p.Limit = ThreadPageLimit
+	}
+
+	threads := st.sortedThreads(false)
+
+	// Курсор - идентификатор последнего треда предыдущей страницы
+	start := 0
+	if p.Cursor != "" {
+		for i, t := range threads {
+			if t.id == p.Cursor {
+				start = i + 1
+				break
+			}
+		}
+	}
+
+	end := start + p.Limit
+	if end > len(threads) {
+		end = len(threads)
+	}
+
+	page := threads[start:end]
+	viewer := st.viewer()
+	now := time.Now()
+
+	resp := instagram_api.InboxResponse{
+		Inbox: instagram_api.Inbox{
+			Threads:              make([]instagram_api.Thread, 0, len(page)),
+			HasOlder:             end < len(threads),
+			UnseenCountTs:        strconv.FormatInt(now.UnixNano()/int64(time.Millisecond), 10),
+			PendingRequestsTotal: len(st.sortedThreads(true)),
+		},
+		Viewer:               types.User{ID: viewer.UserID, Username: viewer.Username},
+		SeqID:                strconv.FormatInt(st.seqID, 10),
+		SnapshotAtMs:         strconv.FormatInt(now.UnixNano()/int64(time.Millisecond), 10),
+		PendingRequestsTotal: len(st.sortedThreads(true)),
+		Status:               resultOK,
+	}
+
+	for _, t := range page {
+		resp.Inbox.Threads = append(resp.Inbox.Threads, st.encodeThread(t, "", ThreadPageLimit))
+
+		if last := t.items[len(t.items)-1]; last.UserID != viewer.UserID {
+			resp.Inbox.UnseenCount++
+		}
+	}
+
+	if len(page) > 0 && resp.Inbox.HasOlder {
+		resp.Inbox.OldestCursor = page[len(page)-1].id
+	}
+
+	return resp, nil
+}
+
+func (st *state) inboxPending() (interface{}, error) {
+	threads := st.sortedThreads(true)
+
+	resp := make([]instagram_api.Thread, 0, len(threads))
+	for _, t := range threads {
+		resp = append(resp, st.encodeThread(t, "", ThreadPageLimit))
+	}
+
+	return resp, nil
+}
+
+func (st *state) resolvePending(params json.RawMessage, accept bool) (interface{}, error) {
+	p := struct {
+		Threads []string `json:"threads"`
+	}{}
+
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	for _, id := range p.Threads {
+		t, ok := st.threads[id]
+		if !ok || !t.pending {
+			continue
+		}
+
+		if accept {
+			t.pending = false
+		} else {
+			delete(st.threads, id)
+		}
+
+		st.seqID++
+	}
+
+	return p.Threads, nil
+}
+
+func (st *state) thread(params json.RawMessage) (interface{}, error) {
+	p := struct {
+		ThreadID string `json:"thread_id"`
+		Cursor   string `json:"cursor"`
+	}{}
+
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	t, ok := st.threads[p.ThreadID]
+	if !ok {
+		return nil, errors.New(ErrorTextThreadNotFound)
+	}
+
+	return st.encodeThread(t, p.Cursor, ThreadPageLimit), nil
+}
+
+func (st *state) sendText(params json.RawMessage) (interface{}, error) {
+	p := struct {
+		Text     string `json:"text"`
+		Username string `json:"username"`
+	}{}
+
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	target, err := st.threadByUsername(p.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := st.appendItem(target.id, Item{UserID: st.viewer().UserID, Text: p.Text}); err != nil {
+		return nil, err
+	}
+
+	st.sent = append(st.sent, Sent{
+		Username: p.Username,
+		ThreadID: target.id,
+		Text:     p.Text,
+	})
+
+	return resultOK, nil
+}
+
+func (st *state) realtimeSendText(params json.RawMessage) (interface{}, error) {
+	p := struct {
+		Text     string `json:"text"`
+		ThreadID string `json:"thread_id"`
+	}{}
+
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	t, ok := st.threads[p.ThreadID]
+	if !ok {
+		return nil, errors.New(ErrorTextThreadNotFound)
+	}
+
+	if _, err := st.appendItem(t.id, Item{UserID: st.viewer().UserID, Text: p.Text}); err != nil {
+		return nil, err
+	}
+
+	st.sent = append(st.sent, Sent{
+		Username: t.user.Username,
+		ThreadID: t.id,
+		Text:     p.Text,
+		Realtime: true,
+	})
+
+	return resultOK, nil
+}
+
+// sendMedia Принимает медиа, в тред оно не добавляется - слот не хранит содержимое файлов
+func (st *state) sendMedia(params json.RawMessage, kind string) (interface{}, error) {
+	p := struct {
+		Username string `json:"username"`
+		File     string `json:"file"`
+		MimeType string `json:"mime_type"`
+	}{}
+
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	target, err := st.threadByUsername(p.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := base64.StdEncoding.DecodeString(p.File)
+	if err != nil {
+		return nil, fmt.Errorf("File is not base64. %s", err)
+	}
+
+	st.sent = append(st.sent, Sent{
+		Username:  p.Username,
+		ThreadID:  target.id,
+		Media:     kind,
+		MimeType:  p.MimeType,
+		MediaSize: len(file),
+	})
+
+	return resultOK, nil
+}
+
+func (st *state) sendLike(params json.RawMessage) (interface{}, error) {
+	p := struct {
+		Like     string `json:"like"`
+		Username string `json:"username"`
+	}{}
+
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	target, err := st.threadByUsername(p.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := st.appendItem(target.id, Item{UserID: st.viewer().UserID, Text: p.Like}); err != nil {
+		return nil, err
+	}
+
+	st.sent = append(st.sent, Sent{
+		Username: p.Username,
+		ThreadID: target.id,
+		Text:     p.Like,
+	})
+
+	return resultOK, nil
+}
+
+func (st *state) sendLink(params json.RawMessage) (interface{}, error) {
+	p := struct {
+		Text     string   `json:"text"`
+		Urls     []string `json:"urls"`
+		Username string   `json:"username"`
+	}{}
+
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	if len(p.Urls) == 0 {
+		return nil, errors.New("urls should not be empty")
+	}
+
+	target, err := st.threadByUsername(p.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := st.appendItem(target.id, Item{UserID: st.viewer().UserID, Text: p.Text}); err != nil {
+		return nil, err
+	}
+
+	st.sent = append(st.sent, Sent{
+		Username: p.Username,
+		ThreadID: target.id,
+		Text:     p.Text,
+	})
+
+	return resultOK, nil
+}
+
+func (st *state) sendReaction(params json.RawMessage, remove bool) (interface{}, error) {
+	p := struct {
+		ThreadID string `json:"thread_id"`
+		ItemID   string `json:"item_id"`
+		Emoji    string `json:"emoji"`
+	}{}
+
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	t, ok := st.threads[p.ThreadID]
+	if !ok {
+		return nil, errors.New(ErrorTextThreadNotFound)
+	}
+
+	emoji := p.Emoji
+	if remove {
+		emoji = ""
+	}
+
+	if _, err := st.react(t.id, p.ItemID, st.viewer().UserID, emoji); err != nil {
+		return nil, err
+	}
+
+	st.sent = append(st.sent, Sent{
+		Username: t.user.Username,
+		ThreadID: t.id,
+		ItemID:   p.ItemID,
+		Emoji:    p.Emoji,
+		Remove:   remove,
+	})
+
+	return resultOK, nil
+}
+
+func (st *state) markSeen(params json.RawMessage) (interface{}, error) {
+	p := struct {
+		ThreadID string `json:"thread_id"`
+		ItemID   string `json:"item_id"`
+	}{}
+
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	if err := st.see(p.ThreadID, st.viewer().UserID, p.ItemID); err != nil {
+		return nil, err
+	}
+
+	return resultOK, nil
+}
+
+// threadByUsername Тред с собеседником, вызывается под блокировкой
+func (st *state) threadByUsername(username string) (*thread, error) {
+	for _, t := range st.threads {
+		if t.user.Username == username {
+			return t, nil
+		}
+	}
+
+	return nil, fmt.Errorf("User [%s] was not found", username)
+}
+
+// encodeThread Страница треда от новых сообщений к старым, курсор - идентификатор последнего сообщения предыдущей страницы
+func (st *state) encodeThread(t *thread, cursor string, limit int) instagram_api.Thread {
+	end := len(t.items)
+	if cursor != "" {
+		for i, item := range t.items {
+			if item.ID == cursor {
+				end = i
+				break
+			}
+		}
+	}
+
+	start := end - limit
+	if start < 0 {
+		start = 0
+	}
+
+	viewer := st.viewer()
+
+	resp := instagram_api.Thread{
+		ID:             t.id,
+		V2ID:           t.id,
+		Items:          make([]instagram_api.ThreadItem, 0, end-start),
+		LastActivityAt: strconv.FormatInt(t.lastActivityAt(), 10),
+		Pending:        t.pending,
+		ThreadType:     "private",
+		ViewerID:       viewer.UserID,
+		HasOlder:       start > 0,
+		Inviter:        types.User{ID: t.user.ID, Username: t.user.Username},
+		Users:          []types.User{{ID: t.user.ID, Username: t.user.Username}},
+	}
+
+	for i := end - 1; i >= start; i-- {
+		resp.Items = append(resp.Items, encodeItem(t.items[i]))
+	}
+
+	resp.LastSeenAt = make(map[string]instagram_api.LastSeen, len(t.lastSeen))
+	for userID, seen := range t.lastSeen {
+		resp.LastSeenAt[userID] = instagram_api.LastSeen{
+			ItemID:    seen.ItemID,
+			Timestamp: strconv.FormatInt(seen.Timestamp, 10),
+		}
+	}
+
+	if resp.HasOlder {
+		resp.OldestCursor = t.items[start].ID
+	}
+
+	if len(t.items) > 0 {
+		last := t.items[len(t.items)-1]
+
+		resp.LastPermanentItem.ItemID = last.ID
+		resp.LastPermanentItem.UserID = last.UserID
+		resp.LastPermanentItem.Timestamp = strconv.FormatInt(last.Timestamp, 10)
+		resp.LastPermanentItem.ItemType = "text"
+	}
+
+	return resp
+}
+
+func encodeItem(item Item) instagram_api.ThreadItem {
+	reactions := &types.Reactions{
+		Emojis: make([]types.Reaction, 0, len(item.Reactions)),
+	}
+
+	for _, reaction := range item.Reactions {
+		reactions.Emojis = append(reactions.Emojis, encodeReaction(reaction))
+	}
+
+	return instagram_api.ThreadItem{
+		ID:        item.ID,
+		UserID:    item.UserID,
+		Timestamp: item.Timestamp,
+		Type:      "text",
+		Text:      types.Text(item.Text),
+		Reactions: reactions,
+	}
+}
+
+func encodeReaction(reaction Reaction) types.Reaction {
+	return types.Reaction{
+		SenderID:  reaction.UserID,
+		Timestamp: reaction.Timestamp,
+		Emoji:     reaction.Emoji,
+	}
+}
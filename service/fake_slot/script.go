@@ -0,0 +1,132 @@
+package fake_slot
+
+import (
+	"fmt"
+)
+
+// AddAccount Разрешает вход учетной записи на слот
+func (s *Server) AddAccount(account Account) {
+	s.state.mux.Lock()
+	defer s.state.mux.Unlock()
+
+	if account.UserID == "" {
+		account.UserID = fmt.Sprintf("%d", len(s.state.accounts)+1000)
+	}
+
+	s.state.accounts[account.Username] = account
+}
+
+// AddSession Сохраненная сессия: вход учетной записи не требует 2FA и challenge
+func (s *Server) AddSession(username string) {
+	s.state.mux.Lock()
+	defer s.state.mux.Unlock()
+
+	s.state.sessions[username] = struct{}{}
+}
+
+// ExpireSession Сбрасывает сессию, следующие запросы получат ошибку "User not logged in"
+func (s *Server) ExpireSession(username string) {
+	s.state.mux.Lock()
+	defer s.state.mux.Unlock()
+
+	delete(s.state.sessions, username)
+}
+
+// AddThread Создает тред с собеседником, pending - запрос на переписку
+func (s *Server) AddThread(threadID string, user User, pending bool) {
+	s.state.mux.Lock()
+	defer s.state.mux.Unlock()
+
+	s.state.threads[threadID] = &thread{
+		id:      threadID,
+		user:    user,
+		pending: pending,
+		items:   make([]Item, 0),
+	}
+	s.state.seqID++
+}
+
+// AddItem Добавляет сообщение в тред без уведомления realtime, изменение видно только через inbox
+// Пустой UserID - сообщение от собеседника
+func (s *Server) AddItem(threadID string, item Item) (Item, error) {
+	s.state.mux.Lock()
+	defer s.state.mux.Unlock()
+
+	if item.UserID == "" {
+		if t, ok := s.state.threads[threadID]; ok {
+			item.UserID = t.user.ID
+		}
+	}
+
+	return s.state.appendItem(threadID, item)
+}
+
+// Push Добавляет сообщение в тред и отправляет его подписчикам realtime
+func (s *Server) Push(threadID string, item Item) (Item, error) {
+	item, err := s.AddItem(threadID, item)
+	if err != nil {
+		return item, err
+	}
+
+	s.push(encodeUpdate(threadID, item))
+
+	return item, nil
+}
+
+// React Ставит реакцию пользователя на сообщение, пустой emoji снимает ее, и отправляет обновление подписчикам realtime
+func (s *Server) React(threadID, itemID, userID, emoji string) error {
+	s.state.mux.Lock()
+	reaction, err := s.state.react(threadID, itemID, userID, emoji)
+	s.state.mux.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	s.push(encodeReactionUpdate(threadID, itemID, reaction))
+
+	return nil
+}
+
+// See Отмечает сообщение треда прочитанным участником, прочтение видно в inbox и треде
+func (s *Server) See(threadID, userID, itemID string) error {
+	s.state.mux.Lock()
+	defer s.state.mux.Unlock()
+
+	return s.state.see(threadID, userID, itemID)
+}
+
+// FailNext Следующие times вызовов метода завершатся ошибкой с текстом text
+// Ошибки разных вызовов FailNext одного метода выдаются по очереди
+func (s *Server) FailNext(method, text string, times int) {
+	if times <= 0 {
+		return
+	}
+
+	s.state.mux.Lock()
+	defer s.state.mux.Unlock()
+
+	s.state.failures[method] = append(s.state.failures[method], failure{
+		text:  text,
+		times: times,
+	})
+}
+
+// Sent Сообщения, отправленные через слот
+func (s *Server) Sent() []Sent {
+	s.state.mux.Lock()
+	defer s.state.mux.Unlock()
+
+	sent := make([]Sent, len(s.state.sent))
+	copy(sent, s.state.sent)
+
+	return sent
+}
+
+// Calls Количество вызовов метода, включая завершившиеся ошибкой
+func (s *Server) Calls(method string) int {
+	s.state.mux.Lock()
+	defer s.state.mux.Unlock()
+
+	return s.state.calls[method]
+}
@@ -0,0 +1,261 @@
+package fake_slot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"channels-instagram-dm/service/instagram_api"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	ShutdownTimeout = 5 * time.Second
+)
+
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool {
+		return true
+	},
+}
+
+// request Входящий запрос JSON-RPC, параметры разбираются обработчиком метода
+type request struct {
+	ID      string          `json:"id"`
+	JsonRpc string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+// Server Слот библиотеки instagram-api в памяти процесса
+// Отвечает на методы JSON-RPC по WebSocket, поведение задается сценарием через методы сервера
+type Server struct {
+	mux      sync.Mutex
+	listener net.Listener
+	http     *http.Server
+	conns    map[*conn]struct{}
+	state    *state
+}
+
+// conn Соединение клиента со слотом
+type conn struct {
+	mux      sync.Mutex // Запись в WebSocket не допускает конкурентных вызовов, под ней же меняются подписки realtime
+	ws       *websocket.Conn
+	realtime map[string]struct{} // Идентификаторы запросов realtime@start, в ответ на которые отправляются обновления
+}
+
+func NewServer() *Server {
+	return &Server{
+		conns: make(map[*conn]struct{}),
+		state: newState(),
+	}
+}
+
+// Start Запускает слот, пустой addr - свободный порт на 127.0.0.1
+func (s *Server) Start(addr string) error {
+	if addr == "" {
+		addr = "127.0.0.1:0"
+	}
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("Failed to listen [%s]. %w", addr, err)
+	}
+
+	s.state.mux.Lock()
+	s.state.host = l.Addr().String()
+	s.state.mux.Unlock()
+
+	s.mux.Lock()
+	s.listener = l
+	s.http = &http.Server{
+		Handler: http.HandlerFunc(s.serve),
+	}
+	s.mux.Unlock()
+
+	go func() {
+		_ = s.http.Serve(l)
+	}()
+
+	return nil
+}
+
+// Host Хост слота для источника слотов
+func (s *Server) Host() string {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if s.listener == nil {
+		return ""
+	}
+
+	return s.listener.Addr().String()
+}
+
+func (s *Server) Close() error {
+	s.Disconnect()
+
+	s.mux.Lock()
+	server := s.http
+	s.mux.Unlock()
+
+	if server == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	defer cancel()
+
+	return server.Shutdown(ctx)
+}
+
+// Disconnect Обрывает все соединения без закрывающего фрейма, как при падении слота
+func (s *Server) Disconnect() {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	for c := range s.conns {
+		_ = c.ws.UnderlyingConn().Close()
+		delete(s.conns, c)
+	}
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	ws, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	c := &conn{
+		ws:       ws,
+		realtime: make(map[string]struct{}),
+	}
+
+	s.mux.Lock()
+	s.conns[c] = struct{}{}
+	s.mux.Unlock()
+
+	defer func() {
+		s.mux.Lock()
+		delete(s.conns, c)
+		s.mux.Unlock()
+
+		_ = ws.Close()
+	}()
+
+	for {
+		_, message, err := ws.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		req := request{}
+		if err := json.Unmarshal(message, &req); err != nil {
+			continue
+		}
+
+		// Запросы обрабатываются параллельно, как в библиотеке
+		go s.handle(c, req)
+	}
+}
+
+func (s *Server) handle(c *conn, req request) {
+	if req.Method == "realtime@start" {
+		if err := s.state.authorized(req.Method); err != nil {
+			c.reply(req.ID, nil, err)
+			return
+		}
+
+		c.mux.Lock()
+		c.realtime[req.ID] = struct{}{}
+		c.mux.Unlock()
+
+		return
+	}
+
+	result, err := s.state.call(req.Method, req.Params)
+
+	c.reply(req.ID, result, err)
+}
+
+// encodeUpdate Сообщения realtime приходят без реакций, как и у Instagram
+func encodeUpdate(threadID string, item Item) instagram_api.RealtimeUpdate {
+	update := instagram_api.RealtimeUpdate{
+		ThreadID:     threadID,
+		ThreadItemID: item.ID,
+		ThreadItem:   encodeItem(item),
+	}
+
+	update.ThreadItem.Reactions = nil
+
+	return update
+}
+
+func encodeReactionUpdate(threadID, itemID string, reaction Reaction) instagram_api.RealtimeUpdate {
+	op := instagram_api.RealtimeOpAdd
+	if reaction.Emoji == "" {
+		op = instagram_api.RealtimeOpRemove
+	}
+
+	encoded := encodeReaction(reaction)
+
+	return instagram_api.RealtimeUpdate{
+		ThreadID:     threadID,
+		ThreadItemID: itemID,
+		Op:           op,
+		Reaction:     &encoded,
+	}
+}
+
+// push Рассылает обновление realtime всем подписанным соединениям
+func (s *Server) push(update instagram_api.RealtimeUpdate) {
+	s.mux.Lock()
+	conns := make([]*conn, 0, len(s.conns))
+	for c := range s.conns {
+		conns = append(conns, c)
+	}
+	s.mux.Unlock()
+
+	for _, c := range conns {
+		c.mux.Lock()
+		ids := make([]string, 0, len(c.realtime))
+		for id := range c.realtime {
+			ids = append(ids, id)
+		}
+		c.mux.Unlock()
+
+		for _, id := range ids {
+			c.reply(id, update, nil)
+		}
+	}
+}
+
+func (c *conn) reply(id string, result interface{}, err error) {
+	resp := instagram_api.PayloadResponse{
+		Response: instagram_api.Response{
+			ID:      id,
+			JsonRpc: "2.0",
+		},
+		Result: result,
+	}
+
+	if err != nil {
+		resp.Error = err.Error()
+		resp.Result = nil
+	}
+
+	payload, errMarshal := json.Marshal(resp)
+	if errMarshal != nil {
+		return
+	}
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	_ = c.ws.WriteMessage(websocket.TextMessage, payload)
+}
@@ -0,0 +1,291 @@
+package fake_slot
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Тексты ошибок библиотеки, которые распознает сервис instagram_api
+const (
+	ErrorTextNoLoggedIn        = "User not logged in. Please call login() and then try again."
+	ErrorTextChallengeRequired = "challenge required"
+	ErrorTextBadPassword       = "bad password"
+	ErrorTextInvalidUsername   = "invalid username"
+	ErrorTextInvalidCode       = "invalid code"
+	ErrorTextThreadNotFound    = "thread not found"
+	ErrorTextItemNotFound      = "item not found"
+)
+
+const (
+	ThreadPageLimit = 20 // Количество сообщений на странице треда
+)
+
+// Account Учетная запись, которой разрешено входить на слот
+type Account struct {
+	Username      string
+	Password      string
+	UserID        string
+	TwoFactorCode string // Непустой код - вход требует подтверждения 2FA
+	ChallengeCode string // Непустой код - вход требует прохождения challenge
+}
+
+// User Собеседник в треде
+type User struct {
+	ID       string
+	Username string
+}
+
+// Item Сообщение треда, Timestamp в микросекундах
+type Item struct {
+	ID        string
+	UserID    string
+	Text      string
+	Timestamp int64
+	Reactions []Reaction
+}
+
+// Reaction Реакция пользователя на сообщение, Timestamp в микросекундах
+type Reaction struct {
+	UserID    string
+	Emoji     string
+	Timestamp int64
+}
+
+// Sent Сообщение, отправленное через слот
+type Sent struct {
+	Username  string
+	ThreadID  string
+	Text      string
+	Realtime  bool
+	Media     string // photo, video или voice для отправленного медиа
+	MimeType  string
+	MediaSize int
+	ItemID    string // Сообщение, на которое поставлена или с которого снята реакция
+	Emoji     string
+	Remove    bool
+}
+
+type thread struct {
+	id       string
+	user     User
+	pending  bool
+	items    []Item              // От старых сообщений к новым
+	lastSeen map[string]LastSeen // По идентификатору участника
+}
+
+// LastSeen Последнее прочитанное участником сообщение, Timestamp в микросекундах
+type LastSeen struct {
+	ItemID    string
+	Timestamp int64
+}
+
+func (t *thread) lastActivityAt() int64 {
+	if len(t.items) == 0 {
+		return 0
+	}
+
+	return t.items[len(t.items)-1].Timestamp
+}
+
+// failure Ошибка, которой слот ответит на ближайшие вызовы метода
+type failure struct {
+	text  string
+	times int
+}
+
+type state struct {
+	mux      sync.Mutex
+	host     string
+	accounts map[string]Account
+	sessions map[string]struct{}
+	active   string
+	required string // Незавершенный шаг входа активного аккаунта
+	threads  map[string]*thread
+	seqID    int64
+	itemSeq  int64
+	failures map[string][]failure
+	sent     []Sent
+	calls    map[string]int
+}
+
+func newState() *state {
+	return &state{
+		accounts: make(map[string]Account),
+		sessions: make(map[string]struct{}),
+		threads:  make(map[string]*thread),
+		seqID:    1,
+		failures: make(map[string][]failure),
+		sent:     make([]Sent, 0),
+		calls:    make(map[string]int),
+	}
+}
+
+// fail Возвращает запланированную ошибку метода, вызывается под блокировкой
+func (st *state) fail(method string) error {
+	queue := st.failures[method]
+	if len(queue) == 0 {
+		return nil
+	}
+
+	f := queue[0]
+	f.times--
+
+	if f.times == 0 {
+		st.failures[method] = queue[1:]
+	} else {
+		queue[0] = f
+	}
+
+	return errors.New(f.text)
+}
+
+// authorized Методы direct@ и realtime@ доступны только после входа
+func (st *state) authorized(method string) error {
+	st.mux.Lock()
+	defer st.mux.Unlock()
+
+	st.calls[method]++
+
+	if err := st.fail(method); err != nil {
+		return err
+	}
+
+	return st.authorizedLocked()
+}
+
+func (st *state) authorizedLocked() error {
+	if st.active == "" || st.required != "" {
+		return errors.New(ErrorTextNoLoggedIn)
+	}
+
+	if _, ok := st.sessions[st.active]; !ok {
+		return errors.New(ErrorTextNoLoggedIn)
+	}
+
+	return nil
+}
+
+func (st *state) viewer() Account {
+	account, ok := st.accounts[st.active]
+	if !ok {
+		return Account{Username: st.active}
+	}
+
+	return account
+}
+
+// sortedThreads Треды по убыванию последней активности
+func (st *state) sortedThreads(pending bool) []*thread {
+	threads := make([]*thread, 0, len(st.threads))
+	for _, t := range st.threads {
+		if t.pending == pending && len(t.items) > 0 {
+			threads = append(threads, t)
+		}
+	}
+
+	sort.SliceStable(threads, func(i, j int) bool {
+		if threads[i].lastActivityAt() != threads[j].lastActivityAt() {
+			return threads[i].lastActivityAt() > threads[j].lastActivityAt()
+		}
+
+		return threads[i].id < threads[j].id
+	})
+
+	return threads
+}
+
+// react Ставит реакцию пользователя на сообщение или снимает ее при пустом emoji, вызывается под блокировкой
+func (st *state) react(threadID, itemID, userID, emoji string) (Reaction, error) {
+	t, ok := st.threads[threadID]
+	if !ok {
+		return Reaction{}, errors.New(ErrorTextThreadNotFound)
+	}
+
+	for i := range t.items {
+		if t.items[i].ID != itemID {
+			continue
+		}
+
+		reaction := Reaction{
+			UserID:    userID,
+			Emoji:     emoji,
+			Timestamp: time.Now().UnixNano() / int64(time.Microsecond),
+		}
+
+		reactions := make([]Reaction, 0, len(t.items[i].Reactions)+1)
+		for _, item := range t.items[i].Reactions {
+			if item.UserID != userID {
+				reactions = append(reactions, item)
+			}
+		}
+
+		if emoji != "" {
+			reactions = append(reactions, reaction)
+		}
+
+		t.items[i].Reactions = reactions
+
+		return reaction, nil
+	}
+
+	return Reaction{}, errors.New(ErrorTextItemNotFound)
+}
+
+// see Отмечает сообщение треда прочитанным участником, вызывается под блокировкой
+func (st *state) see(threadID, userID, itemID string) error {
+	t, ok := st.threads[threadID]
+	if !ok {
+		return errors.New(ErrorTextThreadNotFound)
+	}
+
+	for _, item := range t.items {
+		if item.ID != itemID {
+			continue
+		}
+
+		if t.lastSeen == nil {
+			t.lastSeen = make(map[string]LastSeen)
+		}
+
+		t.lastSeen[userID] = LastSeen{
+			ItemID:    itemID,
+			Timestamp: time.Now().UnixNano() / int64(time.Microsecond),
+		}
+
+		return nil
+	}
+
+	return errors.New(ErrorTextItemNotFound)
+}
+
+// appendItem Добавляет сообщение в тред, вызывается под блокировкой
+func (st *state) appendItem(threadID string, item Item) (Item, error) {
+	t, ok := st.threads[threadID]
+	if !ok {
+		return Item{}, fmt.Errorf("Thread [%s] was not found", threadID)
+	}
+
+	st.itemSeq++
+
+	if item.ID == "" {
+		item.ID = strconv.FormatInt(st.itemSeq, 10)
+	}
+
+	if item.Timestamp == 0 {
+		item.Timestamp = time.Now().UnixNano() / int64(time.Microsecond)
+	}
+
+	// Сообщения треда упорядочены по времени, новое сообщение не может оказаться раньше последнего
+	if last := t.lastActivityAt(); item.Timestamp <= last {
+		item.Timestamp = last + 1
+	}
+
+	t.items = append(t.items, item)
+	st.seqID++
+
+	return item, nil
+}
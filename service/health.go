@@ -0,0 +1,176 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"channels-instagram-dm/domain"
+)
+
+const (
+	HealthCheckTimer        = 30 * time.Second // Интервал проверки слотов
+	HealthWindowSize        = 10               // Количество последних проверок, по которым считаются метрики
+	HealthDegradedLatency   = 2 * time.Second  // Среднее время ответа, после которого слот считается медленным
+	HealthDegradedErrorRate = 0.2              // Доля ошибок, после которой слот считается деградировавшим
+	HealthFailuresMax       = 3                // Количество ошибок подряд, после которого слот считается недоступным
+)
+
+type healthResult struct {
+	latency time.Duration
+	err     error
+}
+
+// healthWindow Скользящее окно проверок слота
+type healthWindow struct {
+	results       []healthResult
+	failures      int // Ошибки подряд
+	lastCheckAt   time.Time
+	lastSuccessAt time.Time
+	lastError     string
+}
+
+func (w *healthWindow) append(result healthResult) {
+	w.results = append(w.results, result)
+	if len(w.results) > HealthWindowSize {
+		w.results = w.results[len(w.results)-HealthWindowSize:]
+	}
+
+	w.lastCheckAt = time.Now()
+
+	if result.err != nil {
+		w.failures++
+		w.lastError = result.err.Error()
+		return
+	}
+
+	w.failures = 0
+	w.lastSuccessAt = w.lastCheckAt
+}
+
+func (w *healthWindow) health() domain.SlotHealth {
+	health := domain.SlotHealth{
+		Status:        domain.SlotHealthUnknown,
+		LastCheckAt:   w.lastCheckAt,
+		LastSuccessAt: w.lastSuccessAt,
+		LastError:     w.lastError,
+	}
+
+	if len(w.results) == 0 {
+		return health
+	}
+
+	var failed int
+	var latency time.Duration
+
+	for _, result := range w.results {
+		if result.err != nil {
+			failed++
+			continue
+		}
+
+		latency += result.latency
+	}
+
+	if succeeded := len(w.results) - failed; succeeded > 0 {
+		health.Latency = latency / time.Duration(succeeded)
+	}
+
+	health.ErrorRate = float64(failed) / float64(len(w.results))
+
+	switch {
+	case w.failures >= HealthFailuresMax:
+		health.Status = domain.SlotHealthUnavailable
+	case health.ErrorRate >= HealthDegradedErrorRate || health.Latency >= HealthDegradedLatency:
+		health.Status = domain.SlotHealthDegraded
+	default:
+		health.Status = domain.SlotHealthHealthy
+	}
+
+	return health
+}
+
+// monitorHealth Периодически проверяет слоты через system@discovery, не дожидаясь RefreshSlots
+func (f *factory) monitorHealth() {
+	ticker := time.NewTicker(HealthCheckTimer)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-f.ctx.Done():
+			return
+		case <-ticker.C:
+			f.checkHealth()
+		}
+	}
+}
+
+func (f *factory) checkHealth() {
+	f.mx.Lock()
+	slots := make([]domain.SlotContainer, len(f.slots))
+	copy(slots, f.slots)
+	f.mx.Unlock()
+
+	wg := &sync.WaitGroup{}
+
+	// Зависший слот не должен задерживать проверку остальных
+	for _, sc := range slots {
+		if sc.Service == nil {
+			continue
+		}
+
+		wg.Add(1)
+
+		go func(sc domain.SlotContainer) {
+			defer wg.Done()
+
+			// Discovery проходит вне очереди слота, поэтому задержка не включает ожидание других запросов
+			startedAt := time.Now()
+			_, err := sc.Service.Discovery()
+
+			f.storeHealth(sc.Slot.Host, sc.Service, healthResult{
+				latency: time.Since(startedAt),
+				err:     err,
+			})
+		}(sc)
+	}
+
+	wg.Wait()
+}
+
+func (f *factory) storeHealth(host string, service domain.InstagramAPI, result healthResult) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	window, ok := f.health[host]
+	if !ok {
+		window = &healthWindow{}
+		f.health[host] = window
+	}
+
+	window.append(result)
+	health := window.health()
+
+	for i, sc := range f.slots {
+		if sc.Slot.Host != host {
+			continue
+		}
+
+		if sc.Health.Status != health.Status {
+			f.logger.Info(fmt.Sprintf("CheckHealth: Slot %s is %s", host, health.Status), nil)
+		}
+
+		f.slots[i].Health = health
+
+		// Свободный недоступный слот исключается до discovery, занятый остается за аккаунтом:
+		// потерю соединения обрабатывает переподключение сервиса
+		if health.Status == domain.SlotHealthUnavailable && sc.Slot.Status == domain.SlotStatusFree && sc.Service == service {
+			service.Close()
+
+			f.slots[i].Slot.Status = domain.SlotStatusUnavailable
+			f.slots[i].Service = nil
+		}
+
+		return
+	}
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"channels-instagram-dm/domain"
+)
+
+func TestHealthWindow(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	tests := []struct {
+		name    string
+		results []healthResult
+		status  domain.SlotHealthStatus
+	}{
+		{"empty", nil, domain.SlotHealthUnknown},
+		{"healthy", repeatResult(healthResult{latency: 100 * time.Millisecond}, 5), domain.SlotHealthHealthy},
+		{"slow", repeatResult(healthResult{latency: HealthDegradedLatency}, 3), domain.SlotHealthDegraded},
+		{"error rate", append(repeatResult(healthResult{latency: time.Millisecond}, 8), healthResult{err: errFailed}, healthResult{err: errFailed}, healthResult{latency: time.Millisecond}), domain.SlotHealthDegraded},
+		{"failures in a row", append(repeatResult(healthResult{latency: time.Millisecond}, 5), repeatResult(healthResult{err: errFailed}, HealthFailuresMax)...), domain.SlotHealthUnavailable},
+		{"recovered", append(repeatResult(healthResult{err: errFailed}, HealthFailuresMax), healthResult{latency: time.Millisecond}), domain.SlotHealthDegraded},
+		// Старые ошибки уходят из окна
+		{"window", append(repeatResult(healthResult{err: errFailed}, HealthFailuresMax), repeatResult(healthResult{latency: time.Millisecond}, HealthWindowSize)...), domain.SlotHealthHealthy},
+	}
+
+	for _, test := range tests {
+		w := &healthWindow{}
+		for _, result := range test.results {
+			w.append(result)
+		}
+
+		if health := w.health(); health.Status != test.status {
+			t.Errorf("%s: want %s, got %s", test.name, test.status, health.Status)
+		}
+
+		if len(w.results) > HealthWindowSize {
+			t.Errorf("%s: window size %d exceeds %d", test.name, len(w.results), HealthWindowSize)
+		}
+	}
+}
+
+func TestHealthWindowMetrics(t *testing.T) {
+	w := &healthWindow{}
+	w.append(healthResult{latency: 100 * time.Millisecond})
+	w.append(healthResult{latency: 300 * time.Millisecond})
+	w.append(healthResult{err: errors.New("timeout")})
+	w.append(healthResult{err: errors.New("connection refused")})
+
+	health := w.health()
+
+	// Задержка считается только по успешным проверкам
+	if health.Latency != 200*time.Millisecond {
+		t.Errorf("latency: want 200ms, got %s", health.Latency)
+	}
+
+	if health.ErrorRate != 0.5 {
+		t.Errorf("error rate: want 0.5, got %f", health.ErrorRate)
+	}
+
+	if health.LastError != "connection refused" {
+		t.Errorf("last error: want last failure, got %s", health.LastError)
+	}
+
+	if w.failures != 2 {
+		t.Errorf("failures: want 2, got %d", w.failures)
+	}
+
+	if health.LastSuccessAt.IsZero() || !health.LastSuccessAt.Before(health.LastCheckAt) && !health.LastSuccessAt.Equal(health.LastCheckAt) {
+		t.Errorf("last success: want before last check, got %s and %s", health.LastSuccessAt, health.LastCheckAt)
+	}
+}
+
+func repeatResult(result healthResult, n int) []healthResult {
+	results := make([]healthResult, 0, n)
+	for i := 0; i < n; i++ {
+		results = append(results, result)
+	}
+
+	return results
+}
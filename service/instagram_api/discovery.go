@@ -32,6 +32,10 @@ func (s *service) Discovery() (d domain.DiscoveryRow, err error) {
 		}
 
 		if val, ok := response.Result.(*domain.DiscoveryRow); ok {
+			if val.ActiveUser != "" {
+				s.setAccount(val.ActiveUser)
+			}
+
 			return *val, nil
 		}
 
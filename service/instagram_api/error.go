@@ -3,6 +3,8 @@ package instagram_api
 import (
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"channels-instagram-dm/domain"
 )
@@ -14,6 +16,22 @@ var (
 	ErrorChallengeRequired    = errors.New("Challenge required")
 	ErrorLoginInvalidUsername = errors.New("Invalid username")
 	ErrorLoginBadPassword     = errors.New("Bad password")
+	ErrorConnectionLost       = errors.New("Connection lost")
+	ErrorRateLimited          = errors.New("Rate limited")
+	ErrorSpamBlocked          = errors.New("Spam blocked")
+	ErrorCheckpoint           = errors.New("Checkpoint required")
+	ErrorUserNotFound         = errors.New("User not found")
+	ErrorThreadNotFound       = errors.New("Thread not found")
+	ErrorProxyFailed          = errors.New("Proxy failed")
+	ErrorTimeout              = errors.New("Timeout")
+)
+
+// Подсказки повтора для ошибок, которые устраняются со временем
+const (
+	RetryAfterRateLimited = 30 * time.Minute
+	RetryAfterSpamBlocked = 24 * time.Hour
+	RetryAfterProxyFailed = 5 * time.Minute
+	RetryAfterTimeout     = 1 * time.Minute
 )
 
 var (
@@ -21,8 +39,25 @@ var (
 	errChallengeRequired = "challenge required"
 	errBadPassword       = "bad password"
 	errInvalidUser       = "invalid username"
+	errConnectionLost    = "connection lost" // Внутренняя ошибка сервиса, которой завершаются ожидающие запросы при разрыве
 )
 
+// errorPatterns Фрагменты текстов ошибок библиотеки и Instagram, порядок важен: первое совпадение определяет ошибку
+// Фрагменты сравниваются без учета регистра
+var errorPatterns = []struct {
+	fragments []string
+	newError  func(text string) error
+}{
+	{[]string{"login_required", "not logged in"}, func(text string) error { return newErrorNoLoggedIn() }},
+	{[]string{"checkpoint_required", "challenge_required", "checkpoint"}, newErrorCheckpoint},
+	{[]string{"user not found", "user_not_found"}, newErrorUserNotFound},
+	{[]string{"thread not found", "thread_not_found"}, newErrorThreadNotFound},
+	{[]string{"spam", "action_blocked", "action blocked", "sentry_block"}, newErrorSpamBlocked},
+	{[]string{"feedback_required", "please wait a few minutes", "rate limit", "too many requests"}, newErrorRateLimited},
+	{[]string{"proxy", "tunneling socket", "econnrefused", "ehostunreach"}, newErrorProxyFailed},
+	{[]string{"timeout", "timed out", "etimedout", "esockettimedout"}, newErrorTimeout},
+}
+
 func newError(text string) error {
 	switch text {
 	case errNoLoggedIn:
@@ -33,9 +68,33 @@ func newError(text string) error {
 		return newErrorLoginInvalidUsername()
 	case errBadPassword:
 		return newErrorLoginBadPassword()
-	default:
-		return fmt.Errorf("%v", text)
+	case errConnectionLost:
+		return newErrorConnectionLost()
 	}
+
+	lower := strings.ToLower(text)
+
+	for _, pattern := range errorPatterns {
+		for _, fragment := range pattern.fragments {
+			if strings.Contains(lower, fragment) {
+				return pattern.newError(text)
+			}
+		}
+	}
+
+	return fmt.Errorf("%v", text)
+}
+
+// newErrorLogin Распознанные ошибки возвращаются как есть, остальные считаются неудачным входом
+func newErrorLogin(text string) error {
+	err := newError(text)
+
+	var e *domain.Error
+	if errors.As(err, &e) {
+		return err
+	}
+
+	return newErrorLoginFailed(text)
 }
 
 func newErrorNoLoggedIn() error {
@@ -61,3 +120,35 @@ func newErrorLoginInvalidUsername() error {
 func newErrorLoginBadPassword() error {
 	return domain.NewError(ErrorLoginBadPassword.Error(), fmt.Errorf("%w", domain.ErrorInvalidCredentials))
 }
+
+func newErrorConnectionLost() error {
+	return domain.NewError(ErrorConnectionLost.Error(), fmt.Errorf("%w", domain.ErrorConnectionLost))
+}
+
+func newErrorRateLimited(text string) error {
+	return domain.NewErrorRetry(ErrorRateLimited.Error(), fmt.Errorf("%w. %s", domain.ErrorRateLimited, text), RetryAfterRateLimited)
+}
+
+func newErrorSpamBlocked(text string) error {
+	return domain.NewErrorRetry(ErrorSpamBlocked.Error(), fmt.Errorf("%w. %s", domain.ErrorSpamBlocked, text), RetryAfterSpamBlocked)
+}
+
+func newErrorCheckpoint(text string) error {
+	return domain.NewError(ErrorCheckpoint.Error(), fmt.Errorf("%w. %s", domain.ErrorCheckpoint, text))
+}
+
+func newErrorUserNotFound(text string) error {
+	return domain.NewError(ErrorUserNotFound.Error(), fmt.Errorf("%w. %s", domain.ErrorTargetNotFound, text))
+}
+
+func newErrorThreadNotFound(text string) error {
+	return domain.NewError(ErrorThreadNotFound.Error(), fmt.Errorf("%w. %s", domain.ErrorTargetNotFound, text))
+}
+
+func newErrorProxyFailed(text string) error {
+	return domain.NewErrorRetry(ErrorProxyFailed.Error(), fmt.Errorf("%w. %s", domain.ErrorProxyFailed, text), RetryAfterProxyFailed)
+}
+
+func newErrorTimeout(text string) error {
+	return domain.NewErrorRetry(ErrorTimeout.Error(), fmt.Errorf("%w. %s", domain.ErrorTimeout, text), RetryAfterTimeout)
+}
@@ -0,0 +1,67 @@
+package instagram_api
+
+import (
+	"errors"
+	"testing"
+
+	"channels-instagram-dm/domain"
+)
+
+func TestNewError(t *testing.T) {
+	tests := []struct {
+		text       string
+		want       error
+		retryAfter bool
+	}{
+		{errNoLoggedIn, domain.ErrorNoLoggedIn, false},
+		{"login_required", domain.ErrorNoLoggedIn, false},
+		{errChallengeRequired, domain.ErrorInvalidCredentials, false},
+		{errBadPassword, domain.ErrorInvalidCredentials, false},
+		{errInvalidUser, domain.ErrorInvalidCredentials, false},
+		{errConnectionLost, domain.ErrorConnectionLost, false},
+		{"IgCheckpointError: checkpoint_required", domain.ErrorCheckpoint, false},
+		{"User not found", domain.ErrorTargetNotFound, false},
+		{"thread_not_found", domain.ErrorTargetNotFound, false},
+		{"IgActionSpamError: feedback_required spam", domain.ErrorSpamBlocked, true},
+		{"feedback_required", domain.ErrorRateLimited, true},
+		{"Too Many Requests", domain.ErrorRateLimited, true},
+		{"tunneling socket could not be established", domain.ErrorProxyFailed, true},
+		{"ESOCKETTIMEDOUT", domain.ErrorTimeout, true},
+	}
+
+	for _, test := range tests {
+		err := newError(test.text)
+
+		if !errors.Is(err, test.want) {
+			t.Errorf("newError(%q): want %v, got %v", test.text, test.want, err)
+			continue
+		}
+
+		if retryAfter := domain.RetryAfter(err); (retryAfter > 0) != test.retryAfter {
+			t.Errorf("newError(%q): unexpected retry after %s", test.text, retryAfter)
+		}
+	}
+}
+
+func TestNewErrorUnknown(t *testing.T) {
+	err := newError("something went wrong")
+
+	var e *domain.Error
+	if errors.As(err, &e) {
+		t.Fatalf("newError: want plain error, got %s", e.Code())
+	}
+
+	if err.Error() != "something went wrong" {
+		t.Fatalf("newError: want original text, got %s", err)
+	}
+}
+
+func TestNewErrorLogin(t *testing.T) {
+	if err := newErrorLogin("unexpected response"); !errors.Is(err, domain.ErrorLoginFailed) {
+		t.Fatalf("newErrorLogin: want login failed, got %v", err)
+	}
+
+	if err := newErrorLogin(errBadPassword); !errors.Is(err, domain.ErrorInvalidCredentials) {
+		t.Fatalf("newErrorLogin: want invalid credentials, got %v", err)
+	}
+}
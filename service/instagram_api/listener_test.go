@@ -0,0 +1,96 @@
+package instagram_api
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"channels-instagram-dm/domain"
+)
+
+type discardLogger struct{}
+
+func (l discardLogger) Copy(string) domain.Logger    { return l }
+func (l discardLogger) Writer() io.Writer            { return ioutil.Discard }
+func (l discardLogger) Critical(string, interface{}) {}
+func (l discardLogger) Debug(string, interface{})    {}
+func (l discardLogger) Info(string, interface{})     {}
+func (l discardLogger) Error(string, interface{})    {}
+
+func newTestListener(ctx context.Context) *listener {
+	return &listener{
+		ctx:     ctx,
+		ch:      make(chan PayloadResponse),
+		release: func() {},
+	}
+}
+
+// Получатель, который не читает ответ, не задерживает ответы другим слушателям и завершение слушателей
+func TestDispatchSlowListener(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stalled := newTestListener(ctx)
+	waiting := newTestListener(ctx)
+
+	s := &service{
+		ctx:    ctx,
+		logger: discardLogger{},
+		listenersMap: map[string]*listener{
+			"stalled": stalled,
+			"waiting": waiting,
+		},
+	}
+
+	go s.dispatch(Response{ID: "stalled"}, []byte(`{"id":"stalled"}`))
+
+	// Ответ застрявшему слушателю уже передается
+	time.Sleep(50 * time.Millisecond)
+
+	go s.dispatch(Response{ID: "waiting"}, []byte(`{"id":"waiting"}`))
+
+	select {
+	case response := <-waiting.ch:
+		if response.ID != "waiting" {
+			t.Fatalf("dispatch: unexpected response %+v", response)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("dispatch: response was blocked by stalled listener")
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		s.failListeners()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("failListeners: blocked by stalled listener")
+	}
+
+	s.mux.Lock()
+	left := len(s.listenersMap)
+	s.mux.Unlock()
+
+	if left != 0 {
+		t.Fatalf("failListeners: want no listeners, got %d", left)
+	}
+
+	// Застрявший слушатель получает первый ответ, а затем ошибку соединения
+	if response := <-stalled.ch; response.ID != "stalled" {
+		t.Fatalf("stalled: unexpected response %+v", response)
+	}
+
+	if response := <-stalled.ch; response.Error != errConnectionLost {
+		t.Fatalf("stalled: want connection lost, got %+v", response)
+	}
+
+	if _, ok := <-stalled.ch; ok {
+		t.Fatal("stalled: want closed channel")
+	}
+}
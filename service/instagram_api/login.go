@@ -2,15 +2,15 @@ package instagram_api
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"time"
 
-	"channels-instagram-dm/domain"
 	"channels-instagram-dm/domain/model/instagram"
 )
 
 func (s *service) Login(credentials instagram.Credentials) (instagram.Required, error) {
+	s.setAccount(credentials.Username)
+
 	request := NewRequest("auth@login")
 	request.Params = struct {
 		Username string `json:"username"`
@@ -41,11 +41,7 @@ func (s *service) Login(credentials instagram.Credentials) (instagram.Required,
 		}
 
 		if response.Error != "" {
-			if errors.Is(newError(response.Error), domain.ErrorInvalidCredentials) {
-				return required, newError(response.Error)
-			}
-
-			return required, newErrorLoginFailed(response.Error)
+			return required, newErrorLogin(response.Error)
 		}
 
 		if val, ok := response.Result.(*LoginRequired); ok {
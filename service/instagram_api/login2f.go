@@ -47,7 +47,7 @@ func (s *service) Login2F(credentials instagram.Credentials, required instagram.
 		}
 
 		if response.Error != "" {
-			return newErrorLoginFailed(response.Error)
+			return newErrorLogin(response.Error)
 		}
 
 		return nil
@@ -0,0 +1,43 @@
+package instagram_api
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DirectMarkSeen Отмечает сообщение треда и все предыдущие прочитанными от имени аккаунта
+func (s *service) DirectMarkSeen(threadID, itemID string) error {
+	request := NewRequest("direct@mark_seen")
+
+	request.Params = struct {
+		ThreadID string `json:"thread_id"`
+		ItemID   string `json:"item_id"`
+	}{
+		ThreadID: threadID,
+		ItemID:   itemID,
+	}
+
+	ctx, cancel := context.WithTimeout(s.ctx, time.Minute)
+	defer cancel()
+
+	ch, err := s.send(ctx, request.ID, request, nil)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("Stopped by timeout %w", ctx.Err())
+	case response, ok := <-ch:
+		if !ok {
+			return fmt.Errorf("Channel was closed")
+		}
+
+		if response.Error != "" {
+			return newError(response.Error)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,280 @@
+package instagram_api
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"os"
+	"time"
+
+	"channels-instagram-dm/domain"
+)
+
+const (
+	MediaDownloadTimeout = 2 * time.Minute
+	MediaSniffSize       = 512      // Столько байт нужно http.DetectContentType
+	MediaFrameOverhead   = 64 << 10 // Запас кадра на остальные поля запроса
+	// MediaSizeMax Файл передается в base64 (4/3 размера) в одном кадре JSON-RPC, поэтому ограничен размером кадра
+	MediaSizeMax = (FrameSizeMax - MediaFrameOverhead) / 4 * 3
+)
+
+// mediaLimits Ограничения Instagram для отправляемого медиа
+type mediaLimits struct {
+	kind      string
+	sizeMax   int64
+	mimeTypes []string
+	sideMin   int // 0 - размеры не проверяются
+	sideMax   int
+}
+
+var (
+	photoLimits = mediaLimits{
+		kind:      "photo",
+		sizeMax:   mediaSizeMax(8 << 20),
+		mimeTypes: []string{"image/jpeg", "image/png"},
+		sideMin:   150,
+		sideMax:   4096,
+	}
+	videoLimits = mediaLimits{
+		kind:      "video",
+		sizeMax:   mediaSizeMax(25 << 20),
+		mimeTypes: []string{"video/mp4", "video/quicktime"},
+		sideMin:   120,
+		sideMax:   1920,
+	}
+	// Голосовые сообщения в контейнере m4a определяются как video/mp4
+	voiceLimits = mediaLimits{
+		kind:      "voice",
+		sizeMax:   mediaSizeMax(5 << 20),
+		mimeTypes: []string{"audio/mpeg", "audio/mp4", "audio/aac", "audio/ogg", "application/ogg", "audio/wave", "video/mp4"},
+	}
+)
+
+// mediaSizeMax Ограничение Instagram, если оно помещается в кадр, иначе ограничение кадра
+func mediaSizeMax(limit int64) int64 {
+	if limit > MediaSizeMax {
+		return MediaSizeMax
+	}
+
+	return limit
+}
+
+// mediaFile Медиа, загруженное во временный файл
+type mediaFile struct {
+	path     string
+	mimeType string
+	size     int64
+	width    int
+	height   int
+}
+
+func (f mediaFile) remove() {
+	_ = os.Remove(f.path)
+}
+
+func (f mediaFile) base64() (string, error) {
+	bs, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(bs), nil
+}
+
+// downloadMedia Загружает медиа по URL Channels во временный файл и проверяет его по ограничениям
+// Временный файл удаляется вызывающим через remove
+func downloadMedia(ctx context.Context, url string, limits mediaLimits) (mediaFile, error) {
+	ctx, cancel := context.WithTimeout(ctx, MediaDownloadTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return mediaFile{}, domain.NewErrorInvalidArgument(fmt.Sprintf("Media url [%s] is invalid. %s", url, err))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return mediaFile{}, fmt.Errorf("Failed to download media [%s]. %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return mediaFile{}, fmt.Errorf("Failed to download media [%s]. Status [%d]", url, resp.StatusCode)
+	}
+
+	if resp.ContentLength > limits.sizeMax {
+		return mediaFile{}, domain.NewErrorInvalidArgument(fmt.Sprintf("Media size [%d] exceeds [%d] bytes for %s", resp.ContentLength, limits.sizeMax, limits.kind))
+	}
+
+	file, err := ioutil.TempFile("", "instagram-media-*")
+	if err != nil {
+		return mediaFile{}, err
+	}
+
+	media := mediaFile{
+		path: file.Name(),
+	}
+
+	// Лимит на байт больше допустимого, чтобы отличить файл ровно допустимого размера от превышения
+	media.size, err = io.Copy(file, io.LimitReader(resp.Body, limits.sizeMax+1))
+	if errClose := file.Close(); err == nil {
+		err = errClose
+	}
+
+	if err != nil {
+		media.remove()
+		return mediaFile{}, fmt.Errorf("Failed to download media [%s]. %w", url, err)
+	}
+
+	if media.size > limits.sizeMax {
+		media.remove()
+		return mediaFile{}, domain.NewErrorInvalidArgument(fmt.Sprintf("Media size exceeds [%d] bytes for %s", limits.sizeMax, limits.kind))
+	}
+
+	if err := media.validate(limits, resp.Header.Get("Content-Type")); err != nil {
+		media.remove()
+		return mediaFile{}, err
+	}
+
+	return media, nil
+}
+
+// validate Определяет MIME по содержимому файла, а если содержимое не распознано - по заголовку ответа
+func (f *mediaFile) validate(limits mediaLimits, contentType string) error {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	head := make([]byte, MediaSniffSize)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return err
+	}
+
+	f.mimeType = http.DetectContentType(head[:n])
+
+	if f.mimeType == "application/octet-stream" {
+		if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+			f.mimeType = mediaType
+		}
+	}
+
+	if !containsMimeType(limits.mimeTypes, f.mimeType) {
+		return domain.NewErrorInvalidArgument(fmt.Sprintf("Media type [%s] is not allowed for %s", f.mimeType, limits.kind))
+	}
+
+	if limits.sideMin == 0 {
+		return nil
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	switch limits.kind {
+	case photoLimits.kind:
+		config, _, err := image.DecodeConfig(file)
+		if err != nil {
+			return domain.NewErrorInvalidArgument(fmt.Sprintf("Failed to read photo dimensions. %s", err))
+		}
+
+		f.width, f.height = config.Width, config.Height
+
+	case videoLimits.kind:
+		f.width, f.height, err = mp4Dimensions(file, f.size)
+		if err != nil {
+			return domain.NewErrorInvalidArgument(fmt.Sprintf("Failed to read video dimensions. %s", err))
+		}
+	}
+
+	if f.width < limits.sideMin || f.height < limits.sideMin || f.width > limits.sideMax || f.height > limits.sideMax {
+		return domain.NewErrorInvalidArgument(fmt.Sprintf("Media dimensions [%dx%d] are out of [%d..%d] for %s",
+			f.width, f.height, limits.sideMin, limits.sideMax, limits.kind))
+	}
+
+	return nil
+}
+
+func containsMimeType(mimeTypes []string, mimeType string) bool {
+	for _, item := range mimeTypes {
+		if item == mimeType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// mp4Dimensions Размеры первой видеодорожки из заголовка tkhd контейнера MP4/MOV
+func mp4Dimensions(r io.ReaderAt, size int64) (int, int, error) {
+	return mp4Walk(r, 0, size)
+}
+
+func mp4Walk(r io.ReaderAt, offset, end int64) (int, int, error) {
+	header := make([]byte, 16)
+
+	for offset+8 <= end {
+		if _, err := r.ReadAt(header[:8], offset); err != nil {
+			return 0, 0, err
+		}
+
+		boxSize := int64(binary.BigEndian.Uint32(header[:4]))
+		boxType := string(header[4:8])
+		headerSize := int64(8)
+
+		switch boxSize {
+		case 0:
+			boxSize = end - offset
+		case 1:
+			if _, err := r.ReadAt(header[8:16], offset+8); err != nil {
+				return 0, 0, err
+			}
+
+			boxSize = int64(binary.BigEndian.Uint64(header[8:16]))
+			headerSize = 16
+		}
+
+		if boxSize < headerSize || offset+boxSize > end {
+			return 0, 0, fmt.Errorf("Box [%s] is broken", boxType)
+		}
+
+		switch boxType {
+		case "moov", "trak":
+			if width, height, err := mp4Walk(r, offset+headerSize, offset+boxSize); err == nil {
+				return width, height, nil
+			}
+		case "tkhd":
+			if width, height := mp4TrackDimensions(r, offset+headerSize, boxSize-headerSize); width > 0 && height > 0 {
+				return width, height, nil
+			}
+		}
+
+		offset += boxSize
+	}
+
+	return 0, 0, fmt.Errorf("Video track was not found")
+}
+
+// mp4TrackDimensions Ширина и высота в конце tkhd в формате 16.16, у звуковых дорожек нулевые
+func mp4TrackDimensions(r io.ReaderAt, offset, size int64) (int, int) {
+	if size < 8 {
+		return 0, 0
+	}
+
+	bs := make([]byte, 8)
+	if _, err := r.ReadAt(bs, offset+size-8); err != nil {
+		return 0, 0
+	}
+
+	return int(binary.BigEndian.Uint32(bs[:4]) >> 16), int(binary.BigEndian.Uint32(bs[4:]) >> 16)
+}
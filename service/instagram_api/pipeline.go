@@ -0,0 +1,283 @@
+package instagram_api
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	InFlightMax = 4 // Ограничение количества одновременных запросов к слоту
+)
+
+const (
+	PriorityHigh   = iota + 1 // Отправка сообщений и авторизация
+	PriorityNormal            // Синхронизация тредов и запросов на переписку
+	PriorityLow               // Обход inbox
+)
+
+// methodPolicy Правила пропуска запросов метода через конвейер слота
+type methodPolicy struct {
+	priority int
+	bucket   string // Методы с одинаковым bucket делят лимит, пустое значение - без лимита
+	stream   bool   // Долгоживущий запрос не занимает место среди одновременных
+	probe    bool   // Проверка слота проходит вне очереди, чтобы задержка отражала только ответ слота
+}
+
+// isCounted Запрос занимает место среди одновременных
+func (p methodPolicy) isCounted() bool {
+	return !p.stream && !p.probe
+}
+
+// bucketLimit Token bucket: rate токенов за period, не более burst подряд
+type bucketLimit struct {
+	rate   int
+	period time.Duration
+	burst  int
+}
+
+var methodPolicies = map[string]methodPolicy{
+	"direct@send_text":             {priority: PriorityHigh, bucket: "send"},
+	"realtime@send_text":           {priority: PriorityHigh, bucket: "send"},
+	"direct@send_photo":            {priority: PriorityHigh, bucket: "send"},
+	"direct@send_video":            {priority: PriorityHigh, bucket: "send"},
+	"direct@send_voice":            {priority: PriorityHigh, bucket: "send"},
+	"direct@send_like":             {priority: PriorityHigh, bucket: "send"},
+	"direct@send_link":             {priority: PriorityHigh, bucket: "send"},
+	"direct@send_reaction":         {priority: PriorityHigh, bucket: "send"},
+	"direct@delete_reaction":       {priority: PriorityHigh, bucket: "send"},
+	"direct@mark_seen":             {priority: PriorityNormal, bucket: "seen"},
+	"auth@login":                   {priority: PriorityHigh, bucket: "auth"},
+	"auth@login2f":                 {priority: PriorityHigh, bucket: "auth"},
+	"auth@challenge":               {priority: PriorityHigh, bucket: "auth"},
+	"auth@logout":                  {priority: PriorityHigh},
+	"system@discovery":             {priority: PriorityHigh, probe: true},
+	"realtime@start":               {priority: PriorityNormal, stream: true},
+	"direct@thread":                {priority: PriorityNormal, bucket: "thread"},
+	"direct@inbox_pending":         {priority: PriorityNormal, bucket: "pending"},
+	"direct@accept_inbox_pending":  {priority: PriorityNormal, bucket: "pending"},
+	"direct@decline_inbox_pending": {priority: PriorityNormal, bucket: "pending"},
+	"direct@inbox":                 {priority: PriorityLow, bucket: "inbox"},
+}
+
+var bucketLimits = map[string]bucketLimit{
+	"send":    {rate: 20, period: time.Minute, burst: 5},
+	"auth":    {rate: 5, period: time.Minute, burst: 2},
+	"thread":  {rate: 12, period: time.Minute, burst: 3},
+	"pending": {rate: 6, period: time.Minute, burst: 2},
+	"inbox":   {rate: 6, period: time.Minute, burst: 2},
+	"seen":    {rate: 12, period: time.Minute, burst: 3},
+}
+
+func policyFor(method string) methodPolicy {
+	if policy, ok := methodPolicies[method]; ok {
+		return policy
+	}
+
+	return methodPolicy{priority: PriorityNormal}
+}
+
+type tokenBucket struct {
+	limit    bucketLimit
+	tokens   float64
+	refillAt time.Time
+}
+
+func newTokenBucket(limit bucketLimit) *tokenBucket {
+	return &tokenBucket{
+		limit:    limit,
+		tokens:   float64(limit.burst),
+		refillAt: time.Now(),
+	}
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	elapsed := now.Sub(b.refillAt)
+	b.refillAt = now
+
+	b.tokens += elapsed.Seconds() * float64(b.limit.rate) / b.limit.period.Seconds()
+	if b.tokens > float64(b.limit.burst) {
+		b.tokens = float64(b.limit.burst)
+	}
+}
+
+// take Возвращает 0, если токен получен, иначе время до появления токена
+func (b *tokenBucket) take(now time.Time) time.Duration {
+	b.refill(now)
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+
+	missing := 1 - b.tokens
+
+	return time.Duration(missing * float64(b.limit.period) / float64(b.limit.rate))
+}
+
+type waiter struct {
+	policy   methodPolicy
+	seq      uint64
+	admitted bool
+	ready    chan struct{}
+}
+
+// pipeline Очередь запросов слота с приоритетами, ограничением одновременных запросов и лимитами методов
+type pipeline struct {
+	mux         sync.Mutex
+	inFlight    int
+	inFlightMax int
+	seq         uint64
+	queue       []*waiter
+	buckets     map[string]*tokenBucket
+	timer       *time.Timer
+	timerAt     time.Time
+}
+
+func newPipeline() *pipeline {
+	buckets := make(map[string]*tokenBucket, len(bucketLimits))
+	for name, limit := range bucketLimits {
+		buckets[name] = newTokenBucket(limit)
+	}
+
+	return &pipeline{
+		inFlightMax: InFlightMax,
+		queue:       make([]*waiter, 0),
+		buckets:     buckets,
+	}
+}
+
+// acquire Ожидает очереди запроса, возвращает функцию освобождения места среди одновременных запросов
+func (p *pipeline) acquire(ctx context.Context, method string) (func(), error) {
+	p.mux.Lock()
+
+	p.seq++
+	w := &waiter{
+		policy: policyFor(method),
+		seq:    p.seq,
+		ready:  make(chan struct{}),
+	}
+
+	p.queue = append(p.queue, w)
+	sort.SliceStable(p.queue, func(i, j int) bool {
+		if p.queue[i].policy.priority != p.queue[j].policy.priority {
+			return p.queue[i].policy.priority < p.queue[j].policy.priority
+		}
+
+		return p.queue[i].seq < p.queue[j].seq
+	})
+
+	p.dispatch()
+	p.mux.Unlock()
+
+	select {
+	case <-w.ready:
+		return p.releaser(w), nil
+	case <-ctx.Done():
+		p.mux.Lock()
+		defer p.mux.Unlock()
+
+		// Запрос мог быть пропущен одновременно с отменой
+		if w.admitted {
+			if w.policy.isCounted() {
+				p.inFlight--
+				p.dispatch()
+			}
+
+			return nil, ctx.Err()
+		}
+
+		p.remove(w)
+
+		return nil, ctx.Err()
+	}
+}
+
+func (p *pipeline) releaser(w *waiter) func() {
+	once := sync.Once{}
+
+	return func() {
+		once.Do(func() {
+			if !w.policy.isCounted() {
+				return
+			}
+
+			p.mux.Lock()
+			defer p.mux.Unlock()
+
+			p.inFlight--
+			p.dispatch()
+		})
+	}
+}
+
+// dispatch Пропускает запросы в порядке приоритета, вызывается под блокировкой
+// Запрос, упершийся в лимит своего метода, не задерживает запросы других методов
+func (p *pipeline) dispatch() {
+	now := time.Now()
+	wait := time.Duration(0)
+
+	queue := p.queue[:0]
+
+	for _, w := range p.queue {
+		// Мест нет: ждут все, кроме долгоживущих запросов и проверок слота
+		if w.policy.isCounted() && p.inFlight >= p.inFlightMax {
+			queue = append(queue, w)
+			continue
+		}
+
+		if bucket, ok := p.buckets[w.policy.bucket]; ok {
+			if d := bucket.take(now); d > 0 {
+				if wait == 0 || d < wait {
+					wait = d
+				}
+
+				queue = append(queue, w)
+				continue
+			}
+		}
+
+		if w.policy.isCounted() {
+			p.inFlight++
+		}
+
+		w.admitted = true
+		close(w.ready)
+	}
+
+	p.queue = queue
+
+	if wait > 0 {
+		p.schedule(now.Add(wait))
+	}
+}
+
+// schedule Повторный проход очереди, когда появится токен
+func (p *pipeline) schedule(at time.Time) {
+	if p.timer != nil && !p.timerAt.IsZero() && !at.Before(p.timerAt) {
+		return
+	}
+
+	if p.timer != nil {
+		p.timer.Stop()
+	}
+
+	p.timerAt = at
+	p.timer = time.AfterFunc(time.Until(at), func() {
+		p.mux.Lock()
+		defer p.mux.Unlock()
+
+		p.timerAt = time.Time{}
+		p.dispatch()
+	})
+}
+
+func (p *pipeline) remove(w *waiter) {
+	for i, item := range p.queue {
+		if item == w {
+			p.queue = append(p.queue[:i], p.queue[i+1:]...)
+			return
+		}
+	}
+}
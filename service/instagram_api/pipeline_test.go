@@ -0,0 +1,195 @@
+package instagram_api
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+const (
+	testAcquireTimeout = 100 * time.Millisecond
+)
+
+func acquireNow(t *testing.T, p *pipeline, method string) func() {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), testAcquireTimeout)
+	defer cancel()
+
+	release, err := p.acquire(ctx, method)
+	if err != nil {
+		t.Fatalf("acquire %s: %s", method, err)
+	}
+
+	return release
+}
+
+func acquireBlocked(t *testing.T, p *pipeline, method string) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), testAcquireTimeout)
+	defer cancel()
+
+	if _, err := p.acquire(ctx, method); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("acquire %s: want blocked, got %v", method, err)
+	}
+}
+
+func TestPipelineInFlightLimit(t *testing.T) {
+	p := newPipeline()
+
+	releases := make([]func(), 0, InFlightMax)
+	for i := 0; i < InFlightMax; i++ {
+		releases = append(releases, acquireNow(t, p, "direct@unknown"))
+	}
+
+	acquireBlocked(t, p, "direct@unknown")
+
+	// Отмененный запрос не остается в очереди и не занимает место
+	if len(p.queue) != 0 {
+		t.Fatalf("queue: want empty, got %d", len(p.queue))
+	}
+
+	releases[0]()
+	// Повторное освобождение не должно освобождать чужое место
+	releases[0]()
+
+	release := acquireNow(t, p, "direct@unknown")
+	acquireBlocked(t, p, "direct@unknown")
+
+	release()
+	for _, release := range releases[1:] {
+		release()
+	}
+
+	if p.inFlight != 0 {
+		t.Fatalf("inFlight: want 0, got %d", p.inFlight)
+	}
+}
+
+func TestPipelineProbeAndStreamBypassLimit(t *testing.T) {
+	p := newPipeline()
+
+	for i := 0; i < InFlightMax; i++ {
+		defer acquireNow(t, p, "direct@unknown")()
+	}
+
+	// Проверка слота и realtime не ждут освобождения мест и не занимают их
+	acquireNow(t, p, "system@discovery")()
+	acquireNow(t, p, "realtime@start")()
+
+	if p.inFlight != InFlightMax {
+		t.Fatalf("inFlight: want %d, got %d", InFlightMax, p.inFlight)
+	}
+}
+
+func TestPipelinePriority(t *testing.T) {
+	p := newPipeline()
+
+	releases := make([]func(), 0, InFlightMax)
+	for i := 0; i < InFlightMax; i++ {
+		releases = append(releases, acquireNow(t, p, "direct@unknown"))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	admitted := make(chan string, 2)
+	acquire := func(method string) {
+		release, err := p.acquire(ctx, method)
+		if err != nil {
+			return
+		}
+
+		admitted <- method
+		release()
+	}
+
+	go acquire("direct@inbox")
+
+	// Запрос с низким приоритетом встал в очередь первым
+	waitFor(t, func() bool {
+		p.mux.Lock()
+		defer p.mux.Unlock()
+
+		return len(p.queue) == 1
+	})
+
+	go acquire("auth@logout")
+
+	waitFor(t, func() bool {
+		p.mux.Lock()
+		defer p.mux.Unlock()
+
+		return len(p.queue) == 2
+	})
+
+	releases[0]()
+
+	if method := <-admitted; method != "auth@logout" {
+		t.Fatalf("admitted: want auth@logout first, got %s", method)
+	}
+
+	if method := <-admitted; method != "direct@inbox" {
+		t.Fatalf("admitted: want direct@inbox second, got %s", method)
+	}
+
+	for _, release := range releases[1:] {
+		release()
+	}
+}
+
+func TestPipelineBucketLimit(t *testing.T) {
+	p := newPipeline()
+
+	burst := bucketLimits["auth"].burst
+	for i := 0; i < burst; i++ {
+		acquireNow(t, p, "auth@login")()
+	}
+
+	acquireBlocked(t, p, "auth@login2f")
+
+	// Исчерпанный лимит метода не задерживает методы других bucket
+	acquireNow(t, p, "direct@thread")()
+	acquireNow(t, p, "auth@logout")()
+}
+
+func TestTokenBucket(t *testing.T) {
+	limit := bucketLimit{rate: 6, period: time.Minute, burst: 2}
+	b := newTokenBucket(limit)
+	now := b.refillAt
+
+	for i := 0; i < limit.burst; i++ {
+		if d := b.take(now); d != 0 {
+			t.Fatalf("take %d: want token, got wait %s", i, d)
+		}
+	}
+
+	if d := b.take(now); d != 10*time.Second {
+		t.Fatalf("take: want wait 10s, got %s", d)
+	}
+
+	if d := b.take(now.Add(10 * time.Second)); d != 0 {
+		t.Fatalf("take after refill: want token, got wait %s", d)
+	}
+
+	// Запас не превышает burst даже после долгого простоя
+	b.take(now.Add(time.Hour))
+	if b.tokens != float64(limit.burst-1) {
+		t.Fatalf("tokens: want %d, got %f", limit.burst-1, b.tokens)
+	}
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition was not met in time")
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+}
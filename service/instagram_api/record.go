@@ -0,0 +1,221 @@
+package instagram_api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+)
+
+const (
+	RecordFileSizeMax = 10 << 20 // Размер файла записи, после которого файл ротируется
+	RecordFilesMax    = 5        // Количество хранимых ротированных файлов аккаунта
+	RecordFileExt     = ".rec"
+)
+
+const (
+	RecordDirectionOut = "out" // Запрос к слоту
+	RecordDirectionIn  = "in"  // Ответ слота
+)
+
+const redacted = "***"
+
+// redactedKeys Параметры, значения которых не попадают в запись: пароль, прокси с учетными данными и коды подтверждения
+var redactedKeys = map[string]struct{}{
+	"password": {},
+	"proxy":    {},
+	"code":     {},
+}
+
+var recordNameReplacer = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// RecordFrame Строка записи: один кадр JSON-RPC
+type RecordFrame struct {
+	At        int64           `json:"at"` // Миллисекунды
+	Direction string          `json:"dir"`
+	Host      string          `json:"host"`
+	ID        string          `json:"id"`
+	Method    string          `json:"method,omitempty"` // Только для запросов, ответы сопоставляются с запросами по ID
+	Frame     json.RawMessage `json:"frame"`
+}
+
+// Recorder Пишет кадры JSON-RPC слотов в файлы по аккаунтам, по одной строке JSON на кадр
+type Recorder struct {
+	mux      sync.Mutex
+	dir      string
+	sizeMax  int64
+	filesMax int
+	files    map[string]*recordFile
+	closed   bool
+}
+
+type recordFile struct {
+	file *os.File
+	size int64
+}
+
+func NewRecorder(dir string) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("Failed to create record dir [%s]. %w", dir, err)
+	}
+
+	return &Recorder{
+		dir:      dir,
+		sizeMax:  RecordFileSizeMax,
+		filesMax: RecordFilesMax,
+		files:    make(map[string]*recordFile),
+	}, nil
+}
+
+// record Записывает кадр в файл аккаунта, до входа аккаунт неизвестен и кадр пишется в файл хоста слота
+func (r *Recorder) record(account string, frame RecordFrame) error {
+	frame.At = time.Now().UnixNano() / int64(time.Millisecond)
+	frame.Frame = redact(frame.Frame)
+
+	line, err := json.Marshal(frame)
+	if err != nil {
+		return err
+	}
+
+	line = append(line, '\n')
+
+	name := account
+	if name == "" {
+		name = frame.Host
+	}
+
+	name = recordNameReplacer.ReplaceAllString(name, "_")
+
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	// Сервисы слотов могут закрываться позже записи
+	if r.closed {
+		return nil
+	}
+
+	f, err := r.open(name)
+	if err != nil {
+		return err
+	}
+
+	if f.size+int64(len(line)) > r.sizeMax && f.size > 0 {
+		if f, err = r.rotate(name); err != nil {
+			return err
+		}
+	}
+
+	n, err := f.file.Write(line)
+	f.size += int64(n)
+
+	return err
+}
+
+func (r *Recorder) path(name string, index int) string {
+	path := filepath.Join(r.dir, name+RecordFileExt)
+	if index > 0 {
+		path = fmt.Sprintf("%s.%d", path, index)
+	}
+
+	return path
+}
+
+func (r *Recorder) open(name string) (*recordFile, error) {
+	if f, ok := r.files[name]; ok {
+		return f, nil
+	}
+
+	file, err := os.OpenFile(r.path(name, 0), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	f := &recordFile{
+		file: file,
+		size: info.Size(),
+	}
+
+	r.files[name] = f
+
+	return f, nil
+}
+
+// rotate Сдвигает файлы name.rec.N -> name.rec.N+1, самый старый удаляется
+func (r *Recorder) rotate(name string) (*recordFile, error) {
+	if f, ok := r.files[name]; ok {
+		_ = f.file.Close()
+		delete(r.files, name)
+	}
+
+	_ = os.Remove(r.path(name, r.filesMax))
+
+	for i := r.filesMax - 1; i >= 0; i-- {
+		if err := os.Rename(r.path(name, i), r.path(name, i+1)); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	return r.open(name)
+}
+
+func (r *Recorder) Close() {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	r.closed = true
+
+	for name, f := range r.files {
+		_ = f.file.Close()
+		delete(r.files, name)
+	}
+}
+
+// redact Заменяет значения секретных параметров на любой глубине кадра
+func redact(frame json.RawMessage) json.RawMessage {
+	// Идентификаторы Instagram не помещаются в float64, числа сохраняются как есть
+	decoder := json.NewDecoder(bytes.NewReader(frame))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		// Кадр не разбирается как JSON - сохраняем строкой, чтобы запись осталась валидной
+		value = string(frame)
+	}
+
+	bs, err := json.Marshal(redactValue(value))
+	if err != nil {
+		return json.RawMessage("null")
+	}
+
+	return bs
+}
+
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if _, ok := redactedKeys[key]; ok && item != nil && item != "" {
+				v[key] = redacted
+				continue
+			}
+
+			v[key] = redactValue(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+	}
+
+	return value
+}
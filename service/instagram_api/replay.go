@@ -0,0 +1,132 @@
+package instagram_api
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"channels-instagram-dm/domain"
+)
+
+// ReplayResult Результат повторного разбора ответа слота из записи
+type ReplayResult struct {
+	Frame  RecordFrame
+	Method string
+	Error  string // Ошибка, которой ответил слот
+	Err    error  // Ошибка разбора ответа или преобразования в модель
+}
+
+// Replay Пропускает ответы из записи через тот же разбор PayloadResponse и toModel, что и при работе со слотом
+// Метод ответа определяется по запросу с тем же ID в той же записи
+func Replay(r io.Reader) ([]ReplayResult, error) {
+	reader := bufio.NewReader(r)
+	methods := make(map[string]string)
+	results := make([]ReplayResult, 0)
+
+	for line := 1; ; line++ {
+		bs, err := reader.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return results, err
+		}
+
+		if len(bs) > 0 {
+			frame := RecordFrame{}
+			if errFrame := json.Unmarshal(bs, &frame); errFrame != nil {
+				return results, fmt.Errorf("Line [%d] is not a record frame. %w", line, errFrame)
+			}
+
+			switch frame.Direction {
+			case RecordDirectionOut:
+				methods[frame.ID] = frame.Method
+			case RecordDirectionIn:
+				results = append(results, replayFrame(frame, methods[frame.ID]))
+			}
+		}
+
+		if errors.Is(err, io.EOF) {
+			return results, nil
+		}
+	}
+}
+
+func replayFrame(frame RecordFrame, method string) ReplayResult {
+	result := ReplayResult{
+		Frame:  frame,
+		Method: method,
+	}
+
+	resp := Response{}
+	if err := json.Unmarshal(frame.Frame, &resp); err != nil {
+		result.Err = fmt.Errorf("Unmarshal message: Error. %w", err)
+		return result
+	}
+
+	if method == "" {
+		result.Err = fmt.Errorf("Request [%s] was not recorded", resp.ID)
+		return result
+	}
+
+	response, err := decodeResponse(resp, frame.Frame, newResult(method))
+	if err != nil {
+		result.Err = fmt.Errorf("Unmarshal message: Error. %w", err)
+		return result
+	}
+
+	if response.Error != "" {
+		result.Error = response.Error
+		return result
+	}
+
+	result.Err = resultToModel(response.Result)
+
+	return result
+}
+
+// newResult Тип результата метода, как его ожидают методы сервиса
+func newResult(method string) interface{} {
+	switch method {
+	case "direct@inbox":
+		return new(InboxResponse)
+	case "direct@inbox_pending":
+		return new([]Thread)
+	case "direct@accept_inbox_pending", "direct@decline_inbox_pending":
+		return new([]string)
+	case "direct@thread":
+		return new(Thread)
+	case "realtime@start":
+		return new(RealtimeUpdate)
+	case "auth@login":
+		return new(LoginRequired)
+	case "system@discovery":
+		return new(domain.DiscoveryRow)
+	default:
+		return nil
+	}
+}
+
+// resultToModel Выполняет преобразование результата в модель, как это делают методы сервиса
+func resultToModel(result interface{}) error {
+	switch v := result.(type) {
+	case *InboxResponse:
+		_, err := v.toModel()
+		return err
+	case *[]Thread:
+		for _, thread := range *v {
+			if _, err := thread.toModel(); err != nil {
+				return fmt.Errorf("Thread [%s]. %w", thread.ID, err)
+			}
+		}
+	case *Thread:
+		_, err := v.toModel()
+		return err
+	case *RealtimeUpdate:
+		_, err := v.toModel()
+		return err
+	case *LoginRequired:
+		v.toModel()
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package instagram_api
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"channels-instagram-dm/domain/model"
+)
+
+func (s *service) DirectSendLike(username string, message model.Message) error {
+	request := NewRequest("direct@send_like")
+
+	payload, ok := message.Payload.(model.MessageLike)
+	if !ok {
+		return fmt.Errorf("Mismatch message payload type, want like ")
+	}
+
+	request.Params = struct {
+		Like     string `json:"like"`
+		Username string `json:"username"`
+	}{
+		Like:     payload.Like,
+		Username: username,
+	}
+
+	ctx, cancel := context.WithTimeout(s.ctx, 3*time.Minute)
+	defer cancel()
+
+	ch, err := s.send(ctx, request.ID, request, nil)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("Stopped by timeout %w", ctx.Err())
+	case response, ok := <-ch:
+		if !ok {
+			return fmt.Errorf("Channel was closed")
+		}
+
+		if response.Error != "" {
+			return newError(response.Error)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,52 @@
+package instagram_api
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"channels-instagram-dm/domain/model"
+)
+
+// DirectSendLink Превью ссылки Instagram строит сам, заголовок и описание из payload не передаются
+func (s *service) DirectSendLink(username string, message model.Message) error {
+	request := NewRequest("direct@send_link")
+
+	payload, ok := message.Payload.(model.MessageLink)
+	if !ok {
+		return fmt.Errorf("Mismatch message payload type, want link ")
+	}
+
+	request.Params = struct {
+		Text     string   `json:"text"`
+		Urls     []string `json:"urls"`
+		Username string   `json:"username"`
+	}{
+		Text:     payload.Url,
+		Urls:     []string{payload.Url},
+		Username: username,
+	}
+
+	ctx, cancel := context.WithTimeout(s.ctx, 3*time.Minute)
+	defer cancel()
+
+	ch, err := s.send(ctx, request.ID, request, nil)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("Stopped by timeout %w", ctx.Err())
+	case response, ok := <-ch:
+		if !ok {
+			return fmt.Errorf("Channel was closed")
+		}
+
+		if response.Error != "" {
+			return newError(response.Error)
+		}
+
+		return nil
+	}
+}
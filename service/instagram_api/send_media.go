@@ -0,0 +1,108 @@
+package instagram_api
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"channels-instagram-dm/domain/model"
+)
+
+type SendMediaParams struct {
+	Username string `json:"username"`
+	File     string `json:"file"` // Содержимое файла в base64
+	MimeType string `json:"mime_type"`
+	Width    int    `json:"width,omitempty"`
+	Height   int    `json:"height,omitempty"`
+}
+
+func (s *service) DirectSendPhoto(username string, message model.Message) (model.MessageMediaImage, error) {
+	payload, ok := message.Payload.(model.MessageMediaImage)
+	if !ok {
+		return payload, fmt.Errorf("Mismatch message payload type, want photo ")
+	}
+
+	media, err := s.sendMedia("direct@send_photo", username, payload.Url, photoLimits)
+	if err != nil {
+		return payload, err
+	}
+
+	payload.Width, payload.Height = media.width, media.height
+
+	return payload, nil
+}
+
+func (s *service) DirectSendVideo(username string, message model.Message) (model.MessageMediaVideo, error) {
+	payload, ok := message.Payload.(model.MessageMediaVideo)
+	if !ok {
+		return payload, fmt.Errorf("Mismatch message payload type, want video ")
+	}
+
+	media, err := s.sendMedia("direct@send_video", username, payload.Url, videoLimits)
+	if err != nil {
+		return payload, err
+	}
+
+	payload.Width, payload.Height = media.width, media.height
+
+	return payload, nil
+}
+
+func (s *service) DirectSendVoice(username string, message model.Message) (model.MessageMediaVoice, error) {
+	payload, ok := message.Payload.(model.MessageMediaVoice)
+	if !ok {
+		return payload, fmt.Errorf("Mismatch message payload type, want voice ")
+	}
+
+	if _, err := s.sendMedia("direct@send_voice", username, payload.Url, voiceLimits); err != nil {
+		return payload, err
+	}
+
+	return payload, nil
+}
+
+// sendMedia Загружает медиа из Channels и передает его слоту для отправки
+func (s *service) sendMedia(method, username, url string, limits mediaLimits) (mediaFile, error) {
+	media, err := downloadMedia(s.ctx, url, limits)
+	if err != nil {
+		return media, err
+	}
+	defer media.remove()
+
+	file, err := media.base64()
+	if err != nil {
+		return media, err
+	}
+
+	request := NewRequest(method)
+	request.Params = SendMediaParams{
+		Username: username,
+		File:     file,
+		MimeType: media.mimeType,
+		Width:    media.width,
+		Height:   media.height,
+	}
+
+	ctx, cancel := context.WithTimeout(s.ctx, 5*time.Minute)
+	defer cancel()
+
+	ch, err := s.send(ctx, request.ID, request, nil)
+	if err != nil {
+		return media, err
+	}
+
+	select {
+	case <-ctx.Done():
+		return media, fmt.Errorf("Stopped by timeout %w", ctx.Err())
+	case response, ok := <-ch:
+		if !ok {
+			return media, fmt.Errorf("Channel was closed")
+		}
+
+		if response.Error != "" {
+			return media, newError(response.Error)
+		}
+
+		return media, nil
+	}
+}
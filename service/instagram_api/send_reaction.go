@@ -0,0 +1,55 @@
+package instagram_api
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"channels-instagram-dm/domain/model"
+)
+
+// DirectSendReaction Ставит или снимает реакцию на сообщение треда
+func (s *service) DirectSendReaction(threadID string, message model.Message) error {
+	payload, ok := message.Payload.(model.MessageReaction)
+	if !ok {
+		return fmt.Errorf("Mismatch message payload type, want reaction ")
+	}
+
+	request := NewRequest("direct@send_reaction")
+	if payload.Remove {
+		request = NewRequest("direct@delete_reaction")
+	}
+
+	request.Params = struct {
+		ThreadID string `json:"thread_id"`
+		ItemID   string `json:"item_id"`
+		Emoji    string `json:"emoji,omitempty"`
+	}{
+		ThreadID: threadID,
+		ItemID:   payload.ItemID,
+		Emoji:    payload.Emoji,
+	}
+
+	ctx, cancel := context.WithTimeout(s.ctx, 3*time.Minute)
+	defer cancel()
+
+	ch, err := s.send(ctx, request.ID, request, nil)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("Stopped by timeout %w", ctx.Err())
+	case response, ok := <-ch:
+		if !ok {
+			return fmt.Errorf("Channel was closed")
+		}
+
+		if response.Error != "" {
+			return newError(response.Error)
+		}
+
+		return nil
+	}
+}
@@ -3,15 +3,23 @@ package instagram_api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"net/url"
+	"math/rand"
 	"sync"
 	"time"
 
 	"channels-instagram-dm/domain"
 
 	"github.com/google/uuid"
-	"github.com/gorilla/websocket"
+)
+
+const (
+	ReconnectDelayMin     = 1 * time.Second
+	ReconnectDelayMax     = 1 * time.Minute
+	ReconnectAttemptsMax  = 10 // После исчерпания попыток сервис закрывается, и слот становится недоступным
+	ListenerFailTimeout   = 5 * time.Second
+	StateSubscriberBuffer = 4
 )
 
 type Request struct {
@@ -51,18 +59,45 @@ type DiscoveryRow struct {
 
 type service struct {
 	mux          sync.Mutex
+	writeMux     sync.Mutex // Запись в транспорт не допускает конкурентных вызовов, под ней же заменяется conn
+	stateMux     sync.Mutex
+	pipeline     *pipeline
 	cancel       context.CancelFunc
 	ctx          context.Context
 	logger       domain.Logger
-	conn         *websocket.Conn
+	endpoint     endpoint
+	conn         transport
+	state        domain.ConnectionState
+	stateSubs    map[int]chan domain.ConnectionState
+	stateSubsSeq int
 	inbox        chan struct{}
-	listenersMap map[string]listener
+	listenersMap map[string]*listener
+	recordMux    sync.Mutex
+	recorder     *Recorder
+	account      string // Аккаунт сессии слота, по нему выбирается файл записи
 }
 
 type listener struct {
-	ctx    context.Context
-	ch     chan PayloadResponse
-	result interface{}
+	ctx     context.Context
+	ch      chan PayloadResponse
+	result  interface{}
+	release func()
+	mux     sync.Mutex // Разбор ответа, передача в ch и закрытие ch не пересекаются
+	closed  bool
+}
+
+// close Закрывает канал слушателя и освобождает его место среди одновременных запросов
+// Вызывается вне блокировки сервиса: ожидает передачу ответа, которая уже началась
+func (l *listener) close() {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	if !l.closed {
+		l.closed = true
+		close(l.ch)
+	}
+
+	l.release()
 }
 
 func NewRequest(method string) Request {
@@ -73,118 +108,52 @@ func NewRequest(method string) Request {
 	}
 }
 
-func NewService(ctxService context.Context, logger domain.Logger, host string) (*service, error) {
-	u := url.URL{Scheme: "ws", Host: host}
-	c, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
+// NewService Пустой transportName - WebSocket без TLS
+func NewService(ctxService context.Context, logger domain.Logger, host string, transportName string) (*service, error) {
+	e, err := newEndpoint(host, transportName)
 	if err != nil {
-		return nil, fmt.Errorf("Open WebSocket: Error. No connect to %s. Dial err %w ", u.String(), err)
+		return nil, err
 	}
 
 	ctx, cancel := context.WithCancel(ctxService)
 
+	c, err := dial(ctx, e)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
 	s := &service{
 		mux:          sync.Mutex{},
+		writeMux:     sync.Mutex{},
+		stateMux:     sync.Mutex{},
+		pipeline:     newPipeline(),
 		ctx:          ctx,
 		cancel:       cancel,
 		logger:       logger,
+		endpoint:     e,
 		conn:         c,
+		state:        domain.ConnectionStateConnected,
+		stateSubs:    make(map[int]chan domain.ConnectionState),
 		inbox:        make(chan struct{}),
-		listenersMap: make(map[string]listener),
+		listenersMap: make(map[string]*listener),
 	}
 
-	c.SetCloseHandler(func(code int, text string) error {
-		logger.Error(fmt.Sprintf("WebSocketConnection: Closed. Code [%d]. Text [%s]", code, text), nil)
-
-		s.Close()
-		return nil
-	})
-
-	go func() {
-
-		for {
-			select {
-			case <-ctx.Done():
-				logger.Error(ctx.Err().Error(), nil)
-				return
-			default:
-
-			}
-
-			_, message, err := c.ReadMessage()
-			if err != nil {
-				logger.Error(fmt.Sprintf("Read message: Error. %s", err), nil)
-
-				// TODO: Это остановка сервиса и нужно как то обработать
-				if err.Error() == "websocket: close 1006 (abnormal closure): unexpected EOF" {
-					logger.Info(fmt.Sprintf("WebSocketConnection: Need to close"), nil)
-
-					s.Close()
-					return
-				}
-
-				time.Sleep(10 * time.Second)
-				continue
-			}
-
-			response := Response{}
-			err = json.Unmarshal(message, &response)
-			if err != nil {
-				logger.Error(fmt.Sprintf("Unmarshal message: Error. %s", err), string(message))
-				continue
-			}
-
-			if response.Error != "" {
-				logger.Error(fmt.Sprintf("Response on [%s]: Error. %s", response.ID, string(message)), nil)
-			}
-
-			go func(resp Response, message []byte) {
-				s.mux.Lock()
-				defer s.mux.Unlock()
-
-				listener, ok := s.listenersMap[resp.ID]
-				if !ok {
-					logger.Info(fmt.Sprintf("No listener on [%s]", resp.ID), nil)
-					return
-				}
-
-				response := PayloadResponse{
-					Response: resp,
-				}
-
-				if response.Error == "" && listener.result != nil {
-					response.Result = listener.result
-					err = json.Unmarshal(message, &response)
-					if err != nil {
-						logger.Error(fmt.Sprintf("Unmarshal message: Error. %s", err), string(message))
-						return
-					}
-				}
-
-				logger.Debug(fmt.Sprintf("Recived message on listener [%s]", resp.ID), string(message))
-
-				select {
-				case <-ctx.Done():
-					return
-				case <-listener.ctx.Done():
-					return
-				case listener.ch <- response:
-					return
-				}
-			}(response, message)
-		}
-	}()
+	go s.run(c)
 
 	//  Зачищаем неактуальные listeners
 	go func() {
 		for {
 			s.mux.Lock()
 
+			expired := make([]*listener, 0)
+
 			for k, listener := range s.listenersMap {
 				select {
 				case <-listener.ctx.Done():
 					// logger.Debug(fmt.Sprintf("Listener [%s] was deleted", k), nil)
 
-					close(listener.ch)
+					expired = append(expired, listener)
 					delete(s.listenersMap, k)
 				default:
 					continue
@@ -193,17 +162,17 @@ func NewService(ctxService context.Context, logger domain.Logger, host string) (
 
 			s.mux.Unlock()
 
+			for _, listener := range expired {
+				listener.close()
+			}
+
 			select {
 			case <-ctx.Done():
 				// Оповещаем слушателей, что закрыли сервис
-				s.mux.Lock()
-
-				for k, listener := range s.listenersMap {
-					close(listener.ch)
-					delete(s.listenersMap, k)
+				for _, listener := range s.takeListeners() {
+					listener.close()
 				}
 
-				s.mux.Unlock()
 				return
 			case <-time.After(10 * time.Second):
 				continue
@@ -214,11 +183,293 @@ func NewService(ctxService context.Context, logger domain.Logger, host string) (
 	return s, nil
 }
 
+// run Читает соединение, а при разрыве переподключается к тому же слоту, сохраняя сервис
+func (s *service) run(c transport) {
+	for {
+		s.read(c)
+
+		if s.IsClosed() {
+			return
+		}
+
+		// Транспорт мог сохранить незавершенные запросы, освобождаем их
+		_ = c.close()
+
+		s.setState(domain.ConnectionStateReconnecting)
+		s.failListeners()
+
+		conn, err := s.reconnect()
+		if err != nil {
+			s.logger.Error(fmt.Sprintf("Connection: Reconnect failed. %s", err), nil)
+
+			s.Close()
+			return
+		}
+
+		c = conn
+	}
+}
+
+// read Возвращает управление при ошибке чтения: соединение считается потерянным
+func (s *service) read(c transport) {
+	for {
+		message, err := c.read()
+		if errors.Is(err, errFrameTooLarge) {
+			// Повторное подключение не поможет: слот снова пришлет тот же кадр
+			s.logger.Error(fmt.Sprintf("Read message: Frame was dropped. %s", err), nil)
+			continue
+		}
+
+		if err != nil {
+			if !s.IsClosed() {
+				s.logger.Error(fmt.Sprintf("Read message: Error. %s", err), nil)
+			}
+
+			return
+		}
+
+		response := Response{}
+		err = json.Unmarshal(message, &response)
+
+		// Нераспознанный кадр тоже записывается, чтобы ошибку разбора можно было воспроизвести
+		s.record(RecordDirectionIn, response.ID, "", message)
+
+		if err != nil {
+			s.logger.Error(fmt.Sprintf("Unmarshal message: Error. %s", err), string(message))
+			continue
+		}
+
+		if response.Error != "" {
+			s.logger.Error(fmt.Sprintf("Response on [%s]: Error. %s", response.ID, string(message)), nil)
+		}
+
+		go s.dispatch(response, message)
+	}
+}
+
+// dispatch Передает ответ слушателю вне блокировки сервиса: медленный получатель не задерживает
+// ответы другим слушателям и новые запросы. Ответы одного слушателя передаются по очереди
+func (s *service) dispatch(resp Response, message []byte) {
+	s.mux.Lock()
+	listener, ok := s.listenersMap[resp.ID]
+	s.mux.Unlock()
+
+	if !ok {
+		s.logger.Info(fmt.Sprintf("No listener on [%s]", resp.ID), nil)
+		return
+	}
+
+	listener.mux.Lock()
+	defer listener.mux.Unlock()
+
+	if listener.closed {
+		return
+	}
+
+	response, err := decodeResponse(resp, message, listener.result)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Unmarshal message: Error. %s", err), string(message))
+		return
+	}
+
+	s.logger.Debug(fmt.Sprintf("Recived message on listener [%s]", resp.ID), string(message))
+
+	select {
+	case <-s.ctx.Done():
+		return
+	case <-listener.ctx.Done():
+		return
+	case listener.ch <- response:
+		// Ответ получен, место среди одновременных запросов освобождается
+		listener.release()
+		return
+	}
+}
+
+// decodeResponse Разбирает результат ответа в тип, ожидаемый методом
+func decodeResponse(resp Response, message []byte, result interface{}) (PayloadResponse, error) {
+	response := PayloadResponse{
+		Response: resp,
+	}
+
+	if response.Error == "" && result != nil {
+		response.Result = result
+		if err := json.Unmarshal(message, &response); err != nil {
+			return response, err
+		}
+	}
+
+	return response, nil
+}
+
+// failListeners Ответы на запросы, отправленные в потерянное соединение, не придут: завершаем их ошибкой
+// Получатели ожидаются одновременно и вне блокировки сервиса, чтобы не задерживать переподключение
+func (s *service) failListeners() {
+	for k, listener := range s.takeListeners() {
+		go s.failListener(k, listener)
+	}
+}
+
+func (s *service) failListener(id string, listener *listener) {
+	listener.mux.Lock()
+
+	if !listener.closed {
+		response := PayloadResponse{
+			Response: Response{
+				ID:    id,
+				Error: errConnectionLost,
+			},
+		}
+
+		select {
+		case listener.ch <- response:
+		case <-listener.ctx.Done():
+		case <-time.After(ListenerFailTimeout):
+		}
+	}
+
+	listener.mux.Unlock()
+
+	listener.close()
+}
+
+// takeListeners Забирает всех слушателей, новые запросы регистрируются уже в пустом списке
+func (s *service) takeListeners() map[string]*listener {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	listeners := s.listenersMap
+	s.listenersMap = make(map[string]*listener)
+
+	return listeners
+}
+
+func (s *service) reconnect() (transport, error) {
+	for attempt := 0; attempt < ReconnectAttemptsMax; attempt++ {
+		delay := reconnectDelay(attempt)
+
+		s.logger.Info(fmt.Sprintf("Connection: Reconnect attempt [%d] in [%s]", attempt+1, delay), nil)
+
+		select {
+		case <-s.ctx.Done():
+			return nil, s.ctx.Err()
+		case <-time.After(delay):
+		}
+
+		c, err := dial(s.ctx, s.endpoint)
+		if err != nil {
+			s.logger.Error(err.Error(), nil)
+			continue
+		}
+
+		s.writeMux.Lock()
+		s.conn = c
+		s.writeMux.Unlock()
+
+		// Сервис мог быть закрыт, пока устанавливалось соединение
+		if s.IsClosed() {
+			_ = c.close()
+			return nil, s.ctx.Err()
+		}
+
+		s.setState(domain.ConnectionStateConnected)
+
+		s.logger.Info("Connection: Reconnected", nil)
+
+		return c, nil
+	}
+
+	return nil, fmt.Errorf("Attempts limit [%d] reached", ReconnectAttemptsMax)
+}
+
+// reconnectDelay Экспоненциальная задержка с джиттером в пределах половины интервала
+func reconnectDelay(attempt int) time.Duration {
+	delay := ReconnectDelayMin << attempt
+	if delay <= 0 || delay > ReconnectDelayMax {
+		delay = ReconnectDelayMax
+	}
+
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+func (s *service) setState(state domain.ConnectionState) {
+	s.stateMux.Lock()
+	defer s.stateMux.Unlock()
+
+	if s.state == state || s.state == domain.ConnectionStateClosed {
+		return
+	}
+
+	s.state = state
+
+	for _, ch := range s.stateSubs {
+		// Медленный подписчик пропускает промежуточные состояния, актуальное доступно через ConnectionState
+		select {
+		case ch <- state:
+		default:
+		}
+	}
+}
+
+func (s *service) ConnectionState() domain.ConnectionState {
+	s.stateMux.Lock()
+	defer s.stateMux.Unlock()
+
+	return s.state
+}
+
+func (s *service) SubscribeOnConnectionState() (chan domain.ConnectionState, func()) {
+	s.stateMux.Lock()
+	defer s.stateMux.Unlock()
+
+	s.stateSubsSeq++
+	id := s.stateSubsSeq
+
+	ch := make(chan domain.ConnectionState, StateSubscriberBuffer)
+	s.stateSubs[id] = ch
+
+	once := sync.Once{}
+
+	return ch, func() {
+		once.Do(func() {
+			s.stateMux.Lock()
+			defer s.stateMux.Unlock()
+
+			delete(s.stateSubs, id)
+		})
+	}
+}
+
+// waitConnected Ожидает восстановления соединения после разрыва
+func (s *service) waitConnected(ctx context.Context) error {
+	states, unsubscribe := s.SubscribeOnConnectionState()
+	defer unsubscribe()
+
+	for {
+		switch s.ConnectionState() {
+		case domain.ConnectionStateConnected:
+			return nil
+		case domain.ConnectionStateClosed:
+			return fmt.Errorf("Service has been closed")
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-states:
+		}
+	}
+}
+
 func (s *service) send(ctx context.Context, listenerID string, req Request, result interface{}) (chan PayloadResponse, error) {
 	if s.IsClosed() {
 		return nil, fmt.Errorf("Service has been closed")
 	}
 
+	if s.ConnectionState() != domain.ConnectionStateConnected {
+		return nil, newErrorConnectionLost()
+	}
+
 	ch := make(chan PayloadResponse)
 
 	payload, err := json.Marshal(req)
@@ -226,15 +477,41 @@ func (s *service) send(ctx context.Context, listenerID string, req Request, resu
 		return nil, err
 	}
 
-	s.listenersMap[listenerID] = listener{
-		ctx:    ctx,
-		ch:     ch,
-		result: result,
+	// Слот не примет кадр больше ограничения, повтор запроса не поможет
+	if len(payload) > FrameSizeMax {
+		return nil, domain.NewErrorInvalidArgument(fmt.Sprintf("Request [%s] size [%d] exceeds frame limit [%d]", req.Method, len(payload), FrameSizeMax))
+	}
+
+	// Ожидаем очереди с учетом приоритета метода и лимитов слота
+	release, err := s.pipeline.acquire(ctx, req.Method)
+	if err != nil {
+		return nil, fmt.Errorf("Request [%s] was not queued. %w", req.Method, err)
+	}
+
+	s.mux.Lock()
+	s.listenersMap[listenerID] = &listener{
+		ctx:     ctx,
+		ch:      ch,
+		result:  result,
+		release: release,
 	}
+	s.mux.Unlock()
 
 	s.logger.Debug(fmt.Sprintf("Prepare send: Listener [%s] on method [%s] was added", listenerID, req.Method), nil)
 
-	if err := s.conn.WriteMessage(websocket.TextMessage, payload); err != nil {
+	s.record(RecordDirectionOut, listenerID, req.Method, payload)
+
+	s.writeMux.Lock()
+	err = s.conn.write(ctx, payload, policyFor(req.Method).stream)
+	s.writeMux.Unlock()
+
+	if err != nil {
+		s.mux.Lock()
+		delete(s.listenersMap, listenerID)
+		s.mux.Unlock()
+
+		release()
+
 		return nil, err
 	}
 
@@ -243,14 +520,53 @@ func (s *service) send(ctx context.Context, listenerID string, req Request, resu
 	return ch, nil
 }
 
-func (s *service) Close() {
-	if err := s.conn.Close(); err != nil {
-		s.logger.Error(fmt.Sprintf("Close service: Error. %s", err), nil)
+// SetRecorder Включает запись кадров слота, nil - выключает
+func (s *service) SetRecorder(recorder *Recorder) {
+	s.recordMux.Lock()
+	defer s.recordMux.Unlock()
+
+	s.recorder = recorder
+}
+
+// setAccount Аккаунт становится известен при входе или из discovery слота
+func (s *service) setAccount(username string) {
+	s.recordMux.Lock()
+	defer s.recordMux.Unlock()
+
+	s.account = username
+}
+
+func (s *service) record(direction, id, method string, message []byte) {
+	s.recordMux.Lock()
+	recorder, account := s.recorder, s.account
+	s.recordMux.Unlock()
+
+	if recorder == nil {
+		return
 	}
 
+	err := recorder.record(account, RecordFrame{
+		Direction: direction,
+		Host:      s.endpoint.host,
+		ID:        id,
+		Method:    method,
+		Frame:     message,
+	})
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Record frame [%s]: Error. %s", id, err), nil)
+	}
+}
+
+func (s *service) Close() {
 	s.cancel()
 
-	// TODO: Возможно стоит добавить событие, чтобы оповещать, что сервис закрылся
+	s.writeMux.Lock()
+	if err := s.conn.close(); err != nil {
+		s.logger.Error(fmt.Sprintf("Close service: Error. %s", err), nil)
+	}
+	s.writeMux.Unlock()
+
+	s.setState(domain.ConnectionStateClosed)
 
 	s.logger.Info("Close service: Success", nil)
 }
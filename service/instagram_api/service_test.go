@@ -0,0 +1,342 @@
+package instagram_api_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/model"
+	"channels-instagram-dm/domain/model/instagram"
+	"channels-instagram-dm/service/fake_slot"
+	"channels-instagram-dm/service/instagram_api"
+)
+
+const testTimeout = 10 * time.Second
+
+type nopLogger struct{}
+
+func (l nopLogger) Copy(string) domain.Logger    { return l }
+func (l nopLogger) Writer() io.Writer            { return ioutil.Discard }
+func (l nopLogger) Critical(string, interface{}) {}
+func (l nopLogger) Debug(string, interface{})    {}
+func (l nopLogger) Info(string, interface{})     {}
+func (l nopLogger) Error(string, interface{})    {}
+
+func newTestService(t *testing.T) (*fake_slot.Server, domain.InstagramAPI) {
+	t.Helper()
+
+	slot := fake_slot.NewServer()
+	if err := slot.Start(""); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { _ = slot.Close() })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	service, err := instagram_api.NewService(ctx, nopLogger{}, slot.Host(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(service.Close)
+
+	return slot, service
+}
+
+// newLoggedInService Слот с сохраненной сессией alice и тредом с bob
+func newLoggedInService(t *testing.T) (*fake_slot.Server, domain.InstagramAPI) {
+	t.Helper()
+
+	slot, service := newTestService(t)
+
+	slot.AddAccount(fake_slot.Account{Username: "alice", Password: "password", UserID: "1"})
+	slot.AddSession("alice")
+	slot.AddThread("t1", fake_slot.User{ID: "2", Username: "bob"}, false)
+
+	if _, err := slot.AddItem("t1", fake_slot.Item{Text: "hello"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := service.Login(instagram.Credentials{Username: "alice", Password: "password"}); err != nil {
+		t.Fatal(err)
+	}
+
+	return slot, service
+}
+
+func textMessage(text string) model.Message {
+	message := model.NewMessage("", "", model.MessageSourceChannels)
+	message.Type = model.MessageTypeText
+	message.Payload = model.MessageText{Text: text}
+
+	return message
+}
+
+// Каждый тест получает свой сервис: auth bucket пропускает не больше двух входов подряд
+func TestServiceLoginInvalid(t *testing.T) {
+	slot, service := newTestService(t)
+
+	slot.AddAccount(fake_slot.Account{Username: "alice", Password: "password"})
+
+	if _, err := service.Login(instagram.Credentials{Username: "alice", Password: "wrong"}); !errors.Is(err, domain.ErrorInvalidCredentials) {
+		t.Fatalf("bad password: want invalid credentials, got %v", err)
+	}
+
+	if _, err := service.Login(instagram.Credentials{Username: "nobody"}); !errors.Is(err, domain.ErrorInvalidCredentials) {
+		t.Fatalf("invalid username: want invalid credentials, got %v", err)
+	}
+}
+
+func TestServiceLogin(t *testing.T) {
+	slot, service := newTestService(t)
+
+	slot.AddAccount(fake_slot.Account{Username: "alice", Password: "password", TwoFactorCode: "123456"})
+
+	credentials := instagram.Credentials{Username: "alice", Password: "password"}
+
+	required, err := service.Login(credentials)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if required.Case != instagram.RequiredStep2F {
+		t.Fatalf("login: want 2f, got %s", required.Case)
+	}
+
+	// До подтверждения 2FA сессии нет
+	if _, err := service.DirectInbox("", 20); !errors.Is(err, domain.ErrorNoLoggedIn) {
+		t.Fatalf("inbox before 2f: want no logged in, got %v", err)
+	}
+
+	required.Options.Code = "123456"
+	if err := service.Login2F(credentials, required); err != nil {
+		t.Fatal(err)
+	}
+
+	discovered, err := service.Discovery()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !discovered.Active || discovered.ActiveUser != "alice" || len(discovered.Users) != 1 {
+		t.Fatalf("discovery: want active alice, got %+v", discovered)
+	}
+
+	if err := service.Logout(); err != nil {
+		t.Fatal(err)
+	}
+
+	if discovered, err := service.Discovery(); err != nil || discovered.ActiveUser != "" {
+		t.Fatalf("discovery after logout: want no active user, got %+v %v", discovered, err)
+	}
+}
+
+func TestServiceInboxAndThread(t *testing.T) {
+	slot, service := newLoggedInService(t)
+
+	slot.AddThread("t2", fake_slot.User{ID: "3", Username: "carol"}, true)
+	if _, err := slot.AddItem("t2", fake_slot.Item{Text: "may I?"}); err != nil {
+		t.Fatal(err)
+	}
+
+	inbox, err := service.DirectInbox("", 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(inbox.Threads) != 1 || inbox.Threads[0].ID != "t1" || inbox.PendingRequestsTotal != 1 {
+		t.Fatalf("inbox: want t1 and one pending request, got %d threads, %d pending", len(inbox.Threads), inbox.PendingRequestsTotal)
+	}
+
+	if items := inbox.Threads[0].Items; len(items) != 1 || items[0].Text != "hello" || items[0].UserID != "2" {
+		t.Fatalf("inbox: unexpected items %+v", items)
+	}
+
+	pending, err := service.DirectInboxPending("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pending) != 1 || pending[0].ID != "t2" {
+		t.Fatalf("pending: want t2, got %d threads", len(pending))
+	}
+
+	if err := service.DirectAcceptInboxPending([]string{"t2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	thread, err := service.DirectThread("t2", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if thread.Pending || len(thread.Items) != 1 {
+		t.Fatalf("thread: want accepted with one item, got pending=%t items=%d", thread.Pending, len(thread.Items))
+	}
+
+	if _, err := service.DirectThread("t404", ""); !errors.Is(err, domain.ErrorTargetNotFound) {
+		t.Fatalf("unknown thread: want target not found, got %v", err)
+	}
+}
+
+func TestServiceSend(t *testing.T) {
+	slot, service := newLoggedInService(t)
+
+	if err := service.DirectSendText("bob", textMessage("hi bob")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := service.RealtimeSendText("t1", textMessage("hi again")); err != nil {
+		t.Fatal(err)
+	}
+
+	sent := slot.Sent()
+	if len(sent) != 2 || sent[0].Text != "hi bob" || sent[0].ThreadID != "t1" || !sent[1].Realtime {
+		t.Fatalf("sent: unexpected %+v", sent)
+	}
+
+	if err := service.DirectSendText("nobody", textMessage("hi")); err == nil {
+		t.Fatal("unknown user: want error")
+	}
+}
+
+func TestServiceErrors(t *testing.T) {
+	slot, service := newLoggedInService(t)
+
+	// Ошибки разнесены по методам с разными bucket, чтобы не упираться в лимиты pipeline
+	tests := []struct {
+		method string
+		text   string
+		call   func() error
+		want   error
+	}{
+		{"direct@inbox", "feedback_required", func() error {
+			_, err := service.DirectInbox("", 20)
+			return err
+		}, domain.ErrorRateLimited},
+		{"direct@send_text", "IgActionSpamError: spam", func() error {
+			return service.DirectSendText("bob", textMessage("hi"))
+		}, domain.ErrorSpamBlocked},
+		{"direct@thread", "checkpoint_required", func() error {
+			_, err := service.DirectThread("t1", "")
+			return err
+		}, domain.ErrorCheckpoint},
+		{"direct@inbox_pending", "ESOCKETTIMEDOUT", func() error {
+			_, err := service.DirectInboxPending("")
+			return err
+		}, domain.ErrorTimeout},
+	}
+
+	for _, test := range tests {
+		slot.FailNext(test.method, test.text, 1)
+
+		if err := test.call(); !errors.Is(err, test.want) {
+			t.Errorf("%s %s: want %v, got %v", test.method, test.text, test.want, err)
+		}
+	}
+
+	// Ошибка запроса не затрагивает следующие запросы
+	if _, err := service.DirectInbox("", 20); err != nil {
+		t.Fatal(err)
+	}
+
+	slot.ExpireSession("alice")
+
+	if _, err := service.DirectThread("t1", ""); !errors.Is(err, domain.ErrorNoLoggedIn) {
+		t.Fatalf("expired session: want no logged in, got %v", err)
+	}
+}
+
+func TestServiceRealtimeReconnect(t *testing.T) {
+	slot, service := newLoggedInService(t)
+
+	updates := make(chan instagram.RealtimeUpdate)
+
+	closed, err := service.ListenThreadUpdates(updates)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	states, unsubscribe := service.SubscribeOnConnectionState()
+	defer unsubscribe()
+
+	receive(t, slot, updates, "before")
+
+	slot.Disconnect()
+
+	waitState(t, states, domain.ConnectionStateReconnecting)
+	waitState(t, states, domain.ConnectionStateConnected)
+
+	// Подписка на обновления восстанавливается без участия вызывающего
+	receive(t, slot, updates, "after")
+
+	select {
+	case err := <-closed:
+		t.Fatalf("listener: unexpected stop %v", err)
+	default:
+	}
+
+	if _, err := service.DirectInbox("", 20); err != nil {
+		t.Fatal(err)
+	}
+
+	service.Close()
+
+	if state := service.ConnectionState(); state != domain.ConnectionStateClosed {
+		t.Fatalf("state: want closed, got %s", state)
+	}
+
+	select {
+	case <-closed:
+	case <-time.After(testTimeout):
+		t.Fatal("listener: not stopped after close")
+	}
+}
+
+// receive Отправляет сообщение в тред, пока оно не придет через realtime: подписка могла еще не восстановиться
+func receive(t *testing.T, slot *fake_slot.Server, updates chan instagram.RealtimeUpdate, text string) {
+	t.Helper()
+
+	deadline := time.After(testTimeout)
+
+	for {
+		if _, err := slot.Push("t1", fake_slot.Item{Text: text}); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case update := <-updates:
+			if update.ThreadID != "t1" || string(update.ThreadItem.Text) != text {
+				t.Fatalf("update: unexpected %+v", update)
+			}
+
+			return
+		case <-time.After(100 * time.Millisecond):
+		case <-deadline:
+			t.Fatalf("update %q was not received", text)
+		}
+	}
+}
+
+func waitState(t *testing.T, states chan domain.ConnectionState, want domain.ConnectionState) {
+	t.Helper()
+
+	deadline := time.After(testTimeout)
+
+	for {
+		select {
+		case state := <-states:
+			if state == want {
+				return
+			}
+		case <-deadline:
+			t.Fatalf("state %s was not reached", want)
+		}
+	}
+}
@@ -1,19 +1,15 @@
 package instagram_api
 
 import (
+	"errors"
 	"fmt"
 
+	"channels-instagram-dm/domain"
 	"channels-instagram-dm/domain/model/instagram"
 )
 
 func (s *service) ListenThreadUpdates(chRT chan instagram.RealtimeUpdate) (chan error, error) {
-	request := NewRequest("realtime@start")
-	request.Params = struct {
-		Username string `json:"username"`
-		Password string `json:"password"`
-	}{}
-
-	ch, err := s.send(s.ctx, request.ID, request, new(RealtimeUpdate))
+	ch, err := s.startThreadUpdates()
 	if err != nil {
 		return nil, err
 	}
@@ -51,7 +47,18 @@ func (s *service) ListenThreadUpdates(chRT chan instagram.RealtimeUpdate) (chan
 				if response.Error != "" {
 					s.logger.Error(fmt.Sprintf("ListenThreadUpdates: Response with err %s", response.Error), nil)
 					errClosed = newError(response.Error)
-					return
+
+					if !errors.Is(errClosed, domain.ErrorConnectionLost) {
+						return
+					}
+
+					// Подписка восстанавливается после переподключения к слоту
+					if ch, errClosed = s.restartThreadUpdates(); errClosed != nil {
+						return
+					}
+
+					s.logger.Info("ListenThreadUpdates: Restarted after reconnect", nil)
+					continue
 				}
 
 				val, ok := response.Result.(*RealtimeUpdate)
@@ -78,3 +85,21 @@ func (s *service) ListenThreadUpdates(chRT chan instagram.RealtimeUpdate) (chan
 
 	return closed, nil
 }
+
+func (s *service) startThreadUpdates() (chan PayloadResponse, error) {
+	request := NewRequest("realtime@start")
+	request.Params = struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}{}
+
+	return s.send(s.ctx, request.ID, request, new(RealtimeUpdate))
+}
+
+func (s *service) restartThreadUpdates() (chan PayloadResponse, error) {
+	if err := s.waitConnected(s.ctx); err != nil {
+		return nil, err
+	}
+
+	return s.startThreadUpdates()
+}
@@ -0,0 +1,69 @@
+package instagram_api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+const (
+	TransportWebSocket       = "ws"
+	TransportWebSocketSecure = "wss"
+	TransportHTTP            = "http"
+	TransportHTTPSecure      = "https"
+)
+
+const (
+	FrameSizeMax = 32 << 20 // Ограничение размера кадра JSON-RPC: больший запрос не отправляется, больший кадр SSE пропускается
+)
+
+// errFrameTooLarge Кадр превысил ограничение размера и пропущен, соединение при этом исправно
+var errFrameTooLarge = errors.New("Frame is too large")
+
+// transport Канал обмена сообщениями JSON-RPC со слотом
+// Ответы на запросы читаются через read независимо от способа доставки
+type transport interface {
+	// write Отправляет запрос, stream - ответы на запрос приходят потоком до разрыва соединения
+	write(ctx context.Context, payload []byte, stream bool) error
+	// read Блокирует до получения сообщения, ошибка, кроме errFrameTooLarge, означает потерю соединения
+	read() ([]byte, error)
+	close() error
+}
+
+// endpoint Адрес слота с выбранным транспортом
+type endpoint struct {
+	scheme string
+	host   string
+}
+
+func newEndpoint(host, scheme string) (endpoint, error) {
+	if scheme == "" {
+		scheme = TransportWebSocket
+	}
+
+	switch scheme {
+	case TransportWebSocket, TransportWebSocketSecure, TransportHTTP, TransportHTTPSecure:
+	default:
+		return endpoint{}, fmt.Errorf("Transport [%s] is unsupported", scheme)
+	}
+
+	return endpoint{
+		scheme: scheme,
+		host:   host,
+	}, nil
+}
+
+func (e endpoint) String() string {
+	u := url.URL{Scheme: e.scheme, Host: e.host}
+	return u.String()
+}
+
+func dial(ctx context.Context, e endpoint) (transport, error) {
+	switch e.scheme {
+	case TransportHTTP, TransportHTTPSecure:
+		return dialHTTP(ctx, e)
+	default:
+		return dialWebSocket(e)
+	}
+}
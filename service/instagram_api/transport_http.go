@@ -0,0 +1,311 @@
+package instagram_api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	HTTPDialTimeout   = 10 * time.Second
+	HTTPMessageBuffer = 64
+	SSELineBuffer     = 64 << 10 // Начальный буфер чтения строк потока SSE
+)
+
+// httpTransport JSON-RPC 2.0 поверх HTTP POST, потоковые методы читаются через SSE
+// Сетевая ошибка любого запроса считается потерей соединения, как и разрыв потока SSE
+type httpTransport struct {
+	ctx      context.Context
+	cancel   context.CancelFunc
+	client   *http.Client
+	url      string
+	messages chan []byte
+	dropped  chan error // Пропущенные кадры потока SSE
+	broken   chan struct{}
+	once     sync.Once
+	err      error
+}
+
+func dialHTTP(ctx context.Context, e endpoint) (*httpTransport, error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	t := &httpTransport{
+		ctx:      ctx,
+		cancel:   cancel,
+		client:   &http.Client{},
+		url:      e.String(),
+		messages: make(chan []byte, HTTPMessageBuffer),
+		dropped:  make(chan error, HTTPMessageBuffer),
+		broken:   make(chan struct{}),
+	}
+
+	// Любой HTTP-ответ подтверждает доступность слота
+	ctxDial, cancelDial := context.WithTimeout(ctx, HTTPDialTimeout)
+	defer cancelDial()
+
+	req, err := http.NewRequestWithContext(ctxDial, http.MethodGet, t.url, nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("Open HTTP: Error. No connect to %s. Dial err %w ", t.url, err)
+	}
+
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	_ = resp.Body.Close()
+
+	return t, nil
+}
+
+func (t *httpTransport) write(ctx context.Context, payload []byte, stream bool) error {
+	select {
+	case <-t.broken:
+		return t.err
+	default:
+	}
+
+	if stream {
+		// Поток живет, пока жив транспорт
+		go t.stream(payload)
+		return nil
+	}
+
+	go t.post(ctx, payload)
+
+	return nil
+}
+
+func (t *httpTransport) post(ctx context.Context, payload []byte) {
+	req, err := t.newRequest(ctx, payload, "application/json")
+	if err != nil {
+		t.reject(payload, err)
+		return
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		// Запрос отменен вызывающей стороной, соединение исправно
+		if ctx.Err() != nil || t.ctx.Err() != nil {
+			return
+		}
+
+		t.fail(err)
+		return
+	}
+
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		if ctx.Err() == nil && t.ctx.Err() == nil {
+			t.fail(err)
+		}
+
+		return
+	}
+
+	// Ответ без тела JSON-RPC завершает запрос ошибкой HTTP
+	if !json.Valid(body) {
+		t.reject(payload, fmt.Errorf("HTTP status %s", resp.Status))
+		return
+	}
+
+	t.push(body)
+}
+
+// stream Читает события SSE, данные каждого события - сообщение JSON-RPC
+func (t *httpTransport) stream(payload []byte) {
+	req, err := t.newRequest(t.ctx, payload, "text/event-stream")
+	if err != nil {
+		t.reject(payload, err)
+		return
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		if t.ctx.Err() == nil {
+			t.fail(err)
+		}
+
+		return
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.reject(payload, fmt.Errorf("HTTP status %s", resp.Status))
+		return
+	}
+
+	reader := bufio.NewReaderSize(resp.Body, SSELineBuffer)
+	data := bytes.Buffer{}
+	dropped := false // Событие превысило ограничение и пропускается до конца
+
+	for {
+		line, err := readLine(reader, FrameSizeMax)
+		if errors.Is(err, errFrameTooLarge) {
+			dropped = true
+			data.Reset()
+			continue
+		}
+
+		if err != nil {
+			if t.ctx.Err() != nil {
+				return
+			}
+
+			if errors.Is(err, io.EOF) {
+				err = errors.New("Event stream was closed")
+			}
+
+			t.fail(err)
+			return
+		}
+
+		if line == "" {
+			if dropped {
+				t.drop(fmt.Errorf("%w. Event exceeds [%d] bytes", errFrameTooLarge, FrameSizeMax))
+			} else if data.Len() > 0 {
+				t.push(append([]byte(nil), data.Bytes()...))
+			}
+
+			dropped = false
+			data.Reset()
+
+			continue
+		}
+
+		if dropped || !strings.HasPrefix(line, "data:") {
+			continue
+		}
+
+		if data.Len() > 0 {
+			data.WriteByte('\n')
+		}
+
+		data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+
+		if data.Len() > FrameSizeMax {
+			dropped = true
+			data.Reset()
+		}
+	}
+}
+
+// readLine Читает строку без перевода строки, строка длиннее max дочитывается и отбрасывается с errFrameTooLarge
+func readLine(reader *bufio.Reader, max int) (string, error) {
+	line := make([]byte, 0)
+	tooLarge := false
+
+	for {
+		fragment, err := reader.ReadSlice('\n')
+		if err != nil && !errors.Is(err, bufio.ErrBufferFull) {
+			return "", err
+		}
+
+		if !tooLarge && len(line)+len(fragment) > max {
+			tooLarge = true
+			line = nil
+		}
+
+		if !tooLarge {
+			line = append(line, fragment...)
+		}
+
+		if err == nil {
+			break
+		}
+	}
+
+	if tooLarge {
+		return "", errFrameTooLarge
+	}
+
+	return strings.TrimRight(string(line), "\r\n"), nil
+}
+
+func (t *httpTransport) newRequest(ctx context.Context, payload []byte, accept string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", accept)
+
+	return req, nil
+}
+
+// reject Завершает запрос ошибкой в формате ответа JSON-RPC
+func (t *httpTransport) reject(payload []byte, err error) {
+	request := Request{}
+	if errUnmarshal := json.Unmarshal(payload, &request); errUnmarshal != nil {
+		return
+	}
+
+	message, errMarshal := json.Marshal(Response{
+		ID:      request.ID,
+		JsonRpc: request.JsonRpc,
+		Error:   err.Error(),
+	})
+	if errMarshal != nil {
+		return
+	}
+
+	t.push(message)
+}
+
+func (t *httpTransport) push(message []byte) {
+	select {
+	case <-t.ctx.Done():
+	case <-t.broken:
+	case t.messages <- message:
+	}
+}
+
+// drop Сообщает о пропущенном кадре, не прерывая соединение
+func (t *httpTransport) drop(err error) {
+	select {
+	case <-t.ctx.Done():
+	case <-t.broken:
+	case t.dropped <- err:
+	}
+}
+
+func (t *httpTransport) fail(err error) {
+	t.once.Do(func() {
+		t.err = err
+		close(t.broken)
+	})
+}
+
+func (t *httpTransport) read() ([]byte, error) {
+	select {
+	case message := <-t.messages:
+		return message, nil
+	case err := <-t.dropped:
+		return nil, err
+	case <-t.broken:
+		return nil, t.err
+	case <-t.ctx.Done():
+		return nil, t.ctx.Err()
+	}
+}
+
+func (t *httpTransport) close() error {
+	t.cancel()
+	return nil
+}
@@ -0,0 +1,90 @@
+package instagram_api
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestReadLine(t *testing.T) {
+	data := "short\n" + strings.Repeat("x", 40) + "\nnext\r\n\nlast"
+	reader := bufio.NewReaderSize(strings.NewReader(data), 16)
+
+	want := []struct {
+		line string
+		err  error
+	}{
+		{"short", nil},
+		{"", errFrameTooLarge},
+		{"next", nil},
+		{"", nil},
+	}
+
+	for i, w := range want {
+		line, err := readLine(reader, 32)
+		if line != w.line || !errors.Is(err, w.err) {
+			t.Fatalf("line %d: want %q %v, got %q %v", i, w.line, w.err, line, err)
+		}
+	}
+
+	// Строка без перевода строки в конце потока не считается полной
+	if _, err := readLine(reader, 32); err == nil {
+		t.Fatal("last line: want EOF")
+	}
+}
+
+func TestHTTPStreamDropsOversizedEvent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+
+		_, _ = fmt.Fprintf(w, "data: %s\n\n", strings.Repeat("x", FrameSizeMax+1))
+		_, _ = fmt.Fprint(w, "data: {\"id\":\"1\",\n")
+		_, _ = fmt.Fprint(w, "data: \"jsonrpc\":\"2.0\"}\n\n")
+		w.(http.Flusher).Flush()
+
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	transport, err := dialHTTP(ctx, endpoint{scheme: TransportHTTP, host: u.Host})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer transport.close()
+
+	if err := transport.write(ctx, []byte(`{"id":"1","method":"realtime@start"}`), true); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := transport.read(); !errors.Is(err, errFrameTooLarge) {
+		t.Fatalf("oversized event: want dropped, got %v", err)
+	}
+
+	// Поток не прерывается, следующее событие доставляется
+	message, err := transport.read()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(message) != "{\"id\":\"1\",\n\"jsonrpc\":\"2.0\"}" {
+		t.Fatalf("event: got %q", message)
+	}
+}
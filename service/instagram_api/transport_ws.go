@@ -0,0 +1,37 @@
+package instagram_api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gorilla/websocket"
+)
+
+type webSocketTransport struct {
+	conn *websocket.Conn
+}
+
+func dialWebSocket(e endpoint) (*webSocketTransport, error) {
+	c, _, err := websocket.DefaultDialer.Dial(e.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("Open WebSocket: Error. No connect to %s. Dial err %w ", e, err)
+	}
+
+	return &webSocketTransport{
+		conn: c,
+	}, nil
+}
+
+// write Конкурентные вызовы недопустимы, сериализуются сервисом
+func (t *webSocketTransport) write(ctx context.Context, payload []byte, stream bool) error {
+	return t.conn.WriteMessage(websocket.TextMessage, payload)
+}
+
+func (t *webSocketTransport) read() ([]byte, error) {
+	_, message, err := t.conn.ReadMessage()
+	return message, err
+}
+
+func (t *webSocketTransport) close() error {
+	return t.conn.Close()
+}
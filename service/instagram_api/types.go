@@ -1,6 +1,7 @@
 package instagram_api
 
 import (
+	"fmt"
 	"strconv"
 
 	"channels-instagram-dm/domain/model/instagram"
@@ -12,7 +13,7 @@ type InboxResponse struct {
 	Viewer               types.User `json:"viewer"`
 	SeqID                string     `json:"seq_id"`
 	SnapshotAtMs         string     `json:"snapshot_at_ms"`
-	PendingRequestsTotal int        `json:"pending_requests_total"` // TODO: Вот этот параметр можно смотреть, чтобы пендить только когда надо
+	PendingRequestsTotal int        `json:"pending_requests_total"`
 	Status               string     `json:"status"`
 }
 
@@ -46,19 +47,16 @@ type Thread struct {
 	// IsGroup              bool         `json:"is_group"`
 	// BusinessThreadFolder uint         `json:"business_thread_folder"`
 	// ReadState            uint         `json:"read_state"`
-	IsVerified   bool         `json:"is_verified_thread"`
-	HasOlder     bool         `json:"has_older"`
-	HasNewer     bool         `json:"has_newer"`
-	NewestCursor string       `json:"newest_cursor"`
-	OldestCursor string       `json:"oldest_cursor"`
-	NextCursor   string       `json:"next_cursor"`
-	PrevCursor   string       `json:"prev_cursor"`
-	Inviter      types.User   `json:"inviter"`
-	Users        []types.User `json:"users"`
-	// LastSeenAt           map[string]struct {
-	// 	Timestamp string `json:"timestamp"`
-	// 	ItemID    string `json:"item_id"`
-	// } `json:"last_seen_at"`
+	IsVerified        bool                `json:"is_verified_thread"`
+	HasOlder          bool                `json:"has_older"`
+	HasNewer          bool                `json:"has_newer"`
+	NewestCursor      string              `json:"newest_cursor"`
+	OldestCursor      string              `json:"oldest_cursor"`
+	NextCursor        string              `json:"next_cursor"`
+	PrevCursor        string              `json:"prev_cursor"`
+	Inviter           types.User          `json:"inviter"`
+	Users             []types.User        `json:"users"`
+	LastSeenAt        map[string]LastSeen `json:"last_seen_at"`
 	LastPermanentItem struct {
 		ItemID        interface{} `json:"item_id"`
 		UserID        interface{} `json:"user_id"`
@@ -70,10 +68,23 @@ type Thread struct {
 	UqSeqId int `json:"uq_seq_id"`
 }
 
+const (
+	RealtimeOpAdd     = "add"
+	RealtimeOpReplace = "replace"
+	RealtimeOpRemove  = "remove"
+)
+
+type LastSeen struct {
+	Timestamp string `json:"timestamp"`
+	ItemID    string `json:"item_id"`
+}
+
 type RealtimeUpdate struct {
-	ThreadID     string     `json:"thread_id"`
-	ThreadItemID string     `json:"thread_item_id"`
-	ThreadItem   ThreadItem `json:"thread_item"`
+	ThreadID     string          `json:"thread_id"`
+	ThreadItemID string          `json:"thread_item_id"`
+	ThreadItem   ThreadItem      `json:"thread_item"`
+	Op           string          `json:"op"`
+	Reaction     *types.Reaction `json:"reaction"` // Реакция на сообщение thread_item_id, thread_item при этом пуст
 }
 
 type ThreadItem struct {
@@ -95,6 +106,7 @@ type ThreadItem struct {
 	ReelShare     types.ReelShare     `json:"reel_share"`
 	Clip          types.Clip          `json:"clip"`
 	Profile       types.Profile       `json:"profile"`
+	Reactions     *types.Reactions    `json:"reactions"` // Отсутствует в realtime, реакции приходят отдельными обновлениями
 }
 
 type LoginRequired struct {
@@ -200,6 +212,19 @@ func (t Thread) toModel() (instagram.ThreadWithItems, error) {
 		threadModel.LastPermanentItem.Timestamp = timestamp
 	}
 
+	threadModel.Thread.LastSeenAt = make(map[string]instagram.LastSeen, len(t.LastSeenAt))
+	for userID, seen := range t.LastSeenAt {
+		timestamp, err := strconv.ParseInt(seen.Timestamp, 10, 64)
+		if err != nil {
+			return instagram.ThreadWithItems{}, fmt.Errorf("Last seen of user [%s]. %w", userID, err)
+		}
+
+		threadModel.Thread.LastSeenAt[userID] = instagram.LastSeen{
+			ItemID:    seen.ItemID,
+			Timestamp: timestamp,
+		}
+	}
+
 	for _, item := range t.Items {
 		itemModel, err := item.toModel()
 		if err != nil {
@@ -356,6 +381,14 @@ func (t ThreadItem) toModel() (instagram.ThreadItem, error) {
 		threadModel.Type = instagram.MessageTypeUndefined
 	}
 
+	if t.Reactions != nil {
+		reactions, err := t.Reactions.ToModel()
+		if err != nil {
+			return instagram.ThreadItem{}, err
+		}
+		threadModel.Reactions = reactions
+	}
+
 	if err := threadModel.Validate(); err != nil {
 		return instagram.ThreadItem{}, err
 	}
@@ -393,6 +426,25 @@ func (u RealtimeUpdate) toModel() (instagram.RealtimeUpdate, error) {
 		ThreadItemID: u.ThreadItemID,
 	}
 
+	if u.Reaction != nil {
+		reaction, err := u.Reaction.ToModel()
+		if err != nil {
+			return instagram.RealtimeUpdate{}, err
+		}
+
+		if u.ThreadItemID == "" {
+			return instagram.RealtimeUpdate{}, fmt.Errorf("Reaction ThreadItemID should not be empty")
+		}
+
+		rtModel.Reaction = &instagram.ReactionUpdate{
+			ItemID:   u.ThreadItemID,
+			Reaction: reaction,
+			Removed:  u.Op == RealtimeOpRemove,
+		}
+
+		return rtModel, nil
+	}
+
 	itemModel, err := u.ThreadItem.toModel()
 	if err != nil {
 		return instagram.RealtimeUpdate{}, err
@@ -0,0 +1,71 @@
+package types
+
+import (
+	"channels-instagram-dm/domain/model/instagram"
+)
+
+// Reactions Реакции на сообщение: лайки старого формата и эмодзи-реакции
+type Reactions struct {
+	Likes []struct {
+		SenderID  interface{} `json:"sender_id"`
+		Timestamp int64       `json:"timestamp"`
+	} `json:"likes"`
+	Emojis []Reaction `json:"emojis"`
+}
+
+type Reaction struct {
+	SenderID  interface{} `json:"sender_id"`
+	Timestamp int64       `json:"timestamp"`
+	Emoji     string      `json:"emoji"`
+}
+
+// ToModel У пользователя может быть только одна реакция на сообщение, эмодзи-реакция заменяет лайк
+func (m Reactions) ToModel() ([]instagram.Reaction, error) {
+	reactions := make([]instagram.Reaction, 0, len(m.Likes)+len(m.Emojis))
+	index := make(map[string]int)
+
+	for _, like := range m.Likes {
+		reaction, err := Reaction{SenderID: like.SenderID, Timestamp: like.Timestamp, Emoji: instagram.ReactionLike}.ToModel()
+		if err != nil {
+			return nil, err
+		}
+
+		index[reaction.UserID] = len(reactions)
+		reactions = append(reactions, reaction)
+	}
+
+	for _, emoji := range m.Emojis {
+		reaction, err := emoji.ToModel()
+		if err != nil {
+			return nil, err
+		}
+
+		if i, ok := index[reaction.UserID]; ok {
+			reactions[i] = reaction
+			continue
+		}
+
+		index[reaction.UserID] = len(reactions)
+		reactions = append(reactions, reaction)
+	}
+
+	return reactions, nil
+}
+
+func (m Reaction) ToModel() (instagram.Reaction, error) {
+	model := instagram.Reaction{
+		UserID:    ValueToString(m.SenderID),
+		Emoji:     m.Emoji,
+		Timestamp: m.Timestamp,
+	}
+
+	if model.Emoji == "" {
+		model.Emoji = instagram.ReactionLike
+	}
+
+	if err := model.Validate(); err != nil {
+		return instagram.Reaction{}, err
+	}
+
+	return model, nil
+}
@@ -10,6 +10,8 @@ func ValueToString(value interface{}) string {
 		return strconv.FormatInt(v, 10)
 	case int:
 		return strconv.Itoa(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
 	default:
 		return ""
 	}
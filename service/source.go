@@ -0,0 +1,175 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"channels-instagram-dm/domain"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	slotsFormatList = "list" // Хосты, разделенные \n
+	slotsFormatJSON = "json"
+	slotsFormatYAML = "yaml"
+)
+
+// slotSource Описание слота в структурированном источнике
+type slotSource struct {
+	Host        string   `json:"host" yaml:"host"`
+	Weight      int      `json:"weight" yaml:"weight"`
+	Tags        []string `json:"tags" yaml:"tags"`
+	MaxAccounts int      `json:"max_accounts" yaml:"max_accounts"`
+	Disabled    bool     `json:"disabled" yaml:"disabled"`
+	Transport   string   `json:"transport" yaml:"transport"`
+}
+
+// slotsDocument Структурированный источник допускает как объект со списком slots, так и сам список
+type slotsDocument struct {
+	Slots []slotSource `json:"slots" yaml:"slots"`
+}
+
+// parseSlots Разбирает источник слотов, формат определяется по расширению, Content-Type или содержимому
+func parseSlots(name, contentType string, bs []byte) ([]domain.Slot, error) {
+	var sources []slotSource
+	var err error
+
+	switch detectSlotsFormat(name, contentType, bs) {
+	case slotsFormatJSON:
+		sources, err = unmarshalSlots(bs, json.Unmarshal)
+	case slotsFormatYAML:
+		sources, err = unmarshalSlots(bs, yaml.Unmarshal)
+	default:
+		sources = parseSlotsList(bs)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	slots := make([]domain.Slot, 0, len(sources))
+	hosts := make(map[string]struct{}, len(sources))
+
+	for _, source := range sources {
+		source.Host = strings.TrimSpace(source.Host)
+
+		if source.Host == "" {
+			return nil, fmt.Errorf("Slot host should not be empty")
+		}
+
+		// Повторное описание хоста игнорируется, как и в списке хостов
+		if _, ok := hosts[source.Host]; ok {
+			continue
+		}
+
+		if source.Weight < 0 {
+			return nil, fmt.Errorf("Slot %s weight should not be negative", source.Host)
+		}
+
+		if source.MaxAccounts < 0 {
+			return nil, fmt.Errorf("Slot %s max accounts should not be negative", source.Host)
+		}
+
+		switch source.Transport {
+		case "", "ws", "wss", "http", "https":
+		default:
+			return nil, fmt.Errorf("Slot %s transport [%s] is unsupported", source.Host, source.Transport)
+		}
+
+		if source.Weight == 0 {
+			source.Weight = 1
+		}
+
+		hosts[source.Host] = struct{}{}
+
+		slots = append(slots, domain.Slot{
+			Host:   source.Host,
+			Status: domain.SlotStatusFree,
+			Attributes: domain.SlotAttributes{
+				Weight:      source.Weight,
+				Tags:        source.Tags,
+				MaxAccounts: source.MaxAccounts,
+				Disabled:    source.Disabled,
+				Transport:   source.Transport,
+			},
+		})
+	}
+
+	return slots, nil
+}
+
+func detectSlotsFormat(name, contentType string, bs []byte) string {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".json":
+		return slotsFormatJSON
+	case ".yaml", ".yml":
+		return slotsFormatYAML
+	}
+
+	switch {
+	case strings.Contains(contentType, "json"):
+		return slotsFormatJSON
+	case strings.Contains(contentType, "yaml"):
+		return slotsFormatYAML
+	}
+
+	data := strings.TrimSpace(string(bs))
+
+	if strings.HasPrefix(data, "{") || strings.HasPrefix(data, "[") {
+		return slotsFormatJSON
+	}
+
+	// Строки списка хостов не содержат пробелов, поэтому "slots:" и "- host:" однозначно указывают на YAML
+	for _, row := range strings.Split(data, "\n") {
+		row = strings.TrimSpace(row)
+
+		if row == "" || strings.HasPrefix(row, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(row, "slots:") || strings.HasPrefix(row, "- ") || row == "---" {
+			return slotsFormatYAML
+		}
+
+		break
+	}
+
+	return slotsFormatList
+}
+
+func unmarshalSlots(bs []byte, unmarshal func([]byte, interface{}) error) ([]slotSource, error) {
+	document := slotsDocument{}
+	if err := unmarshal(bs, &document); err == nil {
+		return document.Slots, nil
+	}
+
+	var list []slotSource
+	if err := unmarshal(bs, &list); err != nil {
+		return nil, fmt.Errorf("Slots source is invalid. %s", err)
+	}
+
+	return list, nil
+}
+
+func parseSlotsList(bs []byte) []slotSource {
+	rows := strings.Split(string(bs), "\n")
+
+	sources := make([]slotSource, 0, len(rows))
+
+	for _, row := range rows {
+		row = strings.TrimSpace(row)
+
+		if row == "" {
+			continue
+		}
+
+		sources = append(sources, slotSource{
+			Host: row,
+		})
+	}
+
+	return sources
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"channels-instagram-dm/domain"
+)
+
+func TestParseSlots(t *testing.T) {
+	tests := []struct {
+		name        string
+		file        string
+		contentType string
+		data        string
+		want        []domain.SlotAttributes
+		hosts       []string
+	}{
+		{
+			name:  "list",
+			file:  "instagram.slots",
+			data:  "instagram_8125:8125\n\n  instagram_8126:8126  \ninstagram_8125:8125\n",
+			hosts: []string{"instagram_8125:8125", "instagram_8126:8126"},
+			want:  []domain.SlotAttributes{{Weight: 1}, {Weight: 1}},
+		},
+		{
+			name:  "json by extension",
+			file:  "slots.json",
+			data:  `{"slots": [{"host": "a:1", "weight": 3, "tags": ["proxy-1"], "max_accounts": 2, "transport": "https"}]}`,
+			hosts: []string{"a:1"},
+			want:  []domain.SlotAttributes{{Weight: 3, Tags: []string{"proxy-1"}, MaxAccounts: 2, Transport: "https"}},
+		},
+		{
+			name:        "json list by content type",
+			file:        "/slots",
+			contentType: "application/json; charset=utf-8",
+			data:        `[{"host": "a:1"}, {"host": "b:1", "disabled": true}]`,
+			hosts:       []string{"a:1", "b:1"},
+			want:        []domain.SlotAttributes{{Weight: 1}, {Weight: 1, Disabled: true}},
+		},
+		{
+			name:  "json by content",
+			file:  "/slots",
+			data:  ` [{"host": "a:1", "transport": "ws"}]`,
+			hosts: []string{"a:1"},
+			want:  []domain.SlotAttributes{{Weight: 1, Transport: "ws"}},
+		},
+		{
+			name:  "yaml by extension",
+			file:  "slots.yml",
+			data:  "slots:\n  - host: a:1\n    weight: 2\n    tags: [proxy-1, eu]\n",
+			hosts: []string{"a:1"},
+			want:  []domain.SlotAttributes{{Weight: 2, Tags: []string{"proxy-1", "eu"}}},
+		},
+		{
+			name:  "yaml list by content",
+			file:  "/slots",
+			data:  "# slots\n- host: a:1\n  max_accounts: 1\n- host: b:1\n",
+			hosts: []string{"a:1", "b:1"},
+			want:  []domain.SlotAttributes{{Weight: 1, MaxAccounts: 1}, {Weight: 1}},
+		},
+	}
+
+	for _, test := range tests {
+		slots, err := parseSlots(test.file, test.contentType, []byte(test.data))
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+
+		if len(slots) != len(test.hosts) {
+			t.Errorf("%s: want %d slots, got %d", test.name, len(test.hosts), len(slots))
+			continue
+		}
+
+		for i, slot := range slots {
+			if slot.Host != test.hosts[i] || slot.Status != domain.SlotStatusFree {
+				t.Errorf("%s: slot %d want free %s, got %s %s", test.name, i, test.hosts[i], slot.Status, slot.Host)
+			}
+
+			if !reflect.DeepEqual(slot.Attributes, test.want[i]) {
+				t.Errorf("%s: slot %s want %+v, got %+v", test.name, slot.Host, test.want[i], slot.Attributes)
+			}
+		}
+	}
+}
+
+func TestParseSlotsInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		data string
+	}{
+		{"empty host", "slots.json", `[{"host": " "}]`},
+		{"negative weight", "slots.json", `[{"host": "a:1", "weight": -1}]`},
+		{"negative max accounts", "slots.yaml", "- host: a:1\n  max_accounts: -2\n"},
+		{"unsupported transport", "slots.json", `[{"host": "a:1", "transport": "grpc"}]`},
+		{"malformed", "slots.json", `{"slots": "a:1"`},
+	}
+
+	for _, test := range tests {
+		if _, err := parseSlots(test.file, "", []byte(test.data)); err == nil {
+			t.Errorf("%s: want error", test.name)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"channels-instagram-dm/domain"
+)
+
+type statusRegistry struct {
+	statuses map[string]*domain.SyncStatus
+	mux      *sync.RWMutex
+}
+
+func StatusRegistry() domain.StatusRegistry {
+	return &statusRegistry{
+		statuses: make(map[string]*domain.SyncStatus),
+		mux:      &sync.RWMutex{},
+	}
+}
+
+func (r *statusRegistry) Register(accountID string) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	r.statuses[accountID] = &domain.SyncStatus{
+		StartedAt: time.Now(),
+	}
+}
+
+func (r *statusRegistry) Unregister(accountID string) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	delete(r.statuses, accountID)
+}
+
+// Update Изменения незарегистрированного аккаунта игнорируются
+func (r *statusRegistry) Update(accountID string, f func(status *domain.SyncStatus)) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if status, ok := r.statuses[accountID]; ok {
+		f(status)
+	}
+}
+
+func (r *statusRegistry) Get(accountID string) (domain.SyncStatus, bool) {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+
+	status, ok := r.statuses[accountID]
+	if !ok {
+		return domain.SyncStatus{}, false
+	}
+
+	return *status, true
+}
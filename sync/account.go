@@ -12,10 +12,16 @@ import (
 	"channels-instagram-dm/domain/model"
 	"channels-instagram-dm/domain/model/instagram"
 	sync_instagram "channels-instagram-dm/sync/instagram"
+	sync_outbound_message "channels-instagram-dm/sync/outbound_message"
+	sync_transfer_message "channels-instagram-dm/sync/transfer_message"
 	sync_undelivered_message "channels-instagram-dm/sync/undelivered_message"
 	utility_clean_activity_log "channels-instagram-dm/sync/utility"
 )
 
+const (
+	LoginBackoffAttempts = 5 // Попытки входа при запуске, пока Instagram просит подождать
+)
+
 // Внимание: Остановка происходит через прерывание контекста
 func startAccount(runtimeContext domain.RuntimeContext, account model.Account) chan struct{} {
 	done := make(chan struct{}, 1)
@@ -25,11 +31,23 @@ func startAccount(runtimeContext domain.RuntimeContext, account model.Account) c
 			done <- struct{}{}
 		}()
 
-		if err := tryLogin(runtimeContext, account); err != nil {
+		// Статус доступен, пока работают горутины аккаунта
+		runtimeContext.Status().Register(account.ID)
+
+		defer func() {
+			runtimeContext.Status().Unregister(account.ID)
+		}()
+
+		if err := loginAccount(runtimeContext, account); err != nil {
+			// Аккаунт остановлен во время паузы перед повтором, причину остановки выставил вызвавший
+			if runtimeContext.Context().Err() != nil {
+				return
+			}
+
 			runtimeContext.Logger().Error(fmt.Sprintf("Failed to IG Login. %s", err), nil)
 
 			runtimeContext.EventBus().PublishSuspendAccount(domain.EventSuspendAccount{
-				Reason:  model.AccountStateReasonNoLoggedIn,
+				Reason:  loginFailureReason(err),
 				Account: account,
 			})
 
@@ -64,7 +82,7 @@ func startAccount(runtimeContext domain.RuntimeContext, account model.Account) c
 		}()
 
 		producer := runtimeContext.MQ().Producer()
-		consumer := runtimeContext.MQ().Consumer()
+		consumer := runtimeContext.MQ().Consumer(account.ExternalID)
 
 		if producer == nil {
 			launch = errors.New("Producer is nil")
@@ -87,11 +105,26 @@ func startAccount(runtimeContext domain.RuntimeContext, account model.Account) c
 			return
 		}
 
+		if err := sync_instagram.ListenBackfill(runtimeContext.WithLogger(runtimeContext.Logger().Copy("BACKFILL")), wg, account); err != nil {
+			launch = fmt.Errorf("Unable to start backfill. %s ", err)
+			return
+		}
+
 		if err := sync_undelivered_message.Listen(runtimeContext.WithLogger(runtimeContext.Logger().Copy("UNDELIVERED")), wg, chTransfer, account); err != nil {
 			launch = fmt.Errorf("Unable to start sync undelivered messages. %s ", err)
 			return
 		}
 
+		if err := sync_transfer_message.Listen(runtimeContext.WithLogger(runtimeContext.Logger().Copy("TRANSFER")), wg, chTransfer, producer, account); err != nil {
+			launch = fmt.Errorf("Unable to start transfer messages. %s ", err)
+			return
+		}
+
+		if err := sync_outbound_message.Listen(runtimeContext.WithLogger(runtimeContext.Logger().Copy("OUTBOUND")), consumer, account); err != nil {
+			launch = fmt.Errorf("Unable to start outbound messages. %s ", err)
+			return
+		}
+
 		if err := utility_clean_activity_log.Listen(runtimeContext.WithLogger(runtimeContext.Logger().Copy("CLEAN")), wg, account); err != nil {
 			launch = fmt.Errorf("Unable to start utility clean_activity_log. %s ", err)
 			return
@@ -109,9 +142,12 @@ func startAccount(runtimeContext domain.RuntimeContext, account model.Account) c
 			return false
 		})
 
+		defer runtimeContext.EventBus().Unsubscribe(chSubscribeOnLoginAccount)
+
 		// Пытаемся переавторизоваться каждые X
 		tickerDefaultDuration := 8 * time.Hour
 		tryLoginAttempts := 0
+		tryLoginReason := model.AccountStateReasonPermanentError
 
 		ticker := time.NewTicker(tickerDefaultDuration)
 
@@ -133,7 +169,7 @@ func startAccount(runtimeContext domain.RuntimeContext, account model.Account) c
 
 				if tryLoginAttempts == 5 {
 					runtimeContext.EventBus().PublishSuspendAccount(domain.EventSuspendAccount{
-						Reason:  model.AccountStateReasonPermanentError,
+						Reason:  tryLoginReason,
 						Account: account,
 					})
 
@@ -148,9 +184,12 @@ func startAccount(runtimeContext domain.RuntimeContext, account model.Account) c
 					continue
 				}
 
-				if errors.Is(err, domain.ErrorNoLoggedIn) || errors.Is(err, domain.ErrorInvalidCredentials) {
+				policy := domain.NewErrorPolicy(err)
+
+				// Вход не удался из-за сессии, учетных данных, challenge или блокировки - повторы не помогут
+				if policy.Action == domain.ErrorActionRelogin || policy.Action == domain.ErrorActionSuspend {
 					runtimeContext.EventBus().PublishSuspendAccount(domain.EventSuspendAccount{
-						Reason:  model.AccountStateReasonNoLoggedIn,
+						Reason:  policy.Reason,
 						Account: account,
 					})
 
@@ -158,6 +197,13 @@ func startAccount(runtimeContext domain.RuntimeContext, account model.Account) c
 				}
 
 				tryLoginAttempts++
+				tryLoginReason = policy.Reason
+
+				if policy.Action == domain.ErrorActionBackoff {
+					ticker.Reset(policy.RetryAfter)
+					continue
+				}
+
 				ticker.Reset(5 * time.Minute)
 			}
 		}
@@ -173,6 +219,45 @@ func stopAccount(runtimeContext domain.RuntimeContext, account model.Account, re
 	})
 }
 
+// loginAccount Авторизует аккаунт при запуске
+// Ограничение запросов или сбой прокси не останавливают аккаунт сразу: вход повторяется после паузы из подсказки
+func loginAccount(runtimeContext domain.RuntimeContext, account model.Account) error {
+	for attempt := 1; ; attempt++ {
+		err := tryLogin(runtimeContext, account)
+		if err == nil {
+			return nil
+		}
+
+		policy := domain.NewErrorPolicy(err)
+		if policy.Action != domain.ErrorActionBackoff || attempt == LoginBackoffAttempts {
+			return err
+		}
+
+		runtimeContext.Logger().Error(fmt.Sprintf("Failed to IG Login, retry after %s. %s", policy.RetryAfter, err), nil)
+
+		timer := time.NewTimer(policy.RetryAfter)
+
+		select {
+		case <-runtimeContext.Context().Done():
+			timer.Stop()
+			return runtimeContext.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// loginFailureReason Причина остановки аккаунта, который не удалось авторизовать при запуске
+func loginFailureReason(err error) string {
+	policy := domain.NewErrorPolicy(err)
+
+	switch policy.Action {
+	case domain.ErrorActionSuspend, domain.ErrorActionBackoff:
+		return policy.Reason
+	default:
+		return model.AccountStateReasonNoLoggedIn
+	}
+}
+
 func tryLogin(runtimeContext domain.RuntimeContext, account model.Account) error {
 	resp, err := login.Run(runtimeContext, login.Request{
 		AutoLogin: true,
@@ -0,0 +1,356 @@
+package instagram
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/model"
+	"channels-instagram-dm/domain/model/instagram"
+)
+
+const (
+	BackfillPageDelay     = 10 * time.Second // Пауза между запросами истории, чтобы не конкурировать с основной синхронизацией
+	BackfillInboxPagesMax = 100              // Ограничение глубины обхода inbox при загрузке всех бесед аккаунта
+)
+
+var errBackfillCancelled = errors.New("Backfill was cancelled")
+
+// ListenBackfill Выполняет загрузки истории аккаунта последовательно, по одной за раз
+// Незавершенные загрузки продолжаются после перезапуска аккаунта
+func ListenBackfill(runtimeContext domain.RuntimeContext, wg *sync.WaitGroup, account model.Account) error {
+	chStartBackfill := runtimeContext.EventBus().SubscribeOnStartBackfill(func(event interface{}) bool {
+		if event, ok := event.(domain.EventStartBackfill); ok {
+			return event.Account.ID == account.ID
+		}
+
+		return false
+	})
+
+	wg.Add(1)
+
+	go func() {
+		defer func() {
+			runtimeContext.EventBus().Unsubscribe(chStartBackfill)
+			wg.Done()
+		}()
+
+		for {
+			handleBackfills(runtimeContext, account)
+
+			select {
+			case <-runtimeContext.Context().Done():
+				runtimeContext.Logger().Debug("Context was closed", nil)
+				return
+			case <-chStartBackfill:
+				runtimeContext.Logger().Debug("Event SubscribeOnStartBackfill", nil)
+			}
+		}
+	}()
+
+	return nil
+}
+
+func handleBackfills(runtimeContext domain.RuntimeContext, account model.Account) {
+	backfills, err := runtimeContext.Repository().BackfillRepository().
+		WhereAccountIDStatus(account.ID, model.BackfillStatusQueued, model.BackfillStatusRunning)
+	if err != nil {
+		runtimeContext.Logger().Error(fmt.Sprintf("Failed to get backfills. %s", err), nil)
+		return
+	}
+
+	for _, backfill := range backfills {
+		if runtimeContext.Context().Err() != nil {
+			return
+		}
+
+		if err := handleBackfill(runtimeContext, account, backfill); err != nil {
+			runtimeContext.Logger().Error(fmt.Sprintf("Failed to handle backfill [%s]. %s", backfill.ID, err), nil)
+		}
+	}
+}
+
+func handleBackfill(runtimeContext domain.RuntimeContext, account model.Account, backfill model.Backfill) error {
+	backfillRepository := runtimeContext.Repository().BackfillRepository()
+
+	// Загрузка могла быть отменена, пока выполнялись предыдущие
+	backfill, err := backfillRepository.WhereID(backfill.ID)
+	if err != nil {
+		return err
+	}
+
+	if backfill.IsFinished() {
+		return nil
+	}
+
+	runtimeContext.Logger().Info(fmt.Sprintf("Backfill [%s] started", backfill.ID), nil)
+
+	backfill.SetStatus(model.BackfillStatusRunning)
+
+	backfill, err = backfillRepository.Store(backfill)
+	if err != nil {
+		return err
+	}
+
+	err = runBackfill(runtimeContext, account, &backfill)
+
+	switch {
+	case err == nil:
+		backfill.SetStatus(model.BackfillStatusDone)
+	case errors.Is(err, errBackfillCancelled):
+		backfill.SetStatus(model.BackfillStatusCancelled)
+	case runtimeContext.Context().Err() != nil:
+		// Аккаунт остановлен, загрузка будет продолжена при следующем запуске
+		return nil
+	default:
+		domain.ApplyErrorPolicy(runtimeContext, account, err)
+
+		backfill.Fail(err)
+	}
+
+	// Отмена могла прийти во время загрузки, перечитываем статус, чтобы не затереть ее
+	current, errStore := backfillRepository.WhereID(backfill.ID)
+	if errStore != nil {
+		return errStore
+	}
+
+	if current.Status == model.BackfillStatusCancelled {
+		backfill.Error = ""
+		backfill.SetStatus(model.BackfillStatusCancelled)
+	}
+
+	if _, errStore := backfillRepository.Store(backfill); errStore != nil {
+		return errStore
+	}
+
+	runtimeContext.Logger().Info(fmt.Sprintf("Backfill [%s] finished with status [%s]", backfill.ID, backfill.Status), nil)
+
+	return err
+}
+
+func runBackfill(runtimeContext domain.RuntimeContext, account model.Account, backfill *model.Backfill) error {
+	instagramAPI, err := runtimeContext.Service().InstagramAPI(account.Username)
+	if err != nil {
+		return err
+	}
+
+	threadIDs, err := collectBackfillThreads(runtimeContext, instagramAPI, *backfill)
+	if err != nil {
+		return err
+	}
+
+	// При продолжении после перезапуска аккаунта прогресс сохраняется, загруженные треды пропускаются
+	progress := backfill.Progress
+	progress.ConversationsTotal = len(progress.ThreadsDone)
+
+	for _, threadID := range threadIDs {
+		if !progress.IsThreadDone(threadID) {
+			progress.ConversationsTotal++
+		}
+	}
+
+	for _, threadID := range threadIDs {
+		if progress.IsThreadDone(threadID) {
+			continue
+		}
+
+		if err := backfillThread(runtimeContext, instagramAPI, account, backfill, &progress, threadID); err != nil {
+			return fmt.Errorf("Failed to backfill thread [%s]. %w", threadID, err)
+		}
+
+		progress.SetThreadDone(threadID)
+
+		if err := storeBackfillProgress(runtimeContext, backfill, progress); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// collectBackfillThreads Возвращает треды для загрузки: тред указанной беседы или все треды inbox аккаунта
+func collectBackfillThreads(runtimeContext domain.RuntimeContext, instagramAPI domain.InstagramAPI, backfill model.Backfill) ([]string, error) {
+	if backfill.ConversationID != "" {
+		conversation, err := runtimeContext.Repository().ConversationRepository().WhereID(backfill.ConversationID)
+		if err != nil {
+			return nil, err
+		}
+
+		return []string{conversation.Attributes.ThreadAttributes.ID}, nil
+	}
+
+	threadIDs := make([]string, 0)
+	cursor := ""
+
+	for page := 0; page < BackfillInboxPagesMax; page++ {
+		if page > 0 {
+			if err := waitBackfill(runtimeContext, backfill.ID); err != nil {
+				return nil, err
+			}
+		}
+
+		inbox, err := instagramAPI.DirectInbox(cursor, InboxPageLimit)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, thread := range inbox.Threads {
+			threadIDs = append(threadIDs, thread.ID)
+		}
+
+		if !inbox.HasOlder || inbox.OldestCursor == "" {
+			break
+		}
+
+		cursor = inbox.OldestCursor
+	}
+
+	return threadIDs, nil
+}
+
+// backfillThread Обходит историю треда от новых сообщений к старым до ограничения по глубине или дате
+// Курсор последней синхронизации беседы не изменяется, поэтому загрузка не влияет на основную синхронизацию
+func backfillThread(runtimeContext domain.RuntimeContext, instagramAPI domain.InstagramAPI, account model.Account, backfill *model.Backfill, progress *model.BackfillProgress, threadID string) error {
+	conversation := model.Conversation{}
+	cursor := ""
+	count := 0
+
+	for {
+		if err := waitBackfill(runtimeContext, backfill.ID); err != nil {
+			return err
+		}
+
+		page, err := instagramAPI.DirectThread(threadID, cursor)
+		if err != nil {
+			return err
+		}
+
+		if conversation.ID == "" {
+			conversation, err = takeConversation(runtimeContext, account, page)
+			if err != nil {
+				return err
+			}
+		}
+
+		reached := false
+
+		for _, item := range page.Items {
+			if backfill.Until > 0 && item.Timestamp < backfill.Until {
+				reached = true
+				continue
+			}
+
+			if backfill.Depth > 0 && count >= backfill.Depth {
+				reached = true
+				break
+			}
+
+			count++
+
+			created, err := storeBackfillItem(runtimeContext, account, conversation, item, backfill.Deliver)
+			if err != nil {
+				return fmt.Errorf("Failed to store thread item [%s]. %w", item.ID, err)
+			}
+
+			if created {
+				progress.Messages++
+			}
+		}
+
+		if err := storeBackfillProgress(runtimeContext, backfill, *progress); err != nil {
+			return err
+		}
+
+		if reached || !page.HasOlder || page.OldestCursor == "" {
+			return nil
+		}
+
+		cursor = page.OldestCursor
+	}
+}
+
+// storeBackfillItem Сохраняет сообщение истории, если оно еще не было сохранено
+// Без флага доставки сообщение сразу помечается доставленным и не попадает в Channels
+func storeBackfillItem(runtimeContext domain.RuntimeContext, account model.Account, conversation model.Conversation, item instagram.ThreadItem, deliver bool) (bool, error) {
+	messageRepository := runtimeContext.Repository().MessageRepository()
+
+	_, err := messageRepository.WhereInstagramAttributeID(item.ID)
+	if err == nil {
+		return false, nil
+	}
+
+	if !errors.Is(err, domain.ErrorNotFound) {
+		return false, err
+	}
+
+	message := newThreadItemMessage(account, conversation, item)
+
+	// Реакции истории сохраняются с сообщением без доставки в Channels
+	for _, reaction := range item.Reactions {
+		message.SetReaction(model.Reaction{
+			UserID:    reaction.UserID,
+			Emoji:     reaction.Emoji,
+			Timestamp: reaction.Timestamp,
+		})
+	}
+
+	if !deliver {
+		message.DeliveredSuccess()
+	}
+
+	if _, err := messageRepository.Store(message); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// storeBackfillProgress Статус перечитывается перед сохранением, чтобы не затереть отмену загрузки
+func storeBackfillProgress(runtimeContext domain.RuntimeContext, backfill *model.Backfill, progress model.BackfillProgress) error {
+	backfillRepository := runtimeContext.Repository().BackfillRepository()
+
+	current, err := backfillRepository.WhereID(backfill.ID)
+	if err != nil {
+		return err
+	}
+
+	if current.Status == model.BackfillStatusCancelled {
+		return errBackfillCancelled
+	}
+
+	current.SetProgress(progress)
+
+	stored, err := backfillRepository.Store(current)
+	if err != nil {
+		return err
+	}
+
+	*backfill = stored
+
+	return nil
+}
+
+// waitBackfill Выдерживает паузу перед очередным запросом и проверяет, не отменена ли загрузка
+func waitBackfill(runtimeContext domain.RuntimeContext, backfillID string) error {
+	timer := time.NewTimer(jitter(BackfillPageDelay))
+	defer timer.Stop()
+
+	select {
+	case <-runtimeContext.Context().Done():
+		return runtimeContext.Context().Err()
+	case <-timer.C:
+	}
+
+	backfill, err := runtimeContext.Repository().BackfillRepository().WhereID(backfillID)
+	if err != nil {
+		return err
+	}
+
+	if backfill.Status == model.BackfillStatusCancelled {
+		return errBackfillCancelled
+	}
+
+	return nil
+}
@@ -1,27 +1,161 @@
 package instagram
 
 import (
-	"errors"
 	"fmt"
+	"time"
 
 	"channels-instagram-dm/domain"
 	"channels-instagram-dm/domain/model"
 )
 
-func handleInbox(runtimeContext domain.RuntimeContext, account model.Account) error {
-	if err := syncInbox(runtimeContext, account); err != nil {
-		if errors.Is(err, domain.ErrorNoLoggedIn) {
-			runtimeContext.EventBus().PublishLoginAccount(domain.EventLoginAccount{
-				Account: account,
-			})
-		}
+const (
+	InboxPageLimit = 20 // Количество тредов на странице inbox
+	InboxPagesMax  = 10 // Ограничение глубины обхода inbox за один раунд
+)
+
+// inboxActivity Сигналы inbox, по которым планировщик подстраивает интервал опроса
+type inboxActivity struct {
+	Changed      bool // Изменился SeqID или количество запросов на переписку
+	UnseenGrowth bool // Выросло количество непрочитанных тредов
+	HasNewer     bool // В inbox есть более новые треды, чем вернулись на первой странице
+}
+
+func (a inboxActivity) isBusy() bool {
+	return a.Changed || a.UnseenGrowth || a.HasNewer
+}
 
-		return fmt.Errorf("%s", err)
+func handleInbox(runtimeContext domain.RuntimeContext, account model.Account) (inboxActivity, error) {
+	activity, err := syncInbox(runtimeContext, account)
+	if err == nil {
+		// Беседы с пропусками после разрывов realtime сверяются, даже если inbox не изменился
+		err = reconcileConversations(runtimeContext, account)
 	}
 
-	return nil
+	if err != nil {
+		domain.ApplyErrorPolicy(runtimeContext, account, err)
+
+		return activity, err
+	}
+
+	return activity, nil
 }
 
-func syncInbox(runtimeContext domain.RuntimeContext, account model.Account) error {
-	return nil
+func syncInbox(runtimeContext domain.RuntimeContext, account model.Account) (inboxActivity, error) {
+	activity := inboxActivity{}
+
+	instagramAPI, err := runtimeContext.Service().InstagramAPI(account.Username)
+	if err != nil {
+		return activity, err
+	}
+
+	accountRepository := runtimeContext.Repository().AccountRepository()
+
+	// Снимок inbox хранится в репозитории, аккаунт в памяти может быть устаревшим
+	account, err = accountRepository.WhereID(account.ID)
+	if err != nil {
+		return activity, err
+	}
+
+	inboxSync := account.InboxSync
+	cursor := ""
+
+	// Обход дошел до треда из прошлого снимка или до конца inbox
+	complete := false
+
+	for page := 0; page < InboxPagesMax; page++ {
+		select {
+		case <-runtimeContext.Context().Done():
+			return activity, runtimeContext.Context().Err()
+		default:
+		}
+
+		inbox, err := instagramAPI.DirectInbox(cursor, InboxPageLimit)
+		if err != nil {
+			return activity, err
+		}
+
+		if page == 0 {
+			// Запросы на переписку не всегда меняют SeqID, поэтому проверяются до сравнения снимков
+			if isPendingSyncRequired(account.InboxSync, inbox.PendingRequestsTotal) {
+				pendingSyncAt := time.Now()
+
+				if err := syncPending(runtimeContext, instagramAPI, account); err != nil {
+					runtimeContext.Logger().Error(fmt.Sprintf("Failed to sync pending. %s", err), nil)
+
+					// Неудачная загрузка повторяется в следующем раунде
+					pendingSyncAt = time.Time{}
+				}
+
+				if err := storePendingSyncAt(runtimeContext, account, pendingSyncAt); err != nil {
+					return activity, err
+				}
+			}
+
+			activity = inboxActivity{
+				Changed:      inbox.SeqID != account.InboxSync.SeqID || inbox.PendingRequestsTotal != account.InboxSync.PendingRequestsTotal,
+				UnseenGrowth: inbox.UnseenCount > account.InboxSync.UnseenCount,
+				HasNewer:     inbox.HasNewer,
+			}
+
+			// SeqID не изменился - в inbox нет изменений с предыдущего раунда
+			if !activity.Changed {
+				runtimeContext.Logger().Debug(fmt.Sprintf("No changes with SeqID [%d]", inbox.SeqID), nil)
+				return activity, nil
+			}
+
+			inboxSync = model.InboxSync{
+				SeqID:                inbox.SeqID,
+				SnapshotAt:           inbox.SnapshotAt,
+				PendingRequestsTotal: inbox.PendingRequestsTotal,
+				UnseenCount:          inbox.UnseenCount,
+			}
+		}
+
+		// Треды отсортированы по последней активности, поэтому обход прекращаем на первом неизмененном треде
+		reached := false
+
+		for _, thread := range inbox.Threads {
+			if thread.LastActivityAt <= account.InboxSync.SnapshotAt {
+				reached = true
+				break
+			}
+
+			if err := syncThread(runtimeContext, instagramAPI, account, thread); err != nil {
+				return activity, fmt.Errorf("Failed to sync thread [%s]. %w", thread.ID, err)
+			}
+		}
+
+		if reached || !inbox.HasOlder || inbox.OldestCursor == "" {
+			complete = true
+			break
+		}
+
+		cursor = inbox.OldestCursor
+	}
+
+	// Обход прерван ограничением глубины: между последним обойденным тредом и прошлым снимком остались
+	// непроверенные треды. Снимок не сдвигается, чтобы следующий раунд обошел inbox снова
+	if !complete {
+		inboxSync.SeqID = account.InboxSync.SeqID
+		inboxSync.SnapshotAt = account.InboxSync.SnapshotAt
+
+		runtimeContext.Logger().Error(fmt.Sprintf("Inbox walk stopped at [%d] pages, snapshot was kept at [%d]", InboxPagesMax, inboxSync.SnapshotAt), nil)
+	}
+
+	account, err = accountRepository.WhereID(account.ID)
+	if err != nil {
+		return activity, err
+	}
+
+	inboxSync.PendingSyncAt = account.InboxSync.PendingSyncAt
+
+	account.SetInboxSync(inboxSync)
+
+	if _, err := accountRepository.Store(account); err != nil {
+		return activity, err
+	}
+
+	runtimeContext.Logger().Debug(fmt.Sprintf("Synced with SeqID [%d] at [%d]", inboxSync.SeqID, inboxSync.SnapshotAt), nil)
+
+	return activity, nil
 }
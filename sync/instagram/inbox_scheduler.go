@@ -8,10 +8,12 @@ import (
 	"time"
 
 	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/model"
 )
 
 const (
 	RoundDurationDefault = 12 * time.Minute // 15
+	RoundDurationMin     = 1 * time.Minute
 	RoundDurationMax     = 3 * time.Hour
 
 	AttemptRoundDurationDefault = 1 * time.Minute
@@ -24,8 +26,11 @@ type scheduler struct {
 	attemptsNumMax              int
 	attemptRoundDurationDefault time.Duration
 	roundDurationDefault        time.Duration
+	roundDurationMin            time.Duration
 	roundDurationMax            time.Duration
 	roundDuration               time.Duration
+	backoff                     time.Duration // Пауза до следующего раунда по подсказке повтора ошибки
+	nextRunAt                   time.Time
 	ctx                         context.Context
 	ticker                      *time.Ticker
 	mux                         *sync.Mutex
@@ -39,6 +44,20 @@ func (s *scheduler) Ticker() *time.Ticker {
 func (s *scheduler) setRound(d time.Duration) {
 	s.logger.Info(fmt.Sprintf("Duration set in [%f] sec, at [%s]", d.Seconds(), time.Now().Add(d).Format(time.RFC3339)), nil)
 	s.ticker.Reset(d)
+	s.nextRunAt = time.Now().Add(d)
+}
+
+// State Снимок состояния планировщика для реестра статусов
+func (s *scheduler) State() domain.SchedulerStatus {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	return domain.SchedulerStatus{
+		RoundDuration: s.roundDuration,
+		IsAttemptMode: s.isAttemptMode,
+		Attempts:      s.attemptsNum,
+		NextRunAt:     s.nextRunAt,
+	}
 }
 
 // Fail Включается режим попытки на 1 раунд
@@ -51,19 +70,62 @@ func (s *scheduler) Fail() *scheduler {
 	return s
 }
 
-func (s *scheduler) Reset() {
+// Backoff Следующий раунд откладывается на паузу из подсказки повтора вместо режима попытки
+func (s *scheduler) Backoff(d time.Duration) *scheduler {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.backoff = d
+
+	return s
+}
+
+// Observe Подстраивает интервал под активность inbox: у активного аккаунта интервал сокращается, у неактивного растет
+func (s *scheduler) Observe(busy bool) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if busy {
+		s.roundDuration = calcBusyDuration(s.roundDuration, s.roundDurationMin)
+		return
+	}
+
+	s.roundDuration = calcRoundDuration(s.roundDuration, s.roundDurationMax)
+}
+
+// Signal Активность вне раунда (realtime): интервал сокращается, а следующий раунд может только приблизиться
+func (s *scheduler) Signal() {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.roundDuration = calcBusyDuration(s.roundDuration, s.roundDurationMin)
+
+	d := jitter(s.roundDuration)
+	if time.Now().Add(d).Before(s.nextRunAt) {
+		s.setRound(d)
+	}
+}
+
+// SetBounds Применяет границы интервала аккаунта, нулевые значения заменяются границами по умолчанию
+func (s *scheduler) SetBounds(bounds model.PollingBounds) {
 	s.mux.Lock()
 	defer s.mux.Unlock()
 
-	s.isAttemptMode = false
-	s.attemptsNum = 0
-	s.roundDuration = s.roundDurationDefault
+	s.roundDurationMin, s.roundDurationMax = calcBounds(bounds)
+	s.roundDuration = clampDuration(s.roundDuration, s.roundDurationMin, s.roundDurationMax)
 }
 
 func (s *scheduler) Next() {
 	s.mux.Lock()
 	defer s.mux.Unlock()
 
+	if s.backoff > 0 {
+		s.setRound(jitter(s.backoff))
+		s.backoff = 0
+
+		return
+	}
+
 	// Если не было ошибок на предыдущем раунде, то сбрасываем счетчик попыток
 	if !s.isAttemptMode {
 		s.attemptsNum = 0
@@ -90,22 +152,23 @@ func (s *scheduler) Next() {
 		}
 	}
 
-	roundDuration := calcRoundDuration(s.roundDuration, s.roundDurationMax)
-	s.roundDuration = roundDuration
-
-	s.setRound(jitter(roundDuration))
+	s.setRound(jitter(s.roundDuration))
 }
 
-func NewScheduler(ctx context.Context, logger domain.Logger) *scheduler {
+func NewScheduler(ctx context.Context, logger domain.Logger, bounds model.PollingBounds) *scheduler {
+	roundDurationMin, roundDurationMax := calcBounds(bounds)
+
 	sch := &scheduler{
 		attemptsNumMax:              AttemptsNumMax,
 		attemptRoundDurationDefault: AttemptRoundDurationDefault,
 		roundDurationDefault:        RoundDurationDefault,
-		roundDurationMax:            RoundDurationMax,
-		roundDuration:               RoundDurationDefault,
+		roundDurationMin:            roundDurationMin,
+		roundDurationMax:            roundDurationMax,
+		roundDuration:               clampDuration(RoundDurationDefault, roundDurationMin, roundDurationMax),
 		mux:                         &sync.Mutex{},
 		logger:                      logger,
 		ticker:                      time.NewTicker(RoundDurationDefault),
+		nextRunAt:                   time.Now().Add(RoundDurationDefault),
 		ctx:                         ctx,
 	}
 
@@ -134,6 +197,51 @@ func calcRoundDuration(timePrev time.Duration, roundDurationMax time.Duration) t
 	return roundDuration
 }
 
+func calcBusyDuration(timePrev time.Duration, roundDurationMin time.Duration) time.Duration {
+	roundDuration := (timePrev / 2).Round(time.Second)
+
+	if roundDuration < roundDurationMin {
+		return roundDurationMin
+	}
+
+	return roundDuration
+}
+
+func calcBounds(bounds model.PollingBounds) (time.Duration, time.Duration) {
+	roundDurationMin, roundDurationMax := bounds.Min, bounds.Max
+
+	if roundDurationMin == 0 {
+		roundDurationMin = RoundDurationMin
+	}
+
+	if roundDurationMax == 0 {
+		roundDurationMax = RoundDurationMax
+	}
+
+	// Задана только одна граница, и она выходит за границу по умолчанию
+	if roundDurationMin > roundDurationMax {
+		if bounds.Min == 0 {
+			roundDurationMin = roundDurationMax
+		} else {
+			roundDurationMax = roundDurationMin
+		}
+	}
+
+	return roundDurationMin, roundDurationMax
+}
+
+func clampDuration(d, min, max time.Duration) time.Duration {
+	if d < min {
+		return min
+	}
+
+	if d > max {
+		return max
+	}
+
+	return d
+}
+
 func calcAttemptDuration(attemptsNum int, attemptRoundDuration time.Duration) time.Duration {
 	newDuration := 1 << attemptsNum * attemptRoundDuration
 	roundDuration := time.Duration(newDuration).Round(time.Second)
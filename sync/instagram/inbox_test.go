@@ -0,0 +1,116 @@
+package instagram
+
+import (
+	"errors"
+	"testing"
+
+	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/model"
+	"channels-instagram-dm/service/fake_slot"
+)
+
+func TestHandleInbox(t *testing.T) {
+	ts := newTestSync(t)
+
+	ts.slot.AddThread("t2", fake_slot.User{ID: "3", Username: "carol"}, false)
+
+	first, err := ts.slot.AddItem("t2", fake_slot.Item{Text: "hey"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	activity, err := handleInbox(ts.runtimeContext, ts.account)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !activity.Changed {
+		t.Fatal("first round: want changed inbox")
+	}
+
+	if text := ts.storedText(first.ID); text != "hey" {
+		t.Fatalf("first round: want stored message, got %q", text)
+	}
+
+	if conversation := ts.conversation(t, "t1"); conversation.Attributes.UserAttributes.Username != "bob" {
+		t.Fatalf("first round: unexpected conversation %+v", conversation.Attributes)
+	}
+
+	next, err := ts.slot.AddItem("t1", fake_slot.Item{Text: "again"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if activity, err = handleInbox(ts.runtimeContext, ts.account); err != nil {
+		t.Fatal(err)
+	}
+
+	if !activity.Changed {
+		t.Fatal("second round: want changed inbox")
+	}
+
+	if text := ts.storedText(next.ID); text != "again" {
+		t.Fatalf("second round: want stored message, got %q", text)
+	}
+
+	conversation := ts.conversation(t, "t1")
+	if conversation.Attributes.ThreadAttributes.LastThreadItemID != next.ID || !isConversationSynced(conversation) {
+		t.Fatalf("second round: want conversation synced to %s, got %+v", next.ID, conversation.Attributes)
+	}
+}
+
+func TestHandleInboxUnchanged(t *testing.T) {
+	ts := newTestSync(t)
+
+	if _, err := handleInbox(ts.runtimeContext, ts.account); err != nil {
+		t.Fatal(err)
+	}
+
+	account, err := ts.runtimeContext.Repository().AccountRepository().WhereID(ts.account.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if account.InboxSync.SeqID == 0 {
+		t.Fatal("first round: want stored inbox snapshot")
+	}
+
+	threadCalls := ts.slot.Calls("direct@thread")
+
+	activity, err := handleInbox(ts.runtimeContext, ts.account)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if activity.isBusy() {
+		t.Fatalf("second round: want idle inbox, got %+v", activity)
+	}
+
+	if calls := ts.slot.Calls("direct@thread"); calls != threadCalls {
+		t.Fatalf("second round: want no thread requests, got %d", calls-threadCalls)
+	}
+}
+
+func TestHandleInboxErrorPolicy(t *testing.T) {
+	ts := newTestSync(t)
+
+	ts.slot.FailNext("direct@inbox", "checkpoint_required", 1)
+
+	if _, err := handleInbox(ts.runtimeContext, ts.account); !errors.Is(err, domain.ErrorCheckpoint) {
+		t.Fatalf("checkpoint: want checkpoint error, got %v", err)
+	}
+
+	if _, _, suspends := ts.eventBus.counts(); len(suspends) != 1 || suspends[0] != model.AccountStateReasonChallenge {
+		t.Fatalf("checkpoint: want suspend with challenge, got %v", suspends)
+	}
+
+	ts.slot.ExpireSession("alice")
+
+	if _, err := handleInbox(ts.runtimeContext, ts.account); !errors.Is(err, domain.ErrorNoLoggedIn) {
+		t.Fatalf("expired session: want no logged in, got %v", err)
+	}
+
+	if _, logins, _ := ts.eventBus.counts(); logins != 1 {
+		t.Fatalf("expired session: want one relogin, got %d", logins)
+	}
+}
@@ -20,25 +20,28 @@ func Listen(runtimeContext domain.RuntimeContext, wg *sync.WaitGroup, account mo
 	})
 
 	chRealtimeUpdates := make(chan instagram.RealtimeUpdate, 0)
+	gap := newRealtimeGap()
 
 	realtimeRuntimeContext := runtimeContext.WithLogger(runtimeContext.Logger().Copy("REALTIME"))
 	inboxRuntimeContext := runtimeContext.WithLogger(runtimeContext.Logger().Copy("INBOX"))
 
-	err := listenRealtime(realtimeRuntimeContext, wg, chRealtimeUpdates, account)
+	err := listenRealtime(realtimeRuntimeContext, wg, chRealtimeUpdates, gap, account)
 	if err != nil {
 		return err
 	}
 
 	// При первом запуске стараемся собрать как можно быстрее
-	inboxScheduler := NewScheduler(runtimeContext.Context(), inboxRuntimeContext.Logger())
+	inboxScheduler := NewScheduler(runtimeContext.Context(), inboxRuntimeContext.Logger(), account.Polling)
 	inboxScheduler.setRound(jitter(50 * time.Second)) // Джиттер нужен, когда перезапускаем сервис
 
+	updateSchedulerStatus(runtimeContext, account, inboxScheduler)
+
 	wg.Add(1)
 
 	go func() {
 		defer func() {
-			close(chInboxHasChanges)
-			close(chRealtimeUpdates)
+			// chRealtimeUpdates не закрывается: в него может писать подписка сервиса слота, которая живет дольше аккаунта
+			runtimeContext.EventBus().Unsubscribe(chInboxHasChanges)
 			wg.Done()
 		}()
 
@@ -51,23 +54,53 @@ func Listen(runtimeContext domain.RuntimeContext, wg *sync.WaitGroup, account mo
 			case <-chInboxHasChanges:
 				inboxRuntimeContext.Logger().Debug(fmt.Sprintf("Event SubscribeOnInboxHasChanges"), nil)
 
-				inboxScheduler.Reset()
+				inboxScheduler.Signal()
 
-				inboxScheduler.Next()
+				updateSchedulerStatus(runtimeContext, account, inboxScheduler)
 
 			case <-inboxScheduler.Ticker().C:
 				inboxRuntimeContext.Logger().Debug("Scheduler Round", nil)
 
-				if err := handleInbox(inboxRuntimeContext, account); err != nil {
+				roundStartedAt := time.Now()
+
+				activity, err := handleInbox(inboxRuntimeContext, account)
+				if err != nil {
 					inboxRuntimeContext.Logger().Error(fmt.Sprintf("Failed to handle. %s", err), nil)
 
-					inboxScheduler.Fail()
+					// Ограничение частоты, прокси и таймауты не устраняются быстрыми повторами
+					if policy := domain.NewErrorPolicy(err); policy.Action == domain.ErrorActionBackoff {
+						inboxScheduler.Backoff(policy.RetryAfter)
+					} else {
+						inboxScheduler.Fail()
+					}
+				} else {
+					runtimeContext.Status().Update(account.ID, func(status *domain.SyncStatus) {
+						status.LastSyncAt = time.Now()
+					})
+
+					// Раунд обновил последние сообщения тредов, пропуски realtime теперь видны как несинхронизированные беседы
+					gap.close(roundStartedAt)
+
+					inboxScheduler.Observe(activity.isBusy())
 				}
 
+				applyPollingBounds(inboxRuntimeContext, account, inboxScheduler)
+
 				inboxScheduler.Next()
 
+				updateSchedulerStatus(runtimeContext, account, inboxScheduler)
+
 			case update := <-chRealtimeUpdates:
-				if err := handleRealtime(realtimeRuntimeContext, account, update); err != nil {
+				runtimeContext.Status().Update(account.ID, func(status *domain.SyncStatus) {
+					status.Realtime.LastUpdateAt = time.Now()
+				})
+
+				// Трафик realtime - признак активного аккаунта
+				runtimeContext.EventBus().PublishInboxHasChanges(domain.EventInboxHasChanges{
+					Account: account,
+				})
+
+				if err := handleRealtime(realtimeRuntimeContext, account, gap, update); err != nil {
 					realtimeRuntimeContext.Logger().Error(fmt.Sprintf("Failed to handle. %s", err), nil)
 				}
 			}
@@ -76,3 +109,22 @@ func Listen(runtimeContext domain.RuntimeContext, wg *sync.WaitGroup, account mo
 
 	return nil
 }
+
+func updateSchedulerStatus(runtimeContext domain.RuntimeContext, account model.Account, inboxScheduler *scheduler) {
+	state := inboxScheduler.State()
+
+	runtimeContext.Status().Update(account.ID, func(status *domain.SyncStatus) {
+		status.Scheduler = state
+	})
+}
+
+// applyPollingBounds Границы интервала могут быть изменены через API, пока аккаунт запущен
+func applyPollingBounds(runtimeContext domain.RuntimeContext, account model.Account, inboxScheduler *scheduler) {
+	account, err := runtimeContext.Repository().AccountRepository().WhereID(account.ID)
+	if err != nil {
+		runtimeContext.Logger().Error(fmt.Sprintf("Failed to get polling bounds. %s", err), nil)
+		return
+	}
+
+	inboxScheduler.SetBounds(account.Polling)
+}
@@ -0,0 +1,211 @@
+package instagram
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"channels-instagram-dm/api"
+	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/model"
+	"channels-instagram-dm/domain/model/instagram"
+	"channels-instagram-dm/repository/fake_repository"
+	"channels-instagram-dm/service"
+	"channels-instagram-dm/service/fake_slot"
+)
+
+const testTimeout = 10 * time.Second
+
+type nopLogger struct{}
+
+func (l nopLogger) Copy(string) domain.Logger    { return l }
+func (l nopLogger) Writer() io.Writer            { return ioutil.Discard }
+func (l nopLogger) Critical(string, interface{}) {}
+func (l nopLogger) Debug(string, interface{})    {}
+func (l nopLogger) Info(string, interface{})     {}
+func (l nopLogger) Error(string, interface{})    {}
+
+// testEventBus Запоминает события, которые публикуют inbox и realtime. Остальные события синхронизация не публикует
+type testEventBus struct {
+	domain.EventBus
+	mux          sync.Mutex
+	inboxChanges int
+	logins       int
+	suspends     []string
+}
+
+func (b *testEventBus) PublishInboxHasChanges(domain.EventInboxHasChanges) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	b.inboxChanges++
+}
+
+func (b *testEventBus) PublishLoginAccount(domain.EventLoginAccount) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	b.logins++
+}
+
+func (b *testEventBus) PublishSuspendAccount(event domain.EventSuspendAccount) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	b.suspends = append(b.suspends, event.Reason)
+}
+
+func (b *testEventBus) counts() (inboxChanges, logins int, suspends []string) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	return b.inboxChanges, b.logins, append([]string(nil), b.suspends...)
+}
+
+type testStatusRegistry struct {
+	mux      sync.Mutex
+	statuses map[string]domain.SyncStatus
+}
+
+func (r *testStatusRegistry) Register(accountID string) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	r.statuses[accountID] = domain.SyncStatus{}
+}
+
+func (r *testStatusRegistry) Unregister(accountID string) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	delete(r.statuses, accountID)
+}
+
+func (r *testStatusRegistry) Update(accountID string, f func(status *domain.SyncStatus)) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if status, ok := r.statuses[accountID]; ok {
+		f(&status)
+		r.statuses[accountID] = status
+	}
+}
+
+func (r *testStatusRegistry) Get(accountID string) (domain.SyncStatus, bool) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	status, ok := r.statuses[accountID]
+
+	return status, ok
+}
+
+type testSync struct {
+	runtimeContext domain.RuntimeContext
+	eventBus       *testEventBus
+	slot           *fake_slot.Server
+	account        model.Account
+}
+
+// newTestSync Аккаунт alice, вошедший на слот fake_slot, с тредом t1 от bob
+func newTestSync(t *testing.T) *testSync {
+	t.Helper()
+
+	slot := fake_slot.NewServer()
+	if err := slot.Start(""); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { _ = slot.Close() })
+
+	slot.AddAccount(fake_slot.Account{Username: "alice", Password: "password", UserID: "1"})
+	slot.AddSession("alice")
+	slot.AddThread("t1", fake_slot.User{ID: "2", Username: "bob"}, false)
+
+	if _, err := slot.AddItem("t1", fake_slot.Item{Text: "hello"}); err != nil {
+		t.Fatal(err)
+	}
+
+	slotsURI := filepath.Join(t.TempDir(), "instagram.slots")
+	if err := ioutil.WriteFile(slotsURI, []byte(slot.Host()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rep := fake_repository.NewRepository()
+
+	account := model.NewAccount("ext-1", "alice")
+	account.State = model.AccountStateActive
+
+	account, err := rep.AccountRepository().Store(account)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	serviceFactory, err := service.Factory(ctx, nopLogger{}, slotsURI, "", rep)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	eventBus := &testEventBus{}
+	status := &testStatusRegistry{statuses: make(map[string]domain.SyncStatus)}
+	status.Register(account.ID)
+
+	runtimeContext := api.RuntimeContext(ctx, rep, serviceFactory, nopLogger{}, nil, eventBus, nil, status)
+
+	instagramAPI, err := serviceFactory.InstagramAPI("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := instagramAPI.Login(instagram.Credentials{Username: "alice", Password: "password"}); err != nil {
+		t.Fatal(err)
+	}
+
+	return &testSync{
+		runtimeContext: runtimeContext,
+		eventBus:       eventBus,
+		slot:           slot,
+		account:        account,
+	}
+}
+
+func (ts *testSync) storedText(itemID string) string {
+	message, err := ts.runtimeContext.Repository().MessageRepository().WhereInstagramAttributeID(itemID)
+	if err != nil {
+		return ""
+	}
+
+	return model.GetChannelsMessage(message).Text
+}
+
+func (ts *testSync) conversation(t *testing.T, threadID string) model.Conversation {
+	t.Helper()
+
+	conversation, err := ts.runtimeContext.Repository().ConversationRepository().WhereAttributeThreadID(threadID)
+	if err != nil {
+		t.Fatalf("conversation %s: %s", threadID, err)
+	}
+
+	return conversation
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(testTimeout)
+
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for %s", what)
+		}
+
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -0,0 +1,85 @@
+package instagram
+
+import (
+	"fmt"
+	"time"
+
+	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/case/resolve_pending"
+	"channels-instagram-dm/domain/model"
+)
+
+const (
+	PendingSyncInterval = 10 * time.Minute // Запросы на переписку без изменения количества перепроверяются не чаще интервала
+)
+
+// isPendingSyncRequired Запросы загружаются, если изменилось их количество или с прошлой загрузки прошел интервал
+func isPendingSyncRequired(inboxSync model.InboxSync, pendingRequestsTotal int) bool {
+	if pendingRequestsTotal == 0 {
+		return false
+	}
+
+	return pendingRequestsTotal != inboxSync.PendingRequestsTotal || time.Since(inboxSync.PendingSyncAt) >= PendingSyncInterval
+}
+
+func storePendingSyncAt(runtimeContext domain.RuntimeContext, account model.Account, pendingSyncAt time.Time) error {
+	accountRepository := runtimeContext.Repository().AccountRepository()
+
+	account, err := accountRepository.WhereID(account.ID)
+	if err != nil {
+		return err
+	}
+
+	account.InboxSync.PendingSyncAt = pendingSyncAt
+
+	_, err = accountRepository.Store(account)
+
+	return err
+}
+
+// syncPending Сохраняет запросы на переписку и принимает их согласно политике аккаунта
+func syncPending(runtimeContext domain.RuntimeContext, instagramAPI domain.InstagramAPI, account model.Account) error {
+	threads, err := instagramAPI.DirectInboxPending("")
+	if err != nil {
+		return err
+	}
+
+	accept := make([]string, 0, len(threads))
+
+	for _, thread := range threads {
+		// Тред из списка запросов всегда ожидает принятия
+		thread.Pending = true
+
+		conversation, err := takeConversation(runtimeContext, account, thread)
+		if err != nil {
+			return fmt.Errorf("Failed to take pending thread [%s]. %w", thread.ID, err)
+		}
+
+		items, err := collectThreadItems(instagramAPI, thread, conversation.Attributes.LastSyncedThreadItemID)
+		if err != nil {
+			return fmt.Errorf("Failed to collect pending thread [%s]. %w", thread.ID, err)
+		}
+
+		if err := storeThreadItems(runtimeContext, account, conversation, items); err != nil {
+			return fmt.Errorf("Failed to store pending thread [%s]. %w", thread.ID, err)
+		}
+
+		for _, user := range thread.Users {
+			if user.ID != thread.ViewerUserID && account.PendingPolicy.Accepts(user) {
+				accept = append(accept, thread.ID)
+				break
+			}
+		}
+	}
+
+	runtimeContext.Logger().Debug(fmt.Sprintf("Pending threads [%d], accepted by policy [%d]", len(threads), len(accept)), nil)
+
+	if len(accept) == 0 {
+		return nil
+	}
+
+	return resolve_pending.Run(runtimeContext, resolve_pending.Request{
+		ExternalID: account.ExternalID,
+		Accept:     accept,
+	})
+}
@@ -0,0 +1,143 @@
+package instagram
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/model"
+	"channels-instagram-dm/domain/model/instagram"
+)
+
+// storeReactions Сверяет реакции сообщения с реакциями сообщения треда, если тред их передал
+// Каждая поставленная или снятая реакция сохраняется отдельным сообщением для доставки в Channels
+func storeReactions(runtimeContext domain.RuntimeContext, account model.Account, conversation model.Conversation, message model.Message, reactions []instagram.Reaction) (model.Message, error) {
+	if reactions == nil {
+		return message, nil
+	}
+
+	events := make([]model.Message, 0)
+
+	users := make(map[string]struct{}, len(reactions))
+	for _, item := range reactions {
+		users[item.UserID] = struct{}{}
+
+		reaction := model.Reaction{
+			UserID:    item.UserID,
+			Emoji:     item.Emoji,
+			Timestamp: item.Timestamp,
+		}
+
+		if message.SetReaction(reaction) {
+			events = append(events, newReactionMessage(account, conversation, message, reaction, false))
+		}
+	}
+
+	// Время снятия реакции тред не сообщает
+	removedAt := time.Now().UnixNano() / int64(time.Microsecond)
+
+	for _, reaction := range append([]model.Reaction(nil), message.Reactions...) {
+		if _, ok := users[reaction.UserID]; ok {
+			continue
+		}
+
+		message.RemoveReaction(reaction.UserID)
+
+		reaction.Timestamp = removedAt
+		events = append(events, newReactionMessage(account, conversation, message, reaction, true))
+	}
+
+	return storeReactionEvents(runtimeContext, message, events)
+}
+
+// storeRealtimeReaction Применяет реакцию из realtime к сохраненному сообщению
+// Реакция на еще не сохраненное сообщение придет вместе с ним при синхронизации треда
+func storeRealtimeReaction(runtimeContext domain.RuntimeContext, account model.Account, update instagram.RealtimeUpdate) error {
+	conversation, err := runtimeContext.Repository().ConversationRepository().WhereAttributeThreadID(update.ThreadID)
+	if errors.Is(err, domain.ErrorNotFound) {
+		runtimeContext.Logger().Debug(fmt.Sprintf("Reaction skipped, thread [%s] is unknown", update.ThreadID), nil)
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	message, err := runtimeContext.Repository().MessageRepository().WhereInstagramAttributeID(update.Reaction.ItemID)
+	if errors.Is(err, domain.ErrorNotFound) {
+		runtimeContext.Logger().Debug(fmt.Sprintf("Reaction skipped, thread item [%s] is unknown", update.Reaction.ItemID), nil)
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	reaction := model.Reaction{
+		UserID:    update.Reaction.UserID,
+		Emoji:     update.Reaction.Emoji,
+		Timestamp: update.Reaction.Timestamp,
+	}
+
+	if reaction.Timestamp == 0 {
+		reaction.Timestamp = time.Now().UnixNano() / int64(time.Microsecond)
+	}
+
+	events := make([]model.Message, 0, 1)
+
+	if update.Reaction.Removed {
+		if removed, ok := message.RemoveReaction(reaction.UserID); ok {
+			removed.Timestamp = reaction.Timestamp
+			events = append(events, newReactionMessage(account, conversation, message, removed, true))
+		}
+	} else if message.SetReaction(reaction) {
+		events = append(events, newReactionMessage(account, conversation, message, reaction, false))
+	}
+
+	_, err = storeReactionEvents(runtimeContext, message, events)
+
+	return err
+}
+
+func storeReactionEvents(runtimeContext domain.RuntimeContext, message model.Message, events []model.Message) (model.Message, error) {
+	if len(events) == 0 {
+		return message, nil
+	}
+
+	messageRepository := runtimeContext.Repository().MessageRepository()
+
+	message, err := messageRepository.Store(message)
+	if err != nil {
+		return message, err
+	}
+
+	for _, event := range events {
+		if _, err := messageRepository.Store(event); err != nil {
+			return message, err
+		}
+	}
+
+	return message, nil
+}
+
+func newReactionMessage(account model.Account, conversation model.Conversation, target model.Message, reaction model.Reaction, removed bool) model.Message {
+	message := model.NewMessage(account.ID, conversation.ID, model.MessageSourceInstagram)
+	message.SetInstagramAttributes(model.InstagramAttributes{
+		UserID:    reaction.UserID,
+		Timestamp: reaction.Timestamp,
+	})
+	message.SetPayload(model.MessageReaction{
+		MessageID: target.GetChannelsID(),
+		ItemID:    target.Attributes.InstagramAttributes.ID,
+		Emoji:     reaction.Emoji,
+		Remove:    removed,
+	})
+
+	// Собственные реакции аккаунта не доставляются в Channels как входящие
+	if reaction.UserID == conversation.Attributes.ViewerUserID {
+		message.DeliveredSuccess()
+	}
+
+	return message
+}
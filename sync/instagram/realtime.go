@@ -11,7 +11,59 @@ import (
 	"channels-instagram-dm/domain/model/instagram"
 )
 
-func listenRealtime(runtimeContext domain.RuntimeContext, wg *sync.WaitGroup, chUpdates chan instagram.RealtimeUpdate, account model.Account) error {
+// realtimeGap Сообщения, пришедшие во время разрыва realtime, не известны до ближайшего раунда inbox
+// Пока раунд не завершен, тред сверяется перед сохранением первого сообщения из realtime
+type realtimeGap struct {
+	mx         sync.Mutex
+	since      time.Time // Время подключения, нулевое значение - пропусков нет
+	reconciled map[string]bool
+}
+
+func newRealtimeGap() *realtimeGap {
+	return &realtimeGap{
+		reconciled: make(map[string]bool),
+	}
+}
+
+// open Отмечает подключение, после которого возможны пропуски
+func (g *realtimeGap) open() {
+	g.mx.Lock()
+	defer g.mx.Unlock()
+
+	g.since = time.Now()
+	g.reconciled = make(map[string]bool)
+}
+
+// close Снимает отметку, если раунд inbox начат после подключения
+func (g *realtimeGap) close(roundStartedAt time.Time) {
+	g.mx.Lock()
+	defer g.mx.Unlock()
+
+	if g.since.IsZero() || roundStartedAt.Before(g.since) {
+		return
+	}
+
+	g.since = time.Time{}
+	g.reconciled = make(map[string]bool)
+}
+
+func (g *realtimeGap) isReconciled(threadID string) bool {
+	g.mx.Lock()
+	defer g.mx.Unlock()
+
+	return g.since.IsZero() || g.reconciled[threadID]
+}
+
+func (g *realtimeGap) setReconciled(threadID string) {
+	g.mx.Lock()
+	defer g.mx.Unlock()
+
+	if !g.since.IsZero() {
+		g.reconciled[threadID] = true
+	}
+}
+
+func listenRealtime(runtimeContext domain.RuntimeContext, wg *sync.WaitGroup, chUpdates chan instagram.RealtimeUpdate, gap *realtimeGap, account model.Account) error {
 	wg.Add(1)
 
 	// Обрабатываем разрывы соединения
@@ -50,44 +102,135 @@ func listenRealtime(runtimeContext domain.RuntimeContext, wg *sync.WaitGroup, ch
 				continue
 			}
 
-			closed, err := instagramAPI.ListenThreadUpdates(chUpdates)
-			if err != nil {
-				runtimeContext.Logger().Error(fmt.Sprintf("Failed to listen thread updates. %s", err), nil)
-				continue
+			if !listenThreadUpdates(runtimeContext, instagramAPI, chUpdates, gap, account) {
+				return
 			}
+		}
+	}()
 
-			if closed == nil {
-				continue
-			}
+	return nil
+}
 
-			runtimeContext.Logger().Debug("Listening", nil)
+// listenThreadUpdates Слушает обновления до закрытия подписки, возвращает false, если контекст завершен
+// Подписка переживает переподключения сервиса к слоту, поэтому состояние соединения отслеживается по уведомлениям
+func listenThreadUpdates(runtimeContext domain.RuntimeContext, instagramAPI domain.InstagramAPI, chUpdates chan instagram.RealtimeUpdate, gap *realtimeGap, account model.Account) bool {
+	states, unsubscribe := instagramAPI.SubscribeOnConnectionState()
+	defer unsubscribe()
 
-			select {
-			case <-runtimeContext.Context().Done():
-				runtimeContext.Logger().Debug("Context was closed", nil)
-				return
+	closed, err := instagramAPI.ListenThreadUpdates(chUpdates)
+	if err != nil {
+		runtimeContext.Logger().Error(fmt.Sprintf("Failed to listen thread updates. %s", err), nil)
+		return true
+	}
 
-			case err := <-closed:
-				if err == nil {
-					runtimeContext.Logger().Debug("Channel was closed.", nil)
-					continue
-				}
+	if closed == nil {
+		return true
+	}
 
-				if errors.Is(err, domain.ErrorNoLoggedIn) {
-					runtimeContext.EventBus().PublishLoginAccount(domain.EventLoginAccount{
-						Account: account,
-					})
-				}
+	runtimeContext.Logger().Debug("Listening", nil)
 
-				runtimeContext.Logger().Error(fmt.Sprintf("Channel was closed. %s", err), nil)
-				continue
+	gap.open()
+
+	setRealtimeConnected(runtimeContext, account, true)
+
+	for {
+		select {
+		case <-runtimeContext.Context().Done():
+			runtimeContext.Logger().Debug("Context was closed", nil)
+			return false
+
+		case state := <-states:
+			runtimeContext.Logger().Info(fmt.Sprintf("Connection is %s", state), nil)
+
+			switch state {
+			case domain.ConnectionStateConnected:
+				gap.open()
+
+				setRealtimeConnected(runtimeContext, account, true)
+
+				// Сообщения, пришедшие во время разрыва, дособирает приближенный раунд inbox
+				runtimeContext.EventBus().PublishInboxHasChanges(domain.EventInboxHasChanges{
+					Account: account,
+				})
+			default:
+				setRealtimeConnected(runtimeContext, account, false)
 			}
+
+		case err := <-closed:
+			setRealtimeConnected(runtimeContext, account, false)
+
+			if err == nil {
+				runtimeContext.Logger().Debug("Channel was closed.", nil)
+				return true
+			}
+
+			domain.ApplyErrorPolicy(runtimeContext, account, err)
+
+			runtimeContext.Logger().Error(fmt.Sprintf("Channel was closed. %s", err), nil)
+			return true
 		}
-	}()
+	}
+}
 
-	return nil
+func setRealtimeConnected(runtimeContext domain.RuntimeContext, account model.Account, connected bool) {
+	runtimeContext.Status().Update(account.ID, func(status *domain.SyncStatus) {
+		status.Realtime.Connected = connected
+		status.Realtime.Since = time.Now()
+	})
 }
 
-func handleRealtime(runtimeContext domain.RuntimeContext, account model.Account, realtimeUpdate instagram.RealtimeUpdate) error {
+func handleRealtime(runtimeContext domain.RuntimeContext, account model.Account, gap *realtimeGap, realtimeUpdate instagram.RealtimeUpdate) error {
+	if realtimeUpdate.Reaction != nil {
+		return storeRealtimeReaction(runtimeContext, account, realtimeUpdate)
+	}
+
+	conversationRepository := runtimeContext.Repository().ConversationRepository()
+
+	conversation, err := conversationRepository.WhereAttributeThreadID(realtimeUpdate.ThreadID)
+	if err != nil && !errors.Is(err, domain.ErrorNotFound) {
+		return err
+	}
+
+	// Беседа неизвестна, не синхронизирована до последнего сообщения или после переподключения еще не сверялась -
+	// предшественник сообщения неизвестен, поэтому сначала сверяем тред целиком
+	if err != nil || !isConversationSynced(conversation) || !gap.isReconciled(realtimeUpdate.ThreadID) {
+		instagramAPI, err := runtimeContext.Service().InstagramAPI(account.Username)
+		if err != nil {
+			return err
+		}
+
+		conversation, err = reconcileThread(runtimeContext, instagramAPI, account, realtimeUpdate.ThreadID)
+		if err != nil {
+			domain.ApplyErrorPolicy(runtimeContext, account, err)
+
+			return fmt.Errorf("Failed to reconcile thread [%s]. %w", realtimeUpdate.ThreadID, err)
+		}
+
+		gap.setReconciled(realtimeUpdate.ThreadID)
+	}
+
+	message, err := storeThreadItem(runtimeContext, account, conversation, realtimeUpdate.ThreadItem)
+	if err != nil {
+		return fmt.Errorf("Failed to store thread item [%s]. %w", realtimeUpdate.ThreadItem.ID, err)
+	}
+
+	// Сообщение продолжает синхронизированную беседу без пропусков, поэтому сдвигаем и LastSyncedThreadItemID
+	// Иначе беседа останется несинхронизированной и будет сверена в следующем раунде inbox
+	if realtimeUpdate.ThreadItem.Timestamp >= conversation.Attributes.ThreadAttributes.LastActivityAt {
+		if isConversationSynced(conversation) {
+			conversation.Attributes.LastSyncedThreadItemID = realtimeUpdate.ThreadItem.ID
+		}
+
+		conversation.Attributes.ThreadAttributes.LastThreadItemID = realtimeUpdate.ThreadItem.ID
+		conversation.Attributes.ThreadAttributes.LastActivityAt = realtimeUpdate.ThreadItem.Timestamp
+		conversation.LastMessageID = message.ID
+	}
+
+	if _, err := conversationRepository.Store(conversation); err != nil {
+		return err
+	}
+
+	runtimeContext.Logger().Debug(fmt.Sprintf("Thread item [%s] stored in conversation [%s]", realtimeUpdate.ThreadItem.ID, conversation.ID), nil)
+
 	return nil
 }
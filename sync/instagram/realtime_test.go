@@ -0,0 +1,104 @@
+package instagram
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"channels-instagram-dm/domain/model/instagram"
+	"channels-instagram-dm/service/fake_slot"
+)
+
+func TestListenThreadUpdates(t *testing.T) {
+	ts := newTestSync(t)
+
+	instagramAPI, err := ts.runtimeContext.Service().InstagramAPI("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(ts.runtimeContext.Context())
+	defer cancel()
+
+	chUpdates := make(chan instagram.RealtimeUpdate)
+	gap := newRealtimeGap()
+	done := make(chan bool, 1)
+
+	go func() {
+		done <- listenThreadUpdates(ts.runtimeContext.WithContext(ctx), instagramAPI, chUpdates, gap, ts.account)
+	}()
+
+	ts.waitConnected(t)
+
+	// Тред еще не известен: сообщение сохраняется после сверки треда вместе с предыдущими
+	itemID := ts.receive(t, chUpdates, gap, "ping")
+
+	if text := ts.storedText(itemID); text != "ping" {
+		t.Fatalf("realtime: want stored message, got %q", text)
+	}
+
+	if conversation := ts.conversation(t, "t1"); conversation.Attributes.ThreadAttributes.LastThreadItemID != itemID || !isConversationSynced(conversation) {
+		t.Fatalf("realtime: want conversation synced to %s, got %+v", itemID, conversation.Attributes)
+	}
+
+	ts.slot.Disconnect()
+
+	// После переподключения приближается раунд inbox, чтобы собрать сообщения, пришедшие во время разрыва
+	waitFor(t, "inbox signal after reconnect", func() bool {
+		inboxChanges, _, _ := ts.eventBus.counts()
+		return inboxChanges > 0
+	})
+
+	ts.waitConnected(t)
+
+	itemID = ts.receive(t, chUpdates, gap, "pong")
+
+	if text := ts.storedText(itemID); text != "pong" {
+		t.Fatalf("realtime after reconnect: want stored message, got %q", text)
+	}
+
+	cancel()
+
+	select {
+	case resume := <-done:
+		if resume {
+			t.Fatal("listener: want stop on context done")
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("listener: not stopped")
+	}
+}
+
+func (ts *testSync) waitConnected(t *testing.T) {
+	t.Helper()
+
+	waitFor(t, "realtime connected", func() bool {
+		status, _ := ts.runtimeContext.Status().Get(ts.account.ID)
+		return status.Realtime.Connected
+	})
+}
+
+// receive Отправляет сообщение в t1, пока оно не придет через realtime, и обрабатывает его как цикл аккаунта
+func (ts *testSync) receive(t *testing.T, chUpdates chan instagram.RealtimeUpdate, gap *realtimeGap, text string) string {
+	t.Helper()
+
+	deadline := time.After(testTimeout)
+
+	for {
+		if _, err := ts.slot.Push("t1", fake_slot.Item{Text: text}); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case update := <-chUpdates:
+			if err := handleRealtime(ts.runtimeContext, ts.account, gap, update); err != nil {
+				t.Fatal(err)
+			}
+
+			return update.ThreadItem.ID
+		case <-time.After(100 * time.Millisecond):
+		case <-deadline:
+			t.Fatalf("update %q was not received", text)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package instagram
+
+import (
+	"errors"
+	"fmt"
+
+	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/case/add_activity_log"
+	"channels-instagram-dm/domain/model"
+)
+
+const (
+	ReconcileConversationsMax = 10 // Ограничение количества сверяемых бесед за один раунд inbox
+	ReconcileAttemptsMax      = 3  // Неудачные сверки треда подряд, после которых беседа считается синхронизированной
+)
+
+// isConversationSynced Беседа синхронизирована, если последнее сообщение треда сохранено без пропусков
+// Только в этом случае предшественник нового сообщения из realtime известен
+func isConversationSynced(conversation model.Conversation) bool {
+	return conversation.Attributes.ViewerUserID != "" &&
+		conversation.Attributes.LastSyncedThreadItemID != "" &&
+		conversation.Attributes.LastSyncedThreadItemID == conversation.Attributes.ThreadAttributes.LastThreadItemID
+}
+
+// reconcileConversations Сверяет с DirectThread беседы, в которых последнее сообщение треда не было синхронизировано
+func reconcileConversations(runtimeContext domain.RuntimeContext, account model.Account) error {
+	conversations, err := runtimeContext.Repository().ConversationRepository().WhereAccountIDUnsynced(account.ID, ReconcileConversationsMax)
+	if err != nil {
+		return err
+	}
+
+	if len(conversations) == 0 {
+		return nil
+	}
+
+	instagramAPI, err := runtimeContext.Service().InstagramAPI(account.Username)
+	if err != nil {
+		return err
+	}
+
+	for _, conversation := range conversations {
+		select {
+		case <-runtimeContext.Context().Done():
+			return runtimeContext.Context().Err()
+		default:
+		}
+
+		reconciled, err := reconcileThread(runtimeContext, instagramAPI, account, conversation.Attributes.ThreadAttributes.ID)
+
+		// Сессия, блокировка или ограничение запросов относятся к аккаунту, а не к треду
+		if err != nil && isAccountError(err) {
+			return fmt.Errorf("Failed to reconcile conversation [%s]. %w", conversation.ID, err)
+		}
+
+		if err == nil && reconciled.Attributes.LastSyncedThreadItemID == reconciled.Attributes.ThreadAttributes.LastThreadItemID {
+			if err := resetReconcileAttempts(runtimeContext, reconciled); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		reason := "last thread item was not found"
+		if err != nil {
+			reason = err.Error()
+		}
+
+		if err := failReconcile(runtimeContext, account, conversation.ID, reason); err != nil {
+			return err
+		}
+	}
+
+	runtimeContext.Logger().Debug(fmt.Sprintf("Reconciled [%d] conversations", len(conversations)), nil)
+
+	return nil
+}
+
+func isAccountError(err error) bool {
+	switch domain.NewErrorPolicy(err).Action {
+	case domain.ErrorActionRelogin, domain.ErrorActionSuspend, domain.ErrorActionBackoff:
+		return true
+	default:
+		return false
+	}
+}
+
+func resetReconcileAttempts(runtimeContext domain.RuntimeContext, conversation model.Conversation) error {
+	if conversation.Attributes.ReconcileAttempts == 0 {
+		return nil
+	}
+
+	conversation.Attributes.ReconcileAttempts = 0
+
+	_, err := runtimeContext.Repository().ConversationRepository().Store(conversation)
+
+	return err
+}
+
+// failReconcile Учитывает неудачную сверку беседы
+// Тред, который не удается сверить, не должен занимать место других бесед в каждом раунде,
+// поэтому после ReconcileAttemptsMax неудач подряд беседа считается синхронизированной
+func failReconcile(runtimeContext domain.RuntimeContext, account model.Account, conversationID, reason string) error {
+	conversationRepository := runtimeContext.Repository().ConversationRepository()
+
+	conversation, err := conversationRepository.WhereID(conversationID)
+	if err != nil {
+		return err
+	}
+
+	conversation.Attributes.ReconcileAttempts++
+
+	runtimeContext.Logger().Error(fmt.Sprintf("Failed to reconcile conversation [%s], attempt [%d]. %s",
+		conversation.ID, conversation.Attributes.ReconcileAttempts, reason), nil)
+
+	if conversation.Attributes.ReconcileAttempts < ReconcileAttemptsMax {
+		_, err = conversationRepository.Store(conversation)
+		return err
+	}
+
+	conversation.Attributes.LastSyncedThreadItemID = conversation.Attributes.ThreadAttributes.LastThreadItemID
+	conversation.Attributes.ReconcileAttempts = 0
+
+	if _, err := conversationRepository.Store(conversation); err != nil {
+		return err
+	}
+
+	_, _ = add_activity_log.Run(runtimeContext, add_activity_log.Request{
+		AccountID: account.ID,
+		Log:       fmt.Sprintf("Conversation [%s] was marked as synced after [%d] failed reconcile attempts. %s", conversation.ID, ReconcileAttemptsMax, reason),
+	})
+
+	return nil
+}
+
+// reconcileThread Дособирает сообщения треда от последнего синхронизированного до последнего сообщения треда
+func reconcileThread(runtimeContext domain.RuntimeContext, instagramAPI domain.InstagramAPI, account model.Account, threadID string) (model.Conversation, error) {
+	thread, err := instagramAPI.DirectThread(threadID, "")
+	if err != nil {
+		return model.Conversation{}, err
+	}
+
+	conversation, err := takeConversation(runtimeContext, account, thread)
+	if err != nil {
+		return conversation, err
+	}
+
+	items, err := collectThreadItems(instagramAPI, thread, conversation.Attributes.LastSyncedThreadItemID)
+	if err != nil {
+		return conversation, err
+	}
+
+	if err := storeThreadItems(runtimeContext, account, conversation, items); err != nil {
+		return conversation, err
+	}
+
+	if err := storeLastSeen(runtimeContext, account, conversation, thread.LastSeenAt); err != nil {
+		return conversation, err
+	}
+
+	conversationRepository := runtimeContext.Repository().ConversationRepository()
+
+	conversation, err = conversationRepository.WhereID(conversation.ID)
+	if err != nil {
+		return conversation, err
+	}
+
+	if conversation.Attributes.LastSyncedThreadItemID == conversation.Attributes.ThreadAttributes.LastThreadItemID {
+		return conversation, nil
+	}
+
+	// Последнее сообщение треда сохранено ранее (например, из realtime) - беседа синхронизирована
+	// Иначе беседа остается несинхронизированной и будет сверена повторно
+	_, err = runtimeContext.Repository().MessageRepository().WhereInstagramAttributeID(conversation.Attributes.ThreadAttributes.LastThreadItemID)
+	if errors.Is(err, domain.ErrorNotFound) {
+		runtimeContext.Logger().Info(fmt.Sprintf("Thread [%s] last item [%s] was not found, synced up to [%s]",
+			threadID, conversation.Attributes.ThreadAttributes.LastThreadItemID, conversation.Attributes.LastSyncedThreadItemID), nil)
+
+		return conversation, nil
+	}
+
+	if err != nil {
+		return conversation, err
+	}
+
+	conversation.Attributes.LastSyncedThreadItemID = conversation.Attributes.ThreadAttributes.LastThreadItemID
+
+	return conversationRepository.Store(conversation)
+}
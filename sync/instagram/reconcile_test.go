@@ -0,0 +1,55 @@
+package instagram
+
+import (
+	"strings"
+	"testing"
+
+	"channels-instagram-dm/domain/model"
+)
+
+func TestReconcileConversationsGivesUp(t *testing.T) {
+	ts := newTestSync(t)
+
+	conversation, err := ts.runtimeContext.Repository().ConversationRepository().Store(model.Conversation{
+		AccountID: ts.account.ID,
+		Attributes: model.ConversationAttributes{
+			ThreadAttributes: model.ThreadAttributes{
+				ID:               "t1",
+				ViewerUserID:     "1",
+				LastThreadItemID: "lost",
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts.slot.FailNext("direct@thread", "thread is broken", ReconcileAttemptsMax)
+
+	for attempt := 1; attempt < ReconcileAttemptsMax; attempt++ {
+		if err := reconcileConversations(ts.runtimeContext, ts.account); err != nil {
+			t.Fatalf("attempt %d: want thread error skipped, got %v", attempt, err)
+		}
+
+		if conversation := ts.conversation(t, "t1"); conversation.Attributes.ReconcileAttempts != attempt || isConversationSynced(conversation) {
+			t.Fatalf("attempt %d: want unsynced conversation, got %+v", attempt, conversation.Attributes)
+		}
+	}
+
+	if err := reconcileConversations(ts.runtimeContext, ts.account); err != nil {
+		t.Fatal(err)
+	}
+
+	if conversation := ts.conversation(t, "t1"); conversation.Attributes.ReconcileAttempts != 0 || !isConversationSynced(conversation) {
+		t.Fatalf("last attempt: want conversation marked as synced, got %+v", conversation.Attributes)
+	}
+
+	logs, err := ts.runtimeContext.Repository().ActivityLogRepository().WhereAccountID(ts.account.ID, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(logs) != 1 || !strings.Contains(logs[0].Log, conversation.ID) {
+		t.Fatalf("last attempt: want activity log, got %+v", logs)
+	}
+}
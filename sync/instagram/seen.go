@@ -0,0 +1,76 @@
+package instagram
+
+import (
+	"errors"
+
+	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/model"
+	"channels-instagram-dm/domain/model/instagram"
+)
+
+// storeLastSeen Сохраняет прочтения участников треда в беседе
+// Прочтение собеседником сохраненного сообщения доставляется в Channels отдельным сообщением
+func storeLastSeen(runtimeContext domain.RuntimeContext, account model.Account, conversation model.Conversation, lastSeenAt map[string]instagram.LastSeen) error {
+	changed := make(map[string]model.LastSeen)
+	for userID, seen := range lastSeenAt {
+		lastSeen := model.LastSeen{
+			ItemID:    seen.ItemID,
+			Timestamp: seen.Timestamp,
+		}
+
+		if conversation.IsLastSeenNewer(userID, lastSeen) {
+			changed[userID] = lastSeen
+		}
+	}
+
+	if len(changed) == 0 {
+		return nil
+	}
+
+	conversationRepository := runtimeContext.Repository().ConversationRepository()
+	messageRepository := runtimeContext.Repository().MessageRepository()
+
+	// Беседа перечитывается, чтобы не затереть сдвиг синхронизации, сохраненный после ее получения
+	conversation, err := conversationRepository.WhereID(conversation.ID)
+	if err != nil {
+		return err
+	}
+
+	for userID, seen := range changed {
+		if !conversation.SetLastSeen(userID, seen) || userID == conversation.Attributes.ViewerUserID {
+			continue
+		}
+
+		target, err := messageRepository.WhereInstagramAttributeID(seen.ItemID)
+		if errors.Is(err, domain.ErrorNotFound) {
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if _, err := messageRepository.Store(newSeenMessage(account, conversation, target, userID, seen)); err != nil {
+			return err
+		}
+	}
+
+	_, err = conversationRepository.Store(conversation)
+
+	return err
+}
+
+func newSeenMessage(account model.Account, conversation model.Conversation, target model.Message, userID string, seen model.LastSeen) model.Message {
+	message := model.NewMessage(account.ID, conversation.ID, model.MessageSourceInstagram)
+	message.SetInstagramAttributes(model.InstagramAttributes{
+		UserID:    userID,
+		Timestamp: seen.Timestamp,
+	})
+	message.SetPayload(model.MessageSeen{
+		MessageID: target.GetChannelsID(),
+		ItemID:    seen.ItemID,
+		Timestamp: seen.Timestamp,
+	})
+
+	return message
+}
@@ -0,0 +1,187 @@
+package instagram
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/model"
+	"channels-instagram-dm/domain/model/instagram"
+)
+
+const (
+	ThreadPagesMax = 5 // Ограничение глубины дозагрузки треда до последнего сохраненного сообщения
+)
+
+func syncThread(runtimeContext domain.RuntimeContext, instagramAPI domain.InstagramAPI, account model.Account, thread instagram.ThreadWithItems) error {
+	conversation, err := takeConversation(runtimeContext, account, thread)
+	if err != nil {
+		return err
+	}
+
+	items, err := collectThreadItems(instagramAPI, thread, conversation.Attributes.LastSyncedThreadItemID)
+	if err != nil {
+		return err
+	}
+
+	if err := storeThreadItems(runtimeContext, account, conversation, items); err != nil {
+		return err
+	}
+
+	if err := syncPageReactions(runtimeContext, account, conversation, thread.Items, items); err != nil {
+		return err
+	}
+
+	return storeLastSeen(runtimeContext, account, conversation, thread.LastSeenAt)
+}
+
+// syncPageReactions Сверяет реакции уже сохраненных сообщений из inbox, новые сообщения сверены при сохранении
+func syncPageReactions(runtimeContext domain.RuntimeContext, account model.Account, conversation model.Conversation, pageItems, storedItems []instagram.ThreadItem) error {
+	stored := make(map[string]struct{}, len(storedItems))
+	for _, item := range storedItems {
+		stored[item.ID] = struct{}{}
+	}
+
+	messageRepository := runtimeContext.Repository().MessageRepository()
+
+	for _, item := range pageItems {
+		if _, ok := stored[item.ID]; ok {
+			continue
+		}
+
+		message, err := messageRepository.WhereInstagramAttributeID(item.ID)
+		if errors.Is(err, domain.ErrorNotFound) {
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if _, err := storeReactions(runtimeContext, account, conversation, message, item.Reactions); err != nil {
+			return fmt.Errorf("Failed to store reactions of thread item [%s]. %w", item.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// takeConversation Находит беседу по треду или создает новую, актуализируя атрибуты треда и собеседника
+func takeConversation(runtimeContext domain.RuntimeContext, account model.Account, thread instagram.ThreadWithItems) (model.Conversation, error) {
+	conversationRepository := runtimeContext.Repository().ConversationRepository()
+
+	conversation, err := conversationRepository.WhereAttributeThreadID(thread.ID)
+	if err != nil {
+		if !errors.Is(err, domain.ErrorNotFound) {
+			return conversation, err
+		}
+
+		conversation = model.NewConversation(account.ID)
+	}
+
+	conversation.SetThreadAttributes(thread.Thread)
+
+	for _, user := range thread.Users {
+		if user.ID != thread.ViewerUserID {
+			conversation.SetUserAttributes(user)
+			break
+		}
+	}
+
+	return conversationRepository.Store(conversation)
+}
+
+// collectThreadItems Возвращает в хронологическом порядке сообщения треда, которые новее lastSyncedItemID
+func collectThreadItems(instagramAPI domain.InstagramAPI, thread instagram.ThreadWithItems, lastSyncedItemID string) ([]instagram.ThreadItem, error) {
+	items := make([]instagram.ThreadItem, 0, len(thread.Items))
+
+	page := thread
+	for i := 0; ; i++ {
+		for _, item := range page.Items {
+			if lastSyncedItemID != "" && item.ID == lastSyncedItemID {
+				return sortThreadItems(items), nil
+			}
+
+			items = append(items, item)
+		}
+
+		// Для новой беседы ограничиваемся сообщениями из inbox, история загружается отдельно
+		if lastSyncedItemID == "" || !page.HasOlder || page.OldestCursor == "" || i == ThreadPagesMax {
+			return sortThreadItems(items), nil
+		}
+
+		next, err := instagramAPI.DirectThread(thread.ID, page.OldestCursor)
+		if err != nil {
+			return nil, err
+		}
+
+		page = next
+	}
+}
+
+func sortThreadItems(items []instagram.ThreadItem) []instagram.ThreadItem {
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Timestamp < items[j].Timestamp
+	})
+
+	return items
+}
+
+func storeThreadItems(runtimeContext domain.RuntimeContext, account model.Account, conversation model.Conversation, items []instagram.ThreadItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	for _, item := range items {
+		message, err := storeThreadItem(runtimeContext, account, conversation, item)
+		if err != nil {
+			return fmt.Errorf("Failed to store thread item [%s]. %w", item.ID, err)
+		}
+
+		conversation.Attributes.LastSyncedThreadItemID = item.ID
+		conversation.LastMessageID = message.ID
+	}
+
+	_, err := runtimeContext.Repository().ConversationRepository().Store(conversation)
+
+	return err
+}
+
+// storeThreadItem Сохраняет сообщение треда, если оно еще не было сохранено
+func storeThreadItem(runtimeContext domain.RuntimeContext, account model.Account, conversation model.Conversation, item instagram.ThreadItem) (model.Message, error) {
+	messageRepository := runtimeContext.Repository().MessageRepository()
+
+	message, err := messageRepository.WhereInstagramAttributeID(item.ID)
+	if err == nil {
+		return storeReactions(runtimeContext, account, conversation, message, item.Reactions)
+	}
+
+	if !errors.Is(err, domain.ErrorNotFound) {
+		return message, err
+	}
+
+	message, err = messageRepository.Store(newThreadItemMessage(account, conversation, item))
+	if err != nil {
+		return message, err
+	}
+
+	return storeReactions(runtimeContext, account, conversation, message, item.Reactions)
+}
+
+func newThreadItemMessage(account model.Account, conversation model.Conversation, item instagram.ThreadItem) model.Message {
+	message := model.NewMessage(account.ID, conversation.ID, model.MessageSourceInstagram)
+	message.SetInstagramAttributes(model.InstagramAttributes{
+		ID:        item.ID,
+		UserID:    item.UserID,
+		Timestamp: item.Timestamp,
+	})
+	message.SetPayload(model.GetInstagramMessagePayload(item))
+
+	// Собственные сообщения аккаунта не доставляются в Channels как входящие
+	if item.UserID == conversation.Attributes.ViewerUserID {
+		message.DeliveredSuccess()
+	}
+
+	return message
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/case/release_slot"
 	"channels-instagram-dm/domain/model"
 )
 
@@ -27,6 +28,9 @@ func Run(runtimeContext domain.RuntimeContext) {
 		chSubscribeOnSuspendAccount := runtimeContext.EventBus().SubscribeOnSuspendAccount(nil)
 		chSubscribeOnAccountLogout := runtimeContext.EventBus().SubscribeOnAccountLogout(nil)
 
+		// Подписки оформлены, можно восстанавливать аккаунты
+		go restoreAccounts(runtimeContext.WithLogger(runtimeContext.Logger().Copy("RESTORE")))
+
 		for {
 			select {
 			case <-runtimeContext.Context().Done():
@@ -88,6 +92,12 @@ func Run(runtimeContext domain.RuntimeContext) {
 				<-proc.done
 				delete(terminateMap, e.Account.ID)
 
+				// Слотом аккаунта распоряжается migrate_account
+				if e.Account.StateReason == model.AccountStateReasonMigration {
+					runtimeContext.Logger().Info(fmt.Sprintf("Event SubscribeOnAccountSuspended: Done with account [%s] for migration", e.Account.ExternalID), nil)
+					continue
+				}
+
 				if api, err := runtimeContext.Service().InstagramAPI(e.Account.Username); err != nil {
 					runtimeContext.Logger().Error(fmt.Sprintf("Event SubscribeOnAccountSuspended: Failed get InstagramAPI with account [%s]. %s", e.Account.ExternalID, err), nil)
 				} else {
@@ -116,27 +126,22 @@ func Run(runtimeContext domain.RuntimeContext) {
 			case e := <-chSubscribeOnAccountLogout:
 				runtimeContext.Logger().Info(fmt.Sprintf("Event SubscribeOnAccountLogout: Processing with account [%s]", e.Account.ExternalID), nil)
 
-				_, ok := terminateMap[e.Account.ID]
-
-				// Logout, когда аккаунт активен
-				if ok {
-					runtimeContext.EventBus().PublishSuspendAccount(domain.EventSuspendAccount{
-						Reason:  model.AccountStateReasonNoLoggedIn,
-						Account: e.Account,
-					})
-				}
+				// Logout, когда аккаунт активен. Аккаунт останавливается здесь же, иначе его цикл снова займет слот
+				if proc, ok := terminateMap[e.Account.ID]; ok {
+					logger := runtimeContext.Logger().Copy(proc.account.ExternalID)
 
-				// Logout, когда аккаунт не добавлен
-				if !ok {
-					if api, err := runtimeContext.Service().InstagramAPI(e.Account.Username); err != nil {
-						runtimeContext.Logger().Error(fmt.Sprintf("Event SubscribeOnAccountLogout: Failed get InstagramAPI with account [%s]. %s", e.Account.ExternalID, err), nil)
-					} else {
-						api.Close()
+					if err := stopAccount(runtimeContext.WithLogger(logger), proc.account, model.AccountStateReasonNoLoggedIn); err != nil {
+						runtimeContext.Logger().Error(fmt.Sprintf("Event SubscribeOnAccountLogout: Failed stop with account [%s]. %s", e.Account.ExternalID, err), nil)
 					}
 
-					if err := runtimeContext.Service().RefreshSlots(); err != nil {
-						runtimeContext.Logger().Error(fmt.Sprintf("Event SubscribeOnAccountLogout: Failed RefreshSlots with account [%s]. %s", e.Account.ExternalID, err), nil)
-					}
+					proc.cancel()
+					<-proc.done
+					delete(terminateMap, e.Account.ID)
+				}
+
+				// Сессии на слоте больше нет: слот и закрепление освобождаются
+				if err := release_slot.Run(runtimeContext, release_slot.Request{Username: e.Account.Username}); err != nil {
+					runtimeContext.Logger().Error(fmt.Sprintf("Event SubscribeOnAccountLogout: Failed release slot with account [%s]. %s", e.Account.ExternalID, err), nil)
 				}
 
 				runtimeContext.Logger().Info(fmt.Sprintf("Event SubscribeOnAccountLogout: Done with account [%s]", e.Account.ExternalID), nil)
@@ -0,0 +1,183 @@
+package outbound_message
+
+import (
+	"errors"
+	"fmt"
+
+	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/case/get_message"
+	"channels-instagram-dm/domain/case/mark_seen"
+	"channels-instagram-dm/domain/case/send_message"
+	"channels-instagram-dm/domain/model"
+	"channels-instagram-dm/domain/model/channels"
+	"channels-instagram-dm/mq"
+)
+
+// Listen Подписка закрывается вместе с consumer при остановке аккаунта
+func Listen(runtimeContext domain.RuntimeContext, consumer domain.Consumer, account model.Account) error {
+	if consumer == nil {
+		return errors.New("Consumer is nil")
+	}
+
+	return consumer.Subscribe(mq.ChannelsOutboundSubject, func(data []byte) error {
+		return handle(runtimeContext, account, data)
+	})
+}
+
+// handle Возвращаемая ошибка оставляет пакет без подтверждения, и он будет доставлен повторно
+// Пакеты, которые невозможно обработать, подтверждаются, чтобы не блокировать очередь
+func handle(runtimeContext domain.RuntimeContext, account model.Account, data []byte) error {
+	if err := runtimeContext.Context().Err(); err != nil {
+		return err
+	}
+
+	packet, err := mq.Unmarshal(data)
+	if err != nil {
+		runtimeContext.Logger().Error(fmt.Sprintf("Failed to unmarshal packet. %s", err), string(data))
+		return nil
+	}
+
+	if packet.Direction != mq.OutboundDirection {
+		return nil
+	}
+
+	// Пакет другого аккаунта
+	if packet.Integration != account.ExternalID {
+		return nil
+	}
+
+	// Аккаунт долго был остановлен: запоздавшее сообщение собеседнику уже не отправляется
+	if packet.IsExpired(mq.OutboundPacketMaxAge) {
+		runtimeContext.Logger().Error(fmt.Sprintf("Packet [%s] skipped, created at %s", packet.Uuid, packet.CreatedAt), nil)
+		return nil
+	}
+
+	channelsMessage := channels.Message{
+		ID:             packet.Data.Message.ID,
+		AccountID:      account.ID,
+		ConversationID: packet.Data.Conversation.ID,
+		Type:           packet.Data.Message.Type,
+		Text:           packet.Data.Message.Text,
+		Media: channels.Media{
+			ID:   packet.Data.Message.Media.ID,
+			Type: packet.Data.Message.Media.Type,
+			Url:  packet.Data.Message.Media.Url,
+		},
+		Link: channels.Link{
+			Url:             packet.Data.Message.Link.Url,
+			Title:           packet.Data.Message.Link.Title,
+			Summary:         packet.Data.Message.Link.Summary,
+			ImagePreviewUrl: packet.Data.Message.Link.ImagePreviewUrl,
+		},
+		Reaction: channels.Reaction{
+			MessageID: packet.Data.Message.Reaction.MessageID,
+			Emoji:     packet.Data.Message.Reaction.Emoji,
+			Remove:    packet.Data.Message.Reaction.Remove,
+		},
+		Seen: channels.Seen{
+			MessageID: packet.Data.Message.Seen.MessageID,
+			Timestamp: packet.Data.Message.Seen.Timestamp,
+		},
+	}
+
+	if err := channelsMessage.Validate(); err != nil {
+		runtimeContext.Logger().Error(fmt.Sprintf("Invalid packet [%s]. %s", packet.Uuid, err), nil)
+		return nil
+	}
+
+	// Отметка о прочтении не сохраняется и не повторяется: следующая отметка оператора ее перекроет
+	if channelsMessage.Type == channels.MessageTypeSeen {
+		if err := mark_seen.Run(runtimeContext, mark_seen.Request{
+			Account:        account,
+			ConversationID: channelsMessage.ConversationID,
+			MessageID:      channelsMessage.Seen.MessageID,
+		}); err != nil {
+			runtimeContext.Logger().Error(fmt.Sprintf("Packet [%s] skipped. %s", packet.Uuid, err), nil)
+		}
+
+		return nil
+	}
+
+	message, err := takeMessage(runtimeContext, account, channelsMessage)
+	if err != nil {
+		if errors.Is(err, domain.ErrorNotFound) || errors.Is(err, domain.ErrorInvalidArgument) {
+			runtimeContext.Logger().Error(fmt.Sprintf("Packet [%s] skipped. %s", packet.Uuid, err), nil)
+			return nil
+		}
+
+		return err
+	}
+
+	// Повторная доставка пакета, сообщение уже было обработано
+	if message.Delivered.Status != model.MessageDeliveryStatusNone {
+		return nil
+	}
+
+	_, err = send_message.Run(runtimeContext, send_message.Request{
+		Account: account,
+		Message: message,
+	})
+
+	return err
+}
+
+func takeMessage(runtimeContext domain.RuntimeContext, account model.Account, channelsMessage channels.Message) (model.Message, error) {
+	messageRepository := runtimeContext.Repository().MessageRepository()
+
+	message, err := messageRepository.WhereChannelsAttributeID(channelsMessage.ID)
+	if err == nil {
+		return message, nil
+	}
+
+	if !errors.Is(err, domain.ErrorNotFound) {
+		return message, err
+	}
+
+	conversation, err := runtimeContext.Repository().ConversationRepository().WhereID(channelsMessage.ConversationID)
+	if err != nil {
+		return message, err
+	}
+
+	if conversation.AccountID != account.ID {
+		return message, domain.NewErrorNotFound(fmt.Sprintf("Conversation [%s] belongs to another account", conversation.ID))
+	}
+
+	message = model.NewMessage(account.ID, conversation.ID, model.MessageSourceChannels)
+	message.SetChannelsAttributes(model.ChannelsAttributes{
+		ID: channelsMessage.ID,
+	})
+
+	payload := model.GetChannelsMessagePayload(channelsMessage)
+
+	if reaction, ok := payload.(model.MessageReaction); ok {
+		if payload, err = takeReactionTarget(runtimeContext, conversation, reaction); err != nil {
+			return message, err
+		}
+	}
+
+	message.SetPayload(payload)
+
+	return messageRepository.Store(message)
+}
+
+// takeReactionTarget Находит сообщение, на которое ставится реакция, и дополняет реакцию его идентификатором в Instagram
+// Channels знает сообщения из Channels по своему идентификатору, а сообщения из Instagram - по идентификатору сервиса
+func takeReactionTarget(runtimeContext domain.RuntimeContext, conversation model.Conversation, reaction model.MessageReaction) (model.MessageReaction, error) {
+	resp, err := get_message.Run(runtimeContext, get_message.Request{
+		ConversationID: conversation.ID,
+		MessageID:      reaction.MessageID,
+	})
+	if err != nil {
+		return reaction, err
+	}
+
+	target := resp.Message
+
+	if target.Attributes.InstagramAttributes.ID == "" {
+		return reaction, domain.NewErrorInvalidArgument(fmt.Sprintf("Reaction target [%s] has no Instagram item", reaction.MessageID))
+	}
+
+	reaction.ItemID = target.Attributes.InstagramAttributes.ID
+
+	return reaction, nil
+}
@@ -0,0 +1,128 @@
+package sync
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/case/add_activity_log"
+	"channels-instagram-dm/domain/case/resume_account"
+	"channels-instagram-dm/domain/model"
+)
+
+const (
+	RestoreDelay     = 15 * time.Second // Задержка перед восстановлением, пока поднимаются слоты
+	RestoreStagger   = 10 * time.Second // Интервал между запусками аккаунтов
+	RestoreJitterMax = 5 * time.Second
+)
+
+// restoreAccounts Восстанавливает аккаунты, работавшие до перезапуска сервиса
+// Запуски разнесены во времени, чтобы не создавать шквал авторизаций на слотах
+func restoreAccounts(runtimeContext domain.RuntimeContext) {
+	accounts, err := restorableAccounts(runtimeContext)
+	if err != nil {
+		runtimeContext.Logger().Error(fmt.Sprintf("Restore: Failed to get accounts. %s", err), nil)
+		return
+	}
+
+	runtimeContext.Logger().Info(fmt.Sprintf("Restore: Found [%d] accounts", len(accounts)), nil)
+
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	delay := RestoreDelay
+
+	for _, account := range accounts {
+		timer := time.NewTimer(delay + time.Duration(r.Int63n(int64(RestoreJitterMax))))
+
+		select {
+		case <-runtimeContext.Context().Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		delay = RestoreStagger
+
+		restoreAccount(runtimeContext, account)
+	}
+
+	runtimeContext.Logger().Info("Restore: Done", nil)
+}
+
+func restorableAccounts(runtimeContext domain.RuntimeContext) ([]model.Account, error) {
+	accountRepository := runtimeContext.Repository().AccountRepository()
+
+	active, err := accountRepository.WhereState(model.AccountStateActive)
+	if err != nil {
+		return nil, err
+	}
+
+	suspended, err := accountRepository.WhereState(model.AccountStateSuspend)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := make([]model.Account, 0, len(active)+len(suspended))
+	accounts = append(accounts, active...)
+
+	for _, account := range suspended {
+		if isRestorableReason(account.StateReason) {
+			accounts = append(accounts, account)
+		}
+	}
+
+	return accounts, nil
+}
+
+// isRestorableReason Остановка не требует вмешательства оператора
+// Ограничение запросов и сбой прокси за время перезапуска могли закончиться
+func isRestorableReason(reason string) bool {
+	switch reason {
+	case model.AccountStateReasonServiceStopped, model.AccountStateReasonRateLimited, model.AccountStateReasonProxyFailed:
+		return true
+	default:
+		return false
+	}
+}
+
+func restoreAccount(runtimeContext domain.RuntimeContext, account model.Account) {
+	runtimeContext.Logger().Info(fmt.Sprintf("Restore: Processing with account [%s]", account.ExternalID), nil)
+
+	// За время задержки аккаунт мог быть остановлен, удален или запущен через API
+	current, err := runtimeContext.Repository().AccountRepository().WhereID(account.ID)
+	if err != nil {
+		runtimeContext.Logger().Error(fmt.Sprintf("Restore: Failed to get account [%s]. %s", account.ExternalID, err), nil)
+		return
+	}
+
+	if current.State != account.State || current.StateReason != account.StateReason {
+		runtimeContext.Logger().Info(fmt.Sprintf("Restore: Skipped account [%s], state was changed", account.ExternalID), nil)
+		return
+	}
+
+	if _, running := runtimeContext.Status().Get(account.ID); running {
+		runtimeContext.Logger().Info(fmt.Sprintf("Restore: Skipped account [%s], already running", account.ExternalID), nil)
+		return
+	}
+
+	account = current
+
+	// Аккаунт остановлен сервисом: штатное возобновление опубликует EventAccountResumed
+	if account.State == model.AccountStateSuspend {
+		if err := resume_account.Run(runtimeContext, resume_account.Request{ExternalID: account.ExternalID}); err != nil {
+			runtimeContext.Logger().Error(fmt.Sprintf("Restore: Failed with account [%s]. %s", account.ExternalID, err), nil)
+		}
+
+		return
+	}
+
+	// Аккаунт остался активным после аварийной остановки сервиса
+	_, _ = add_activity_log.Run(runtimeContext, add_activity_log.Request{
+		AccountID: account.ID,
+		Log:       "Account was restored after service restart",
+	})
+
+	runtimeContext.EventBus().PublishAccountResumed(domain.EventAccountResumed{
+		Account: account,
+	})
+}
@@ -0,0 +1,182 @@
+package transfer_message
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"channels-instagram-dm/domain"
+	"channels-instagram-dm/domain/case/send_message"
+	"channels-instagram-dm/domain/model"
+	"channels-instagram-dm/mq"
+)
+
+func Listen(runtimeContext domain.RuntimeContext, wg *sync.WaitGroup, ch chan model.MessagesBatch, producer domain.Producer, account model.Account) error {
+	if producer == nil {
+		return errors.New("Producer is nil")
+	}
+
+	wg.Add(1)
+
+	go func() {
+		defer func() {
+			wg.Done()
+		}()
+
+		for {
+			select {
+			case <-runtimeContext.Context().Done():
+				runtimeContext.Logger().Debug("Context was closed", nil)
+				return
+			case batch, ok := <-ch:
+				if !ok {
+					runtimeContext.Logger().Debug("Channel was closed", nil)
+					return
+				}
+
+				transferBatch(runtimeContext, producer, account, batch)
+			}
+		}
+	}()
+
+	return nil
+}
+
+func transferBatch(runtimeContext domain.RuntimeContext, producer domain.Producer, account model.Account, batch model.MessagesBatch) {
+	for conversationID, messages := range batch {
+		select {
+		case <-runtimeContext.Context().Done():
+			return
+		default:
+		}
+
+		conversation, err := runtimeContext.Repository().ConversationRepository().WhereID(conversationID)
+		if err != nil {
+			runtimeContext.Logger().Error(fmt.Sprintf("Failed to get conversation [%s]. %s", conversationID, err), nil)
+			continue
+		}
+
+		if err := transferConversation(runtimeContext, producer, account, conversation, messages); err != nil {
+			runtimeContext.Logger().Error(fmt.Sprintf("Failed to transfer conversation [%s]. %s", conversationID, err), nil)
+		}
+	}
+}
+
+// transferConversation Доставляет сообщения беседы строго по порядку в каждом направлении
+// При первой же временной ошибке доставка направления прерывается, чтобы последующие сообщения не обогнали недоставленное
+// Отклоненное сообщение не будет доставлено никогда, поэтому очередь за ним продолжается
+func transferConversation(runtimeContext domain.RuntimeContext, producer domain.Producer, account model.Account, conversation model.Conversation, messages []model.Message) error {
+	sort.SliceStable(messages, func(i, j int) bool {
+		if messages[i].Attributes.InstagramAttributes.Timestamp != messages[j].Attributes.InstagramAttributes.Timestamp {
+			return messages[i].Attributes.InstagramAttributes.Timestamp < messages[j].Attributes.InstagramAttributes.Timestamp
+		}
+
+		return messages[i].CreatedAt.Before(messages[j].CreatedAt)
+	})
+
+	var errInbound, errOutbound error
+
+	for _, message := range messages {
+		switch message.Source {
+		case model.MessageSourceInstagram:
+			if errInbound != nil {
+				continue
+			}
+
+			if err := transferMessage(runtimeContext, producer, account, conversation, message); err != nil {
+				if domain.IsPermanentError(err) {
+					runtimeContext.Logger().Error(fmt.Sprintf("Message [%s] was rejected. %s", message.ID, err), nil)
+					continue
+				}
+
+				errInbound = fmt.Errorf("Message [%s]. %w", message.ID, err)
+			}
+
+		case model.MessageSourceChannels:
+			if errOutbound != nil {
+				continue
+			}
+
+			resp, err := send_message.Run(runtimeContext, send_message.Request{
+				Account: account,
+				Message: message,
+			})
+			if err != nil {
+				errOutbound = fmt.Errorf("Message [%s]. %w", message.ID, err)
+				continue
+			}
+
+			switch resp.Message.Delivered.Status {
+			case model.MessageDeliveryStatusSuccess:
+			case model.MessageDeliveryStatusRejected:
+				runtimeContext.Logger().Error(fmt.Sprintf("Message [%s] was rejected", message.ID), nil)
+			default:
+				errOutbound = fmt.Errorf("Message [%s] was not sent", message.ID)
+			}
+		}
+	}
+
+	if errInbound != nil {
+		return errInbound
+	}
+
+	return errOutbound
+}
+
+func transferMessage(runtimeContext domain.RuntimeContext, producer domain.Producer, account model.Account, conversation model.Conversation, message model.Message) error {
+	messageRepository := runtimeContext.Repository().MessageRepository()
+
+	message.DeliveredWaiting()
+
+	message, err := messageRepository.Store(message)
+	if err != nil {
+		return err
+	}
+
+	payload := mq.Payload{
+		Message: mq.NewMessage(model.GetChannelsMessage(message)),
+		Conversation: mq.Conversation{
+			ID:      conversation.ID,
+			Pending: conversation.Attributes.ThreadAttributes.Pending,
+		},
+		Sender: mq.Sender{
+			ID:       conversation.Attributes.UserAttributes.ID,
+			Username: conversation.Attributes.UserAttributes.Username,
+			Avatar:   conversation.Attributes.UserAttributes.Avatar,
+		},
+		Timestamp: message.Attributes.InstagramAttributes.Timestamp,
+	}
+
+	data, err := mq.Marshal(mq.AppName, mq.InboundDirection, account.ExternalID, payload)
+	if err != nil {
+		// Сообщение, которое не удалось сериализовать, не будет доставлено и при повторе
+		message.DeliveredReject()
+
+		if _, errStore := messageRepository.Store(message); errStore != nil {
+			runtimeContext.Logger().Error(fmt.Sprintf("Failed to store delivery status of message [%s]. %s", message.ID, errStore), nil)
+		}
+
+		return domain.NewErrorInvalidArgument(err.Error())
+	}
+
+	if err := producer.Publish(mq.ChannelsSubject, data); err != nil {
+		message.DeliveredFail()
+
+		if _, errStore := messageRepository.Store(message); errStore != nil {
+			runtimeContext.Logger().Error(fmt.Sprintf("Failed to store delivery status of message [%s]. %s", message.ID, errStore), nil)
+		}
+
+		return err
+	}
+
+	message.DeliveredSuccess()
+
+	if _, err := messageRepository.Store(message); err != nil {
+		return err
+	}
+
+	runtimeContext.Logger().Debug(fmt.Sprintf("Message [%s] delivered", message.ID), nil)
+
+	return nil
+}
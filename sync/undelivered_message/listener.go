@@ -55,7 +55,13 @@ func Listen(runtimeContext domain.RuntimeContext, wg *sync.WaitGroup, ch chan mo
 				batch[message.ConversationID] = append(ms, message)
 			}
 
-			ch <- batch
+			select {
+			case <-runtimeContext.Context().Done():
+				runtimeContext.Logger().Debug("Context was closed", nil)
+				return
+			case ch <- batch:
+			}
+
 			ticker.Reset(tickDuration)
 		}
 	}()